@@ -1,6 +1,14 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"engkids/pkg/cron"
+)
 
 func GetEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -8,3 +16,328 @@ func GetEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// Config — настройки приложения, читаются из окружения один раз при запуске
+type Config struct {
+	AppName   string
+	Port      string
+	DB        DB
+	Logs      Logs
+	Timeout   Timeout
+	Jobs      Jobs
+	Scheduler Scheduler
+	Mail      Mail
+	Game      Game
+	Realtime  Realtime
+	Storage   Storage
+	// IdempotencyTTL — сколько хранить ответы на запросы с Idempotency-Key
+	IdempotencyTTL time.Duration
+}
+
+// DB — параметры подключения к Postgres
+type DB struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+}
+
+// Logs — куда пишутся и откуда читаются логи
+type Logs struct {
+	Dir              string // каталог файлового хранилища логов
+	LogstashAddr     string // host:port TCP-входа Logstash; пусто — не отправлять
+	ElasticsearchURL string // пусто — искать логи в файлах
+	Index            string // шаблон индексов с логами; пусто — по умолчанию
+}
+
+// Timeout — дедлайны обработки запросов
+type Timeout struct {
+	Default time.Duration
+	Routes  map[string]time.Duration // префикс пути -> дедлайн
+}
+
+// Jobs — воркеры фоновых задач
+type Jobs struct {
+	Queues    map[string]int // очередь -> число параллельных задач
+	InProcess bool           // выполнять задачи в процессе HTTP-сервера
+}
+
+// Scheduler — периодические задачи обслуживания
+type Scheduler struct {
+	Enabled        bool
+	Location       *time.Location // часовой пояс расписаний и дней серий
+	Retention      time.Duration  // сколько хранить мягко удалённые записи
+	DigestSchedule string         // cron-выражение рассылки итогов недели; пусто — не рассылать
+}
+
+// Mail — SMTP для писем родителям; без SMTP_ADDR письма только пишутся в лог
+type Mail struct {
+	SMTPAddr string
+	From     string
+	Username string
+	Password string
+}
+
+// Game — викторина для нескольких игроков
+type Game struct {
+	QuestionTime time.Duration // время на ответ
+	RevealTime   time.Duration // сколько показывать правильный ответ
+	Rounds       int           // вопросов в игре, если урок позволяет
+	MaxPlayers   int
+	LobbyTTL     time.Duration // через сколько удалить игру, которую так и не начали
+}
+
+// Realtime — события в реальном времени (WebSocket и SSE)
+type Realtime struct {
+	EventLogSize int // сколько последних событий пользователя хранить для Last-Event-ID
+}
+
+// Storage — файлы медиа: картинки и аудио уроков
+type Storage struct {
+	Driver       string // local или s3
+	Dir          string // каталог локального хранилища
+	PublicURL    string // адрес, по которому приложение раздаёт файлы локального хранилища
+	SigningKey   string // ключ подписи ссылок на файлы локального хранилища
+	S3           S3
+	URLTTL       time.Duration // срок действия ссылок на файлы
+	MaxImageSize int64         // байт
+	MaxAudioSize int64         // байт
+}
+
+// S3 — S3-совместимое хранилище (AWS S3, MinIO)
+type S3 struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // адреса endpoint/bucket/key, как у MinIO
+}
+
+// Load читает настройки из переменных окружения
+func Load() (*Config, error) {
+	timeout, err := loadTimeout()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := loadJobs()
+	if err != nil {
+		return nil, err
+	}
+	scheduler, err := loadScheduler()
+	if err != nil {
+		return nil, err
+	}
+	game, err := loadGame()
+	if err != nil {
+		return nil, err
+	}
+	logSize, err := strconv.Atoi(GetEnv("EVENT_LOG_SIZE", "100"))
+	if err != nil || logSize <= 0 {
+		return nil, fmt.Errorf("EVENT_LOG_SIZE: expected positive number, got %q", GetEnv("EVENT_LOG_SIZE", "100"))
+	}
+	storage, err := loadStorage()
+	if err != nil {
+		return nil, err
+	}
+	idempotencyTTL, err := time.ParseDuration(GetEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || idempotencyTTL <= 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_TTL: expected positive duration, got %q", GetEnv("IDEMPOTENCY_TTL", "24h"))
+	}
+
+	return &Config{
+		AppName: GetEnv("APP_NAME", "engkids"),
+		Port:    GetEnv("PORT", "3000"),
+		DB: DB{
+			Host:     GetEnv("DB_HOST", ""),
+			Port:     GetEnv("DB_PORT", ""),
+			User:     GetEnv("DB_USER", ""),
+			Password: GetEnv("DB_PASSWORD", ""),
+			Name:     GetEnv("DB_NAME", ""),
+		},
+		Logs: Logs{
+			Dir:              GetEnv("LOG_DIR", "logs"),
+			LogstashAddr:     GetEnv("LOGSTASH_ADDR", ""),
+			ElasticsearchURL: GetEnv("ELASTICSEARCH_URL", ""),
+			Index:            GetEnv("LOGS_INDEX", ""),
+		},
+		Timeout:   timeout,
+		Jobs:      jobs,
+		Scheduler: scheduler,
+		Mail: Mail{
+			SMTPAddr: GetEnv("SMTP_ADDR", ""),
+			From:     GetEnv("SMTP_FROM", "EngKids <noreply@engkids.local>"),
+			Username: GetEnv("SMTP_USERNAME", ""),
+			Password: GetEnv("SMTP_PASSWORD", ""),
+		},
+		Game:           game,
+		Realtime:       Realtime{EventLogSize: logSize},
+		Storage:        storage,
+		IdempotencyTTL: idempotencyTTL,
+	}, nil
+}
+
+// loadTimeout читает REQUEST_TIMEOUT (по умолчанию 10s, 0 — без дедлайна) и
+// REQUEST_TIMEOUT_ROUTES — дедлайны отдельных маршрутов вида "/api/logs=30s,/api/auth=5s"
+func loadTimeout() (Timeout, error) {
+	def, err := time.ParseDuration(GetEnv("REQUEST_TIMEOUT", "10s"))
+	if err != nil {
+		return Timeout{}, fmt.Errorf("REQUEST_TIMEOUT: %w", err)
+	}
+
+	t := Timeout{Default: def, Routes: map[string]time.Duration{
+		// поиск по логам в Elasticsearch бывает заметно дольше остальных запросов
+		"/api/logs": 30 * time.Second,
+	}}
+	for _, item := range strings.Split(GetEnv("REQUEST_TIMEOUT_ROUTES", ""), ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(item, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return Timeout{}, fmt.Errorf("REQUEST_TIMEOUT_ROUTES: expected /prefix=duration, got %q", item)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return Timeout{}, fmt.Errorf("REQUEST_TIMEOUT_ROUTES: %s: %w", prefix, err)
+		}
+		t.Routes[strings.TrimSpace(prefix)] = d
+	}
+	return t, nil
+}
+
+// loadJobs читает JOB_QUEUES вида "default=2,emails=1" и JOB_WORKERS_IN_PROCESS
+// (false — задачи выполняет только отдельный процесс engkids worker)
+func loadJobs() (Jobs, error) {
+	j := Jobs{Queues: map[string]int{}, InProcess: GetEnv("JOB_WORKERS_IN_PROCESS", "true") != "false"}
+	for _, item := range strings.Split(GetEnv("JOB_QUEUES", "default=2"), ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || n <= 0 {
+			return Jobs{}, fmt.Errorf("JOB_QUEUES: expected queue=concurrency, got %q", item)
+		}
+		j.Queues[strings.TrimSpace(name)] = n
+	}
+	return j, nil
+}
+
+// loadScheduler читает SCHEDULER_ENABLED, SCHEDULER_TIMEZONE (имя из базы IANA),
+// SOFT_DELETE_RETENTION (по умолчанию 30 дней) и DIGEST_SCHEDULE
+func loadScheduler() (Scheduler, error) {
+	loc, err := time.LoadLocation(GetEnv("SCHEDULER_TIMEZONE", "Europe/Moscow"))
+	if err != nil {
+		return Scheduler{}, fmt.Errorf("SCHEDULER_TIMEZONE: %w", err)
+	}
+	retention, err := time.ParseDuration(GetEnv("SOFT_DELETE_RETENTION", "720h"))
+	if err != nil {
+		return Scheduler{}, fmt.Errorf("SOFT_DELETE_RETENTION: %w", err)
+	}
+	digest := GetEnv("DIGEST_SCHEDULE", "0 18 * * 0")
+	if digest != "" {
+		if _, err := cron.Parse(digest); err != nil {
+			return Scheduler{}, fmt.Errorf("DIGEST_SCHEDULE: %w", err)
+		}
+	}
+	return Scheduler{
+		Enabled:        GetEnv("SCHEDULER_ENABLED", "true") != "false",
+		Location:       loc,
+		Retention:      retention,
+		DigestSchedule: digest,
+	}, nil
+}
+
+// loadGame читает GAME_QUESTION_TIME, GAME_REVEAL_TIME, GAME_ROUNDS,
+// GAME_MAX_PLAYERS и GAME_LOBBY_TTL
+func loadGame() (Game, error) {
+	g := Game{}
+	durations := []struct {
+		key, def string
+		dst      *time.Duration
+	}{
+		{"GAME_QUESTION_TIME", "15s", &g.QuestionTime},
+		{"GAME_REVEAL_TIME", "5s", &g.RevealTime},
+		{"GAME_LOBBY_TTL", "30m", &g.LobbyTTL},
+	}
+	for _, d := range durations {
+		v, err := time.ParseDuration(GetEnv(d.key, d.def))
+		if err != nil || v <= 0 {
+			return Game{}, fmt.Errorf("%s: expected positive duration, got %q", d.key, GetEnv(d.key, d.def))
+		}
+		*d.dst = v
+	}
+	ints := []struct {
+		key, def string
+		dst      *int
+	}{
+		{"GAME_ROUNDS", "10", &g.Rounds},
+		{"GAME_MAX_PLAYERS", "8", &g.MaxPlayers},
+	}
+	for _, n := range ints {
+		v, err := strconv.Atoi(GetEnv(n.key, n.def))
+		if err != nil || v <= 0 {
+			return Game{}, fmt.Errorf("%s: expected positive number, got %q", n.key, GetEnv(n.key, n.def))
+		}
+		*n.dst = v
+	}
+	return g, nil
+}
+
+// loadStorage читает STORAGE_DRIVER (local или s3), STORAGE_DIR,
+// STORAGE_PUBLIC_URL, STORAGE_SIGNING_KEY (по умолчанию JWT_SECRET_KEY),
+// S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY,
+// S3_PATH_STYLE, MEDIA_URL_TTL и лимиты загрузки MEDIA_MAX_IMAGE_MB и MEDIA_MAX_AUDIO_MB
+func loadStorage() (Storage, error) {
+	st := Storage{
+		Driver:     GetEnv("STORAGE_DRIVER", "local"),
+		Dir:        GetEnv("STORAGE_DIR", "media"),
+		PublicURL:  GetEnv("STORAGE_PUBLIC_URL", "/media"),
+		SigningKey: GetEnv("STORAGE_SIGNING_KEY", GetEnv("JWT_SECRET_KEY", "your-secret-key")),
+		S3: S3{
+			Endpoint:  GetEnv("S3_ENDPOINT", ""),
+			Region:    GetEnv("S3_REGION", "us-east-1"),
+			Bucket:    GetEnv("S3_BUCKET", ""),
+			AccessKey: GetEnv("S3_ACCESS_KEY", ""),
+			SecretKey: GetEnv("S3_SECRET_KEY", ""),
+			PathStyle: GetEnv("S3_PATH_STYLE", "false") == "true",
+		},
+	}
+	switch st.Driver {
+	case "local":
+		// ссылку, подписанную одним экземпляром, проверяет любой другой
+		if st.SigningKey == "" {
+			return Storage{}, fmt.Errorf("STORAGE_DRIVER=local requires STORAGE_SIGNING_KEY shared by all instances")
+		}
+	case "s3":
+		if st.S3.Endpoint == "" || st.S3.Bucket == "" {
+			return Storage{}, fmt.Errorf("STORAGE_DRIVER=s3 requires S3_ENDPOINT and S3_BUCKET")
+		}
+	default:
+		return Storage{}, fmt.Errorf("STORAGE_DRIVER: expected local or s3, got %q", st.Driver)
+	}
+
+	ttl, err := time.ParseDuration(GetEnv("MEDIA_URL_TTL", "1h"))
+	if err != nil || ttl <= 0 {
+		return Storage{}, fmt.Errorf("MEDIA_URL_TTL: expected positive duration, got %q", GetEnv("MEDIA_URL_TTL", "1h"))
+	}
+	st.URLTTL = ttl
+
+	limits := []struct {
+		key, def string
+		dst      *int64
+	}{
+		{"MEDIA_MAX_IMAGE_MB", "5", &st.MaxImageSize},
+		{"MEDIA_MAX_AUDIO_MB", "10", &st.MaxAudioSize},
+	}
+	for _, l := range limits {
+		mb, err := strconv.Atoi(GetEnv(l.key, l.def))
+		if err != nil || mb <= 0 {
+			return Storage{}, fmt.Errorf("%s: expected positive number, got %q", l.key, GetEnv(l.key, l.def))
+		}
+		*l.dst = int64(mb) << 20
+	}
+	return st, nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "explicit key", env: map[string]string{"STORAGE_SIGNING_KEY": "shared"}},
+		// без общего ключа ссылки одного экземпляра не проверить на другом
+		{name: "empty key", env: map[string]string{"STORAGE_SIGNING_KEY": ""}, wantErr: "STORAGE_SIGNING_KEY"},
+		{name: "empty jwt fallback", env: map[string]string{"JWT_SECRET_KEY": ""}, wantErr: "STORAGE_SIGNING_KEY"},
+		{name: "s3 without key", env: map[string]string{"STORAGE_DRIVER": "s3", "S3_ENDPOINT": "http://minio:9000", "S3_BUCKET": "media", "STORAGE_SIGNING_KEY": ""}},
+		{name: "s3 without bucket", env: map[string]string{"STORAGE_DRIVER": "s3", "S3_ENDPOINT": "http://minio:9000"}, wantErr: "S3_BUCKET"},
+		{name: "unknown driver", env: map[string]string{"STORAGE_DRIVER": "ftp"}, wantErr: "STORAGE_DRIVER"},
+		{name: "bad ttl", env: map[string]string{"MEDIA_URL_TTL": "0s"}, wantErr: "MEDIA_URL_TTL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := loadStorage()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error %v, want mention of %s", err, tt.wantErr)
+			}
+		})
+	}
+}
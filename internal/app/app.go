@@ -0,0 +1,302 @@
+// Package app собирает приложение из зависимостей: сервисы, обработчики,
+// middleware и маршруты. Глобального состояния нет, поэтому в одном процессе
+// можно поднять несколько независимых экземпляров
+package app
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"engkids/config"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/events"
+	"engkids/internal/handlers"
+	"engkids/internal/jobs"
+	"engkids/internal/middlewares"
+	"engkids/internal/realtime"
+	"engkids/internal/repositories"
+	"engkids/internal/routes"
+	"engkids/internal/scheduler"
+	"engkids/internal/services"
+	"engkids/pkg/logger"
+	"engkids/pkg/logstore"
+	"engkids/pkg/mailer"
+	"engkids/pkg/metrics"
+	"engkids/pkg/storage"
+	"engkids/pkg/tracing"
+	"engkids/pkg/websocket"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gofiber/swagger"
+	"github.com/sirupsen/logrus"
+)
+
+// Deps — внешние зависимости, которые создаются вне контейнера: в Bootstrap
+// по конфигурации, в тестах — хранилища в памяти
+type Deps struct {
+	Logger   *logrus.Logger
+	Repos    *repositories.Repositories
+	LogStore logstore.LogStore
+	Leader   scheduler.Leader  // nil — экземпляр всегда лидер
+	Mailer   mailer.Mailer     // nil — письма пишутся в лог
+	PubSub   websocket.PubSub  // nil — WebSocket-сообщения не выходят за пределы процесса
+	Storage  storage.Storage   // nil — файлы в локальном каталоге cfg.Storage.Dir
+	Metrics  *metrics.Registry // nil — собственный реестр приложения
+	Tracer   *tracing.Provider // nil — спаны не экспортируются
+}
+
+// Services — сервисы бизнес-логики
+type Services struct {
+	Auth        *services.AuthService
+	Users       *services.UserService
+	Maintenance *services.MaintenanceService
+	Digests     *services.DigestService
+	Games       *services.GameService
+	Sync        *services.SyncService
+	Words       *services.WordService
+	Media       *services.MediaService
+}
+
+// App — собранное приложение
+type App struct {
+	Config   *config.Config
+	Logger   *logrus.Logger
+	Repos    *repositories.Repositories
+	Services Services
+	Fiber    *fiber.App
+
+	// Metrics — реестр метрик, отдаваемый на /metrics; Tracer создаёт спаны
+	// запросов и фоновой работы
+	Metrics *metrics.Registry
+	Tracer  *tracing.Provider
+
+	// Events — шина доменных событий; подписчики регистрируются до StartBackground
+	Events     *events.Bus
+	Dispatcher *events.Dispatcher
+
+	// Jobs ставит фоновые задачи; обработчики регистрируются в Worker до StartWorkers
+	Jobs   *jobs.Client
+	Worker *jobs.Worker
+
+	// Hub держит WebSocket-соединения; Realtime рассылает в них доменные события
+	Hub      *websocket.Hub
+	Realtime *realtime.Publisher
+
+	// Scheduler выполняет периодические задачи; запускается StartScheduler
+	Scheduler *scheduler.Scheduler
+
+	// closers освобождают ресурсы при остановке, в обратном порядке добавления
+	closers []func(context.Context) error
+	// beforeStop завершают долгие ответы (SSE), которых HTTP-сервер ждал бы при остановке
+	beforeStop []func()
+	listening  atomic.Bool
+}
+
+// New собирает приложение из готовых зависимостей
+func New(cfg *config.Config, deps Deps) *App {
+	if deps.Metrics == nil {
+		deps.Metrics = metrics.NewRegistry()
+	}
+	business := metrics.NewBusiness(deps.Metrics)
+	bus := events.NewBus()
+	dispatcher := events.NewDispatcher(deps.Repos, bus, events.DispatcherOptions{Metrics: deps.Metrics})
+	if deps.Mailer == nil {
+		deps.Mailer = mailer.LogMailer{}
+	}
+	signer := mediaSigner(cfg.Storage.SigningKey, deps.Logger)
+	mediaPath := mediaPath(cfg.Storage.PublicURL)
+	if deps.Storage == nil {
+		deps.Storage = storage.NewLocal(cmp.Or(cfg.Storage.Dir, "media"), cmp.Or(cfg.Storage.PublicURL, mediaPath), signer)
+	}
+
+	a := &App{
+		Config:  cfg,
+		Logger:  deps.Logger,
+		Repos:   deps.Repos,
+		Metrics: deps.Metrics,
+		Tracer:  deps.Tracer,
+		Services: Services{
+			Auth:        services.NewAuthService(deps.Repos, dispatcher, business),
+			Users:       services.NewUserService(deps.Repos.Users),
+			Maintenance: services.NewMaintenanceService(deps.Repos, cfg.Scheduler.Retention, cfg.Scheduler.Location),
+			Digests:     services.NewDigestService(deps.Repos, deps.Mailer),
+			Sync:        services.NewSyncService(deps.Repos, dispatcher, cfg.Scheduler.Location, business),
+			Media:       services.NewMediaService(deps.Repos, deps.Storage, signer, cfg.Storage),
+		},
+		Events:     bus,
+		Dispatcher: dispatcher,
+		Jobs:       jobs.NewClient(deps.Repos.Jobs),
+		Worker:     jobs.NewWorker(deps.Repos.Jobs, jobs.WorkerOptions{Queues: cfg.Jobs.Queues, Metrics: deps.Metrics}),
+		Scheduler: scheduler.New(deps.Repos.ScheduledRuns, deps.Leader, scheduler.Options{
+			Location: cfg.Scheduler.Location,
+			Metrics:  deps.Metrics,
+		}),
+	}
+	a.Services.Words = services.NewWordService(deps.Repos, a.Services.Media)
+	a.Hub = websocket.NewHub(websocket.Options{PubSub: deps.PubSub, Prepare: realtime.Stamp, Metrics: deps.Metrics})
+	a.Realtime = realtime.NewPublisher(a.Hub, deps.Repos.UserEvents, cfg.Realtime.EventLogSize)
+	a.Services.Games = services.NewGameService(deps.Repos, a.Realtime, cfg.Game, business)
+	realtime.Subscribe(bus, deps.Repos.Children, a.Realtime)
+	jobs.Handle(a.Worker, services.JobWeeklyDigest, a.Services.Digests.Send)
+	a.registerTasks()
+
+	a.Fiber = fiber.New(fiber.Config{
+		AppName:      cfg.AppName,
+		ErrorHandler: apperrors.Handle,
+		// самый большой медиафайл и запас на заголовки multipart
+		BodyLimit: int(a.Services.Media.MaxUploadSize()) + 1<<20,
+	})
+
+	a.Fiber.Use(requestid.New())
+	a.Fiber.Use(tracing.Middleware(a.Tracer))
+	a.Fiber.Use(logger.LoggingMiddleware(a.Logger))
+	a.Fiber.Use(a.Metrics.Middleware())
+	a.Fiber.Use(middlewares.Recover())
+	a.Fiber.Use(middlewares.Timeout(cfg.Timeout.Default, routeTimeouts(cfg.Timeout.Routes)))
+	a.Fiber.Use(cors.New(cors.Config{
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:  "Content-Type, Authorization, Last-Event-ID, Idempotency-Key, traceparent, tracestate",
+		ExposeHeaders: tracing.TraceIDHeader + ", " + middlewares.IdempotentReplayedHeader,
+	}))
+
+	a.Fiber.Get("/swagger/*", swagger.HandlerDefault)
+	a.Fiber.Get("/metrics", a.Metrics.Handler())
+
+	streams := handlers.NewEventsHandler(a.Hub, deps.Repos.UserEvents)
+	a.beforeStop = append(a.beforeStop, streams.Close)
+
+	routes.SetupRoutes(a.Fiber, routes.Handlers{
+		Auth:        handlers.NewAuthHandler(a.Services.Auth),
+		Logs:        handlers.GetLogs(deps.LogStore),
+		Jobs:        handlers.NewJobHandler(a.Jobs),
+		Scheduler:   handlers.NewSchedulerHandler(a.Scheduler),
+		WS:          handlers.NewWSHandler(a.Hub),
+		Games:       handlers.NewGameHandler(a.Services.Games),
+		Events:      streams,
+		Sync:        handlers.NewSyncHandler(a.Services.Sync),
+		Words:       handlers.NewWordHandler(a.Services.Words),
+		Media:       handlers.NewMediaHandler(a.Services.Media),
+		MediaPath:   mediaPath,
+		AuthGate:    middlewares.NewAuth(a.Services.Auth),
+		Idempotency: middlewares.Idempotency(deps.Repos.Idempotency, cfg.IdempotencyTTL),
+	})
+
+	return a
+}
+
+// mediaSigner подписывает ссылки локального хранилища. config.Load без ключа
+// не запускается; пустой ключ бывает только у конфигурации, собранной в коде,
+// и тогда он случайный: ссылки действуют, пока работает процесс, и только на нём
+func mediaSigner(key string, log *logrus.Logger) *storage.URLSigner {
+	if key == "" {
+		log.Warn("STORAGE_SIGNING_KEY is empty: media links are signed with a random key and will not verify on other instances or after a restart")
+		key = rand.Text()
+	}
+	signer, _ := storage.NewURLSigner(key)
+	return signer
+}
+
+// mediaPath — путь маршрута раздачи файлов из адреса STORAGE_PUBLIC_URL
+func mediaPath(publicURL string) string {
+	u, err := url.Parse(publicURL)
+	if err != nil || strings.Trim(u.Path, "/") == "" {
+		return "/media"
+	}
+	return "/" + strings.Trim(u.Path, "/")
+}
+
+func routeTimeouts(routes map[string]time.Duration) []middlewares.RouteTimeout {
+	out := make([]middlewares.RouteTimeout, 0, len(routes))
+	for prefix, d := range routes {
+		out = append(out, middlewares.RouteTimeout{Prefix: prefix, Timeout: d})
+	}
+	return out
+}
+
+// StartBackground запускает WebSocket-хаб, доставку событий из outbox и таймеры викторин
+func (a *App) StartBackground() {
+	a.runInBackground(a.Hub.Run)
+	a.runInBackground(a.Dispatcher.Run)
+	a.runInBackground(a.Services.Games.Run)
+}
+
+// StartWorkers запускает выполнение фоновых задач
+func (a *App) StartWorkers() {
+	a.runInBackground(a.Worker.Run)
+}
+
+// StartScheduler запускает периодические задачи. Выполнять их будет только
+// экземпляр, ставший лидером
+func (a *App) StartScheduler() {
+	a.runInBackground(a.Scheduler.Run)
+}
+
+// runInBackground запускает run в горутине; Shutdown отменяет её контекст и
+// дожидается завершения раньше, чем закрываются ресурсы из Bootstrap. В
+// контексте лежат логгер и провайдер трейсинга приложения
+func (a *App) runInBackground(run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(a.backgroundContext())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+
+	a.onShutdown(func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Listen запускает HTTP-сервер на порту из конфигурации и блокируется до остановки
+func (a *App) Listen() error {
+	a.listening.Store(true)
+	return a.Fiber.Listen(":" + a.Config.Port)
+}
+
+// Serve обслуживает запросы из готового listener'а и блокируется до остановки
+func (a *App) Serve(ln net.Listener) error {
+	a.listening.Store(true)
+	return a.Fiber.Listener(ln)
+}
+
+// Shutdown дожидается завершения текущих запросов и фоновой работы и освобождает ресурсы
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	if a.listening.Load() {
+		for _, stop := range a.beforeStop {
+			stop()
+		}
+		errs = append(errs, a.Fiber.ShutdownWithContext(ctx))
+		a.Logger.Info("HTTP server stopped")
+	}
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		errs = append(errs, a.closers[i](ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// backgroundContext — контекст для работы вне запросов
+func (a *App) backgroundContext() context.Context {
+	ctx := logger.NewContext(context.Background(), logrus.NewEntry(a.Logger))
+	return tracing.ContextWithProvider(ctx, a.Tracer)
+}
+
+func (a *App) onShutdown(fn func(context.Context) error) {
+	a.closers = append(a.closers, fn)
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"engkids/config"
+	"engkids/internal/dto"
+	"engkids/internal/repositories/memory"
+	"engkids/pkg/logstore"
+	"engkids/pkg/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return New(&config.Config{AppName: "engkids-test"}, Deps{
+		Logger:   l,
+		Repos:    memory.New(),
+		LogStore: logstore.NewFileStore(t.TempDir()),
+	})
+}
+
+func post(t *testing.T, a *App, path, body string) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.Fiber.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, raw
+}
+
+func TestInstancesAreIsolated(t *testing.T) {
+	first, second := newTestApp(t), newTestApp(t)
+	creds := `{"email":"parent@example.com","password":"secret123"}`
+
+	status, raw := post(t, first, "/api/auth/register", creds)
+	if status != fiber.StatusCreated {
+		t.Fatalf("register: %d %s", status, raw)
+	}
+	var auth dto.FullAuthResponse
+	if err := json.Unmarshal(raw, &auth); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, raw := post(t, second, "/api/auth/login", creds); status != fiber.StatusUnauthorized {
+		t.Fatalf("second instance sees users of the first: %d %s", status, raw)
+	}
+
+	req := httptest.NewRequest(fiber.MethodGet, "/api/user/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+auth.AccessToken)
+	resp, err := first.Fiber.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("profile: %d", resp.StatusCode)
+	}
+}
+
+func metricsText(t *testing.T, a *App) string {
+	t.Helper()
+	resp, err := a.Fiber.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	return string(raw)
+}
+
+func TestInstancesHaveOwnMetricsAndTracer(t *testing.T) {
+	var spans bytes.Buffer
+	tracer := tracing.NewProvider(tracing.Config{Exporter: tracing.NewStdoutExporter(&spans), SampleRatio: 1})
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	first := New(&config.Config{AppName: "engkids-test"}, Deps{
+		Logger:   l,
+		Repos:    memory.New(),
+		LogStore: logstore.NewFileStore(t.TempDir()),
+		Tracer:   tracer,
+	})
+	second := newTestApp(t)
+
+	if status, raw := post(t, first, "/api/auth/register", `{"email":"parent@example.com","password":"secret123"}`); status != fiber.StatusCreated {
+		t.Fatalf("register: %d %s", status, raw)
+	}
+
+	if m := metricsText(t, first); !strings.Contains(m, "engkids_registrations_total 1\n") {
+		t.Errorf("first instance did not count the registration:\n%s", m)
+	}
+	if m := metricsText(t, second); !strings.Contains(m, "engkids_registrations_total 0\n") ||
+		strings.Contains(m, `route="/api/auth/register"`) {
+		t.Errorf("second instance sees requests of the first:\n%s", m)
+	}
+
+	if err := tracer.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{`"name":"POST /api/auth/register"`, `"name":"AuthService.Register"`} {
+		if !strings.Contains(spans.String(), name) {
+			t.Errorf("span %s not exported:\n%s", name, spans.String())
+		}
+	}
+}
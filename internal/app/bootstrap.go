@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"engkids/config"
+	"engkids/internal/realtime"
+	"engkids/internal/repositories"
+	"engkids/pkg/database"
+	"engkids/pkg/elasticsearch"
+	"engkids/pkg/logger"
+	"engkids/pkg/logstore"
+	"engkids/pkg/mailer"
+	"engkids/pkg/metrics"
+	"engkids/pkg/storage"
+	"engkids/pkg/tracing"
+)
+
+// Bootstrap создаёт инфраструктуру по конфигурации — логгер, трейсинг,
+// подключение к БД, хранилище логов — и собирает приложение. Фоновая работа
+// запускается отдельно: StartBackground, StartWorkers и StartScheduler
+func Bootstrap(cfg *config.Config) (*App, error) {
+	appLogger, err := logger.NewLogger(cfg.AppName)
+	if err != nil {
+		return nil, fmt.Errorf("init logger: %w", err)
+	}
+	appLogger.Info("Logger initialized")
+	registry := metrics.NewRegistry()
+
+	var logstashHook *logger.LogstashHook
+	if addr := cfg.Logs.LogstashAddr; addr != "" {
+		logstashHook = logger.NewLogstashHook(addr, logger.LogstashOptions{Metrics: registry})
+		appLogger.AddHook(logstashHook)
+		appLogger.WithField("addr", addr).Info("Shipping logs to Logstash")
+	}
+
+	tracerProvider, err := tracing.NewProviderFromEnv(cfg.AppName, func(err error) {
+		appLogger.WithError(err).Warn("Failed to export spans")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("init tracing: %w", err)
+	}
+
+	db, err := database.ConnectDB(cfg.DB, registry)
+	if err != nil {
+		return nil, err
+	}
+	appLogger.Info("Database connection established")
+
+	var logStore logstore.LogStore = logstore.NewFileStore(cfg.Logs.Dir)
+	if cfg.Logs.ElasticsearchURL != "" {
+		es, err := elasticsearch.NewClient(cfg.Logs.ElasticsearchURL)
+		if err != nil {
+			appLogger.WithError(err).Warn("Elasticsearch unavailable, falling back to file log store")
+		} else {
+			logStore = logstore.NewElasticStore(es, cfg.Logs.Index)
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("get sql.DB: %w", err)
+	}
+
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if cfg.Mail.SMTPAddr != "" {
+		mail = mailer.SMTP{
+			Addr:     cfg.Mail.SMTPAddr,
+			From:     cfg.Mail.From,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+		}
+	}
+
+	var store storage.Storage // nil — локальный каталог
+	if cfg.Storage.Driver == "s3" {
+		store, err = storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			Region:    cfg.Storage.S3.Region,
+			Bucket:    cfg.Storage.S3.Bucket,
+			AccessKey: cfg.Storage.S3.AccessKey,
+			SecretKey: cfg.Storage.S3.SecretKey,
+			PathStyle: cfg.Storage.S3.PathStyle,
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("media storage: %w", err)
+		}
+	}
+
+	a := New(cfg, Deps{
+		Logger:   appLogger,
+		Repos:    repositories.NewGorm(db),
+		LogStore: logStore,
+		Leader:   database.NewAdvisoryLock(sqlDB, cfg.AppName+":scheduler"),
+		Mailer:   mail,
+		PubSub:   realtime.NewPostgresPubSub(sqlDB, cfg.AppName+"_ws"),
+		Storage:  store,
+		Metrics:  registry,
+		Tracer:   tracerProvider,
+	})
+
+	// ресурсы закрываются в обратном порядке: сначала трейсинг и БД, Logstash —
+	// последним, чтобы в него попали записи об остановке
+	if logstashHook != nil {
+		a.onShutdown(func(ctx context.Context) error {
+			if err := logstashHook.Close(ctx); err != nil {
+				return fmt.Errorf("flush logs to Logstash, %d entries dropped: %w", logstashHook.Dropped(), err)
+			}
+			return nil
+		})
+	}
+	a.onShutdown(func(context.Context) error {
+		return database.CloseDB(db, registry)
+	})
+	if tracerProvider != nil {
+		a.onShutdown(tracerProvider.Shutdown)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,41 @@
+package app
+
+import "engkids/internal/scheduler"
+
+// Имена периодических задач в истории запусков и метриках
+const (
+	TaskPurgeRefreshTokens = "purge_refresh_tokens"
+	TaskPurgeIdempotency   = "purge_idempotency_keys"
+	TaskPurgeDeleted       = "purge_deleted"
+	TaskResetStreaks       = "reset_streaks"
+	TaskStreaksAtRisk      = "warn_streaks_at_risk"
+	TaskWeeklyDigest       = "weekly_digest"
+)
+
+type task struct {
+	name, expr string
+	run        scheduler.Task
+}
+
+// registerTasks регистрирует задачи обслуживания. Расписания считаются в
+// часовом поясе из конфигурации, поэтому серии сбрасываются в местную полночь
+func (a *App) registerTasks() {
+	m := a.Services.Maintenance
+	tasks := []task{
+		{TaskPurgeRefreshTokens, "0 * * * *", m.PurgeExpiredTokens},
+		{TaskPurgeIdempotency, "15 * * * *", m.PurgeIdempotencyKeys},
+		{TaskPurgeDeleted, "30 3 * * *", m.PurgeDeleted},
+		{TaskResetStreaks, "0 0 * * *", m.ResetStreaks},
+		{TaskStreaksAtRisk, "0 18 * * *", m.WarnStreaksAtRisk},
+	}
+	if expr := a.Config.Scheduler.DigestSchedule; expr != "" {
+		tasks = append(tasks, task{TaskWeeklyDigest, expr, m.EnqueueWeeklyDigests})
+	}
+
+	for _, t := range tasks {
+		// выражения проверены при загрузке конфигурации
+		if err := a.Scheduler.Add(t.name, t.expr, t.run); err != nil {
+			panic(err)
+		}
+	}
+}
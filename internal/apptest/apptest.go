@@ -0,0 +1,275 @@
+// Package apptest поднимает приложение целиком для HTTP-тестов через app.Test.
+// По умолчанию используются хранилища в памяти; если задан TEST_DB_HOST,
+// тесты идут против настоящего Postgres (TEST_DB_PORT, TEST_DB_USER,
+// TEST_DB_PASSWORD, TEST_DB_NAME): подключение одно на процесс, таблицы
+// очищаются при каждом вызове New
+package apptest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"engkids/config"
+	"engkids/internal/app"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/internal/repositories/memory"
+	"engkids/pkg/database"
+	"engkids/pkg/logstore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// DefaultPassword — пароль пользователей, создаваемых хелперами
+const DefaultPassword = "secret123"
+
+// Harness — приложение под тестом и его хранилища
+type Harness struct {
+	T      *testing.T
+	App    *app.App
+	Repos  *repositories.Repositories
+	LogDir string // каталог файлового хранилища логов для /api/logs
+}
+
+// New собирает приложение для теста
+func New(t *testing.T) *Harness {
+	t.Helper()
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+
+	cfg := &config.Config{
+		AppName: "engkids-test",
+		Timeout: config.Timeout{Default: 5 * time.Second},
+		Storage: config.Storage{Dir: t.TempDir(), SigningKey: "test-signing-key"},
+	}
+	h := &Harness{T: t, Repos: newRepositories(t), LogDir: t.TempDir()}
+	h.App = app.New(cfg, app.Deps{
+		Logger:   l,
+		Repos:    h.Repos,
+		LogStore: logstore.NewFileStore(h.LogDir),
+	})
+	return h
+}
+
+// Listen запускает приложение вместе с фоновыми процессами на свободном порту
+// и возвращает его адрес host:port. Нужен для WebSocket, которые app.Test не
+// поддерживает; приложение останавливается в конце теста
+func (h *Harness) Listen() string {
+	h.T.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		h.T.Fatalf("listen: %v", err)
+	}
+	h.App.StartBackground()
+	go h.App.Serve(ln)
+	h.T.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.App.Shutdown(ctx); err != nil {
+			h.T.Errorf("shutdown: %v", err)
+		}
+	})
+	return ln.Addr().String()
+}
+
+var (
+	dbOnce sync.Once
+	testDB *gorm.DB
+	dbErr  error
+)
+
+// sharedDB подключается к тестовой базе и применяет миграции один раз на
+// процесс: соединение общее для всех тестов и всех экземпляров приложения
+func sharedDB(host string) (*gorm.DB, error) {
+	dbOnce.Do(func() {
+		testDB, dbErr = database.ConnectDB(config.DB{
+			Host:     host,
+			Port:     config.GetEnv("TEST_DB_PORT", "5432"),
+			User:     config.GetEnv("TEST_DB_USER", "postgres"),
+			Password: config.GetEnv("TEST_DB_PASSWORD", ""),
+			Name:     config.GetEnv("TEST_DB_NAME", "engkids_test"),
+		}, nil)
+	})
+	return testDB, dbErr
+}
+
+func newRepositories(t *testing.T) *repositories.Repositories {
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		return memory.New()
+	}
+
+	db, err := sharedDB(host)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+
+	err = db.Exec("TRUNCATE users, children, progresses, refresh_tokens, outbox_events, jobs, scheduled_runs, realtime_messages, words, word_translations, lesson_words, game_sessions, game_results, game_players, user_events, word_reviews, child_settings, sync_operations, idempotency_keys, media_assets RESTART IDENTITY CASCADE").Error
+	if err != nil {
+		t.Fatalf("truncate test database: %v", err)
+	}
+	return repositories.NewGorm(db)
+}
+
+// Response — ответ приложения с уже прочитанным телом
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// JSON разбирает тело ответа в v
+func (r *Response) JSON(t *testing.T, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("decode response %s: %v", r.Body, err)
+	}
+}
+
+// Request описывает запрос к приложению
+type Request struct {
+	Method  string
+	Path    string
+	Body    any // сериализуется в JSON; строка отправляется как есть
+	Token   string
+	Headers map[string]string
+}
+
+// Do выполняет запрос через app.Test
+func (h *Harness) Do(r Request) *Response {
+	h.T.Helper()
+
+	var body io.Reader
+	switch b := r.Body.(type) {
+	case nil:
+	case string:
+		body = bytes.NewBufferString(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			h.T.Fatal(err)
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req := httptest.NewRequest(r.Method, r.Path, body)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if r.Token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+r.Token)
+	}
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.App.Fiber.Test(req, -1)
+	if err != nil {
+		h.T.Fatalf("%s %s: %v", r.Method, r.Path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.T.Fatal(err)
+	}
+	return &Response{Status: resp.StatusCode, Header: resp.Header, Body: raw}
+}
+
+// Get — сокращение для GET-запроса
+func (h *Harness) Get(path, token string) *Response {
+	h.T.Helper()
+	return h.Do(Request{Method: fiber.MethodGet, Path: path, Token: token})
+}
+
+// Post — сокращение для POST-запроса с JSON-телом
+func (h *Harness) Post(path string, body any, token string) *Response {
+	h.T.Helper()
+	return h.Do(Request{Method: fiber.MethodPost, Path: path, Body: body, Token: token})
+}
+
+// ExpectStatus проверяет статус ответа
+func (h *Harness) ExpectStatus(r *Response, status int) {
+	h.T.Helper()
+	if r.Status != status {
+		h.T.Fatalf("status = %d, want %d: %s", r.Status, status, r.Body)
+	}
+}
+
+// Session — токены авторизованного пользователя
+type Session struct {
+	User         models.User
+	AccessToken  string
+	RefreshToken string
+}
+
+func (h *Harness) session(r *Response) *Session {
+	h.T.Helper()
+	var body struct {
+		AccessToken  string      `json:"access_token"`
+		RefreshToken string      `json:"refresh_token"`
+		User         models.User `json:"user"`
+	}
+	r.JSON(h.T, &body)
+	return &Session{User: body.User, AccessToken: body.AccessToken, RefreshToken: body.RefreshToken}
+}
+
+// Register регистрирует пользователя через API
+func (h *Harness) Register(email string) *Session {
+	h.T.Helper()
+	r := h.Post("/api/auth/register", map[string]string{"email": email, "password": DefaultPassword}, "")
+	h.ExpectStatus(r, fiber.StatusCreated)
+	return h.session(r)
+}
+
+// Login входит через API
+func (h *Harness) Login(email string) *Session {
+	h.T.Helper()
+	r := h.Post("/api/auth/login", map[string]string{"email": email, "password": DefaultPassword}, "")
+	h.ExpectStatus(r, fiber.StatusOK)
+	return h.session(r)
+}
+
+// CreateUser создаёт пользователя с ролью напрямую в хранилище (API
+// регистрирует только родителей) и входит под ним
+func (h *Harness) CreateUser(email, role string) *Session {
+	h.T.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(DefaultPassword), bcrypt.MinCost)
+	if err != nil {
+		h.T.Fatal(err)
+	}
+	user := models.User{Email: email, Password: string(hash), Role: role}
+	if err := h.Repos.Users.Create(h.T.Context(), &user); err != nil {
+		h.T.Fatalf("create user: %v", err)
+	}
+	return h.Login(email)
+}
+
+// WriteLogs кладёт записи в каталог логов, который читает /api/logs
+func (h *Harness) WriteLogs(entries ...logrus.Fields) {
+	h.T.Helper()
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			h.T.Fatal(err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(filepath.Join(h.LogDir, "app.log"), buf.Bytes(), 0o644); err != nil {
+		h.T.Fatal(err)
+	}
+}
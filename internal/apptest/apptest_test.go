@@ -0,0 +1,32 @@
+package apptest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Два приложения в одном процессе не должны делить метрики и не должны
+// падать на повторной регистрации — ни в памяти, ни с TEST_DB_HOST
+func TestTwoHarnessesInOneProcess(t *testing.T) {
+	first := New(t)
+	first.Listen()
+	first.Register("first@example.com")
+
+	second := New(t)
+	second.Listen()
+	second.Register("second@example.com")
+	second.Login("second@example.com")
+
+	for name, h := range map[string]*Harness{"first": first, "second": second} {
+		r := h.Get("/metrics", "")
+		h.ExpectStatus(r, fiber.StatusOK)
+		if !strings.Contains(string(r.Body), "engkids_registrations_total 1\n") {
+			t.Errorf("%s instance metrics:\n%s", name, r.Body)
+		}
+	}
+	if r := first.Get("/metrics", ""); strings.Contains(string(r.Body), "engkids_logins_total 1\n") {
+		t.Errorf("first instance counted a login made on the second:\n%s", r.Body)
+	}
+}
@@ -0,0 +1,51 @@
+package apptest
+
+import (
+	"time"
+
+	"engkids/internal/models"
+)
+
+// Child создаёт профиль ребёнка у родителя
+func (h *Harness) Child(parentID uint, name string, age int) *models.Child {
+	h.T.Helper()
+	child := &models.Child{Name: name, Age: age, ParentID: parentID}
+	if err := h.Repos.Children.Create(h.T.Context(), child); err != nil {
+		h.T.Fatalf("create child: %v", err)
+	}
+	return child
+}
+
+// LessonResult записывает результат урока ребёнка; score < 0 — урок начат, но не пройден
+func (h *Harness) LessonResult(childID, lessonID uint, score int) *models.Progress {
+	h.T.Helper()
+	p := &models.Progress{ChildID: childID, LessonID: lessonID}
+	if score >= 0 {
+		now := time.Now()
+		p.Completed, p.Score, p.CompletedAt = true, score, &now
+	}
+	if err := h.Repos.Progress.Save(h.T.Context(), p); err != nil {
+		h.T.Fatalf("save progress: %v", err)
+	}
+	return p
+}
+
+// LessonWords добавляет в урок слова с переводами на русский: пары headword, перевод
+func (h *Harness) LessonWords(lessonID uint, pairs ...string) []models.Word {
+	h.T.Helper()
+	words := make([]models.Word, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		w := models.Word{
+			Headword:     pairs[i],
+			Translations: []models.WordTranslation{{Language: "ru", Text: pairs[i+1]}},
+		}
+		if err := h.Repos.Words.Create(h.T.Context(), &w); err != nil {
+			h.T.Fatalf("create word: %v", err)
+		}
+		if err := h.Repos.Words.LinkLesson(h.T.Context(), lessonID, w.ID); err != nil {
+			h.T.Fatalf("link word: %v", err)
+		}
+		words = append(words, w)
+	}
+	return words
+}
@@ -0,0 +1,102 @@
+package apptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "перезаписать golden-файлы фактическими ответами")
+
+// volatileKeys — поля, значения которых меняются от запуска к запуску и
+// заменяются в golden-файлах на "<ключ>"
+var volatileKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"request_id":    true,
+	"created_at":    true,
+	"updated_at":    true,
+	"completed_at":  true,
+	"run_at":        true,
+	"finished_at":   true,
+	"locked_at":     true,
+	"next_cursor":   true,
+	"cursor":        true,
+	"started_at":    true,
+	"duration_ms":   true,
+	"instance":      true,
+	"url":           true, // подписанные ссылки на файлы
+	"thumbnail_url": true,
+	"expires_at":    true,
+	// ссылки на медиафайлы слов
+	"image_url":        true,
+	"audio_uk_url":     true,
+	"audio_us_url":     true,
+	"media_expires_at": true,
+}
+
+// Golden сравнивает JSON-ответ с testdata/golden/<name>.json. С флагом
+// -update файл перезаписывается
+func Golden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	got, err := normalize(body)
+	if err != nil {
+		t.Fatalf("golden %s: response is not JSON: %s", name, body)
+	}
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden %s: %v (run go test -update to create it)", name, err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("golden %s mismatch\n--- want\n%s\n--- got\n%s", name, want, got)
+	}
+}
+
+func normalize(body []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(mask(v)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func mask(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if volatileKeys[k] && item != nil {
+				val[k] = "<" + k + ">"
+				continue
+			}
+			val[k] = mask(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = mask(item)
+		}
+	}
+	return v
+}
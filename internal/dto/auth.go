@@ -4,7 +4,7 @@ import "engkids/internal/models"
 
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,password"`
 }
 
 type LoginRequest struct {
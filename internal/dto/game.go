@@ -0,0 +1,17 @@
+package dto
+
+type CreateGameRequest struct {
+	LessonID uint   `json:"lesson_id" validate:"required"`
+	Language string `json:"language" validate:"omitempty,lang_code"` // язык вариантов ответа; по умолчанию ru
+	Rounds   int    `json:"rounds" validate:"omitempty,min=1,max=50"`
+}
+
+type JoinGameRequest struct {
+	ChildID uint `json:"child_id" validate:"required"`
+}
+
+type GameAnswerRequest struct {
+	ChildID uint `json:"child_id" validate:"required"`
+	Round   int  `json:"round" validate:"required,min=1"`
+	Option  *int `json:"option" validate:"required,min=0"` // индекс варианта в question.options
+}
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"time"
+
+	"engkids/internal/models"
+)
+
+// MediaAsset — загруженный файл со ссылками для скачивания
+type MediaAsset struct {
+	models.MediaAsset
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"` // только у картинок, для которых строится миниатюра
+	ExpiresAt    time.Time `json:"expires_at"`              // до какого момента действуют ссылки
+}
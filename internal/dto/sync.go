@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+)
+
+type SyncRequest struct {
+	// Cursor — курсор из ответа прошлой синхронизации; пусто — прислать все данные
+	Cursor     string          `json:"cursor"`
+	Operations []SyncOperation `json:"operations" validate:"max=500,dive"` // не больше 500 за запрос
+}
+
+// SyncOperation — действие, сделанное в приложении без сети. Какие поля
+// нужны, зависит от типа: attempt — lesson_id, score, completed; review —
+// word_id, grade; setting — key, value
+type SyncOperation struct {
+	ID         string    `json:"id" validate:"required,max=64"` // генерирует приложение, уникален у пользователя
+	Type       string    `json:"type" validate:"required,oneof=attempt review setting"`
+	ChildID    uint      `json:"child_id" validate:"required"`
+	ClientTime time.Time `json:"client_time" validate:"required"` // когда действие сделано на устройстве
+
+	LessonID  uint `json:"lesson_id,omitempty"`
+	Score     *int `json:"score,omitempty" validate:"omitempty,min=0,max=100"`
+	Completed bool `json:"completed,omitempty"`
+
+	WordID uint `json:"word_id,omitempty"`
+	Grade  *int `json:"grade,omitempty" validate:"omitempty,min=0,max=5"` // 0–2 — не вспомнил, 3–5 — вспомнил
+
+	Key   string          `json:"key,omitempty" validate:"omitempty,max=64"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+type SyncResponse struct {
+	Results []SyncResult `json:"results"`
+	Changes SyncChanges  `json:"changes"`
+	// Cursor нужно прислать в следующий раз, чтобы получить только новые изменения
+	Cursor string `json:"cursor"`
+}
+
+// SyncResult — итог операции: applied — изменила данные; ignored — на сервере
+// уже лучший результат или более новое значение; duplicate — операция уже
+// принималась; rejected — не принята, причина в code и error
+type SyncResult struct {
+	ID      string           `json:"id"`
+	Status  string           `json:"status"`
+	Code    apperrors.Code   `json:"code,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Details map[string]any   `json:"details,omitempty"`
+	Err     *apperrors.Error `json:"-"` // причина отказа; текст подставляет обработчик на языке запроса
+}
+
+// SyncChanges — данные детей пользователя, изменившиеся с прошлой синхронизации
+type SyncChanges struct {
+	Children []models.Child        `json:"children"`
+	Progress []models.Progress     `json:"progress"`
+	Reviews  []models.WordReview   `json:"reviews"`
+	Settings []models.ChildSetting `json:"settings"`
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"time"
+
+	"engkids/internal/models"
+)
+
+// WordRequest — слово словаря, которое создаёт или меняет редактор
+type WordRequest struct {
+	Headword       string                   `json:"headword" validate:"required,max=100"`
+	PartOfSpeech   string                   `json:"part_of_speech" validate:"omitempty,oneof=noun verb adjective adverb pronoun preposition conjunction interjection numeral phrase"`
+	Difficulty     string                   `json:"difficulty" validate:"omitempty,cefr"` // по умолчанию A1
+	Tags           []string                 `json:"tags" validate:"max=20,dive,required,max=32"`
+	Examples       []string                 `json:"examples" validate:"max=10,dive,required,max=300"`
+	ImageMediaID   *uint                    `json:"image_media_id" validate:"omitempty,gt=0"`    // загруженная картинка
+	AudioUKMediaID *uint                    `json:"audio_uk_media_id" validate:"omitempty,gt=0"` // загруженное аудио
+	AudioUSMediaID *uint                    `json:"audio_us_media_id" validate:"omitempty,gt=0"`
+	Translations   []WordTranslationRequest `json:"translations" validate:"required,min=1,max=20,dive"`
+}
+
+// Word — слово словаря со свежими подписанными ссылками на его медиафайлы
+type Word struct {
+	models.Word
+	ImageURL       string     `json:"image_url,omitempty"`
+	AudioUKURL     string     `json:"audio_uk_url,omitempty"`
+	AudioUSURL     string     `json:"audio_us_url,omitempty"`
+	MediaExpiresAt *time.Time `json:"media_expires_at,omitempty"` // до какого момента действуют ссылки
+}
+
+// WordTranslationRequest — перевод слова; у слова один перевод на язык
+type WordTranslationRequest struct {
+	Language string `json:"language" validate:"required,lang_code"`
+	Text     string `json:"text" validate:"required,max=200"`
+}
+
+// WordProgress — как ребёнок знает слово урока по повторениям карточек
+type WordProgress struct {
+	Word       Word       `json:"word"`
+	Box        int        `json:"box"` // коробка Лейтнера; 0 — слово ещё не повторялось
+	Reviews    int        `json:"reviews"`
+	Learned    bool       `json:"learned"` // слово дошло до последней коробки
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	DueAt      *time.Time `json:"due_at"` // когда повторить
+}
+
+// LessonWordsProgress — прогресс ребёнка по словам урока
+type LessonWordsProgress struct {
+	LessonID uint           `json:"lesson_id"`
+	ChildID  uint           `json:"child_id"`
+	Total    int            `json:"total"`
+	Learned  int            `json:"learned"`
+	Words    []WordProgress `json:"words"`
+}
@@ -0,0 +1,164 @@
+package errors
+
+import "github.com/gofiber/fiber/v2"
+
+// Code — машиночитаемый код ошибки. Значения — часть API, менять их нельзя
+type Code string
+
+// Общие коды
+const (
+	CodeBadRequest         Code = "BAD_REQUEST"
+	CodeInvalidBody        Code = "INVALID_BODY"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeMethodNotAllowed   Code = "METHOD_NOT_ALLOWED"
+	CodeConflict           Code = "CONFLICT"
+	CodePayloadTooLarge    Code = "PAYLOAD_TOO_LARGE"
+	CodeTooManyRequests    Code = "TOO_MANY_REQUESTS"
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+	CodeTimeout            Code = "TIMEOUT"
+)
+
+// Авторизация
+const (
+	CodeAuthInvalidCredentials Code = "AUTH_INVALID_CREDENTIALS"
+	CodeAuthUserExists         Code = "AUTH_USER_EXISTS"
+	CodeAuthTokenMissing       Code = "AUTH_TOKEN_MISSING"
+	CodeAuthTokenMalformed     Code = "AUTH_TOKEN_MALFORMED"
+	CodeAuthTokenExpired       Code = "AUTH_TOKEN_EXPIRED"
+	CodeAuthRefreshInvalid     Code = "AUTH_REFRESH_INVALID"
+	CodeAuthForbidden          Code = "AUTH_FORBIDDEN"
+)
+
+// Дети
+const (
+	CodeChildNotFound     Code = "CHILD_NOT_FOUND"
+	CodeChildLimitReached Code = "CHILD_LIMIT_REACHED"
+)
+
+// Логи
+const (
+	CodeLogsInvalidQuery Code = "LOGS_INVALID_QUERY"
+	CodeLogsUnavailable  Code = "LOGS_UNAVAILABLE"
+)
+
+// Фоновые задачи
+const (
+	CodeJobNotFound     Code = "JOB_NOT_FOUND"
+	CodeJobInvalidState Code = "JOB_INVALID_STATE"
+	CodeJobInvalidQuery Code = "JOB_INVALID_QUERY"
+)
+
+// Викторина
+const (
+	CodeGameNotFound        Code = "GAME_NOT_FOUND"
+	CodeGameInvalidState    Code = "GAME_INVALID_STATE"
+	CodeGameFull            Code = "GAME_FULL"
+	CodeGamePlayerExists    Code = "GAME_PLAYER_EXISTS"
+	CodeGameNotPlayer       Code = "GAME_NOT_PLAYER"
+	CodeGameNotHost         Code = "GAME_NOT_HOST"
+	CodeGameNoPlayers       Code = "GAME_NO_PLAYERS"
+	CodeGameRoundOver       Code = "GAME_ROUND_OVER"
+	CodeGameAlreadyAnswered Code = "GAME_ALREADY_ANSWERED"
+	CodeGameNotEnoughWords  Code = "GAME_NOT_ENOUGH_WORDS"
+)
+
+// Слова
+const (
+	CodeWordNotFound       Code = "WORD_NOT_FOUND"
+	CodeWordExists         Code = "WORD_ALREADY_EXISTS"
+	CodeLessonWordNotFound Code = "LESSON_WORD_NOT_FOUND"
+)
+
+// Офлайн-синхронизация
+const (
+	CodeSyncInvalidCursor    Code = "SYNC_INVALID_CURSOR"
+	CodeSyncInvalidOperation Code = "SYNC_INVALID_OPERATION"
+)
+
+// Повторы запросов с Idempotency-Key
+const (
+	CodeIdempotencyKeyReused  Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInProgress Code = "IDEMPOTENCY_IN_PROGRESS"
+)
+
+// Медиафайлы
+const (
+	CodeMediaNotFound        Code = "MEDIA_NOT_FOUND"
+	CodeMediaTooLarge        Code = "MEDIA_TOO_LARGE"
+	CodeMediaUnsupportedType Code = "MEDIA_UNSUPPORTED_TYPE"
+	CodeMediaInvalidFile     Code = "MEDIA_INVALID_FILE"
+	CodeMediaLinkInvalid     Code = "MEDIA_LINK_INVALID"
+)
+
+var statuses = map[Code]int{
+	CodeBadRequest:         fiber.StatusBadRequest,
+	CodeInvalidBody:        fiber.StatusBadRequest,
+	CodeValidationFailed:   fiber.StatusBadRequest,
+	CodeUnauthorized:       fiber.StatusUnauthorized,
+	CodeForbidden:          fiber.StatusForbidden,
+	CodeNotFound:           fiber.StatusNotFound,
+	CodeMethodNotAllowed:   fiber.StatusMethodNotAllowed,
+	CodeConflict:           fiber.StatusConflict,
+	CodePayloadTooLarge:    fiber.StatusRequestEntityTooLarge,
+	CodeTooManyRequests:    fiber.StatusTooManyRequests,
+	CodeInternal:           fiber.StatusInternalServerError,
+	CodeServiceUnavailable: fiber.StatusServiceUnavailable,
+	CodeTimeout:            fiber.StatusGatewayTimeout,
+
+	CodeAuthInvalidCredentials: fiber.StatusUnauthorized,
+	CodeAuthUserExists:         fiber.StatusConflict,
+	CodeAuthTokenMissing:       fiber.StatusUnauthorized,
+	CodeAuthTokenMalformed:     fiber.StatusUnauthorized,
+	CodeAuthTokenExpired:       fiber.StatusUnauthorized,
+	CodeAuthRefreshInvalid:     fiber.StatusUnauthorized,
+	CodeAuthForbidden:          fiber.StatusForbidden,
+
+	CodeChildNotFound:     fiber.StatusNotFound,
+	CodeChildLimitReached: fiber.StatusConflict,
+
+	CodeLogsInvalidQuery: fiber.StatusBadRequest,
+	CodeLogsUnavailable:  fiber.StatusServiceUnavailable,
+
+	CodeJobNotFound:     fiber.StatusNotFound,
+	CodeJobInvalidState: fiber.StatusConflict,
+	CodeJobInvalidQuery: fiber.StatusBadRequest,
+
+	CodeGameNotFound:        fiber.StatusNotFound,
+	CodeGameInvalidState:    fiber.StatusConflict,
+	CodeGameFull:            fiber.StatusConflict,
+	CodeGamePlayerExists:    fiber.StatusConflict,
+	CodeGameNotPlayer:       fiber.StatusForbidden,
+	CodeGameNotHost:         fiber.StatusForbidden,
+	CodeGameNoPlayers:       fiber.StatusConflict,
+	CodeGameRoundOver:       fiber.StatusConflict,
+	CodeGameAlreadyAnswered: fiber.StatusConflict,
+	CodeGameNotEnoughWords:  fiber.StatusUnprocessableEntity,
+
+	CodeWordNotFound:       fiber.StatusNotFound,
+	CodeWordExists:         fiber.StatusConflict,
+	CodeLessonWordNotFound: fiber.StatusNotFound,
+
+	CodeSyncInvalidCursor:    fiber.StatusBadRequest,
+	CodeSyncInvalidOperation: fiber.StatusUnprocessableEntity,
+
+	CodeIdempotencyKeyReused:  fiber.StatusUnprocessableEntity,
+	CodeIdempotencyInProgress: fiber.StatusConflict,
+
+	CodeMediaNotFound:        fiber.StatusNotFound,
+	CodeMediaTooLarge:        fiber.StatusRequestEntityTooLarge,
+	CodeMediaUnsupportedType: fiber.StatusUnsupportedMediaType,
+	CodeMediaInvalidFile:     fiber.StatusUnprocessableEntity,
+	CodeMediaLinkInvalid:     fiber.StatusForbidden,
+}
+
+// Status возвращает HTTP-статус кода по умолчанию
+func (c Code) Status() int {
+	if s, ok := statuses[c]; ok {
+		return s
+	}
+	return fiber.StatusInternalServerError
+}
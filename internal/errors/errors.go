@@ -0,0 +1,103 @@
+package errors
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error — ошибка предметной области со стабильным кодом. Текст для клиента
+// берётся из каталога сообщений по коду и языку запроса
+type Error struct {
+	Code    Code
+	Status  int
+	Details map[string]any
+	Fields  []FieldError
+	Err     error // исходная ошибка, в ответ не попадает
+}
+
+// New создаёт ошибку с HTTP-статусом по умолчанию для кода
+func New(code Code) *Error {
+	return &Error{Code: code, Status: code.Status()}
+}
+
+// Wrap создаёт ошибку с кодом, сохраняя исходную причину для логов
+func Wrap(code Code, err error) *Error {
+	return &Error{Code: code, Status: code.Status(), Err: err}
+}
+
+// WithDetails добавляет подробности, которые вернутся клиенту и подставятся в
+// сообщение вместо {ключ}
+func (e *Error) WithDetails(details map[string]any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any, len(details))
+	}
+	for k, v := range details {
+		e.Details[k] = v
+	}
+	return e
+}
+
+// WithStatus переопределяет HTTP-статус
+func (e *Error) WithStatus(status int) *Error {
+	e.Status = status
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return string(e.Code)
+}
+
+// Unwrap позволяет errors.Is/As добраться до причины
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode возвращает HTTP-статус ответа
+func (e *Error) StatusCode() int {
+	if e.Status == 0 {
+		return e.Code.Status()
+	}
+	return e.Status
+}
+
+// Is сравнивает ошибки по коду: errors.Is(err, apperrors.New(CodeNotFound))
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// codeForStatus сопоставляет ошибкам Fiber общий код по статусу. Для каждого
+// перечисленного статуса код имеет тот же статус по умолчанию; остальные 4xx
+// получают BAD_REQUEST с исходным статусом
+func codeForStatus(status int) Code {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case fiber.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case fiber.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case fiber.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 400 && status < 500 {
+		return CodeBadRequest
+	}
+	return CodeInternal
+}
@@ -0,0 +1,174 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"engkids/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFrom(t *testing.T) {
+	cause := errors.New("db down")
+	tests := []struct {
+		name   string
+		err    error
+		code   Code
+		status int
+	}{
+		{"domain error", New(CodeChildNotFound), CodeChildNotFound, fiber.StatusNotFound},
+		{"wrapped domain error", fmt.Errorf("load: %w", New(CodeWordExists)), CodeWordExists, fiber.StatusConflict},
+		{"status override", New(CodeBadRequest).WithStatus(fiber.StatusTeapot), CodeBadRequest, fiber.StatusTeapot},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), CodeTimeout, fiber.StatusGatewayTimeout},
+		{"canceled", context.Canceled, CodeServiceUnavailable, fiber.StatusServiceUnavailable},
+		{"internal with deadline", Wrap(CodeInternal, context.DeadlineExceeded), CodeTimeout, fiber.StatusGatewayTimeout},
+		{"internal", Wrap(CodeInternal, cause), CodeInternal, fiber.StatusInternalServerError},
+		{"fiber error", fiber.ErrMethodNotAllowed, CodeMethodNotAllowed, fiber.StatusMethodNotAllowed},
+		{"fiber unknown 4xx", fiber.ErrUnprocessableEntity, CodeBadRequest, fiber.StatusUnprocessableEntity},
+		{"fiber 5xx", fiber.ErrBadGateway, CodeInternal, fiber.StatusBadGateway},
+		{"plain error", cause, CodeInternal, fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Code != tt.code || got.StatusCode() != tt.status {
+				t.Errorf("From = %s/%d, want %s/%d", got.Code, got.StatusCode(), tt.code, tt.status)
+			}
+			if !errors.Is(got, tt.err) && !errors.Is(tt.err, got) {
+				t.Errorf("From lost the cause %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestCodeForStatusIsSymmetric(t *testing.T) {
+	for status := 400; status < 600; status++ {
+		code := codeForStatus(status)
+		switch code {
+		case CodeBadRequest, CodeInternal:
+			// общие коды для статусов без собственного кода
+			continue
+		}
+		if code.Status() != status {
+			t.Errorf("codeForStatus(%d) = %s, but %s maps to %d", status, code, code, code.Status())
+		}
+	}
+	if codeForStatus(fiber.StatusUnprocessableEntity) == CodeValidationFailed {
+		t.Error("422 must not be reported as VALIDATION_FAILED, which is 400")
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", LangRU},
+		{"en", LangEN},
+		{"de, en;q=0.8", LangEN},
+		{"ru;q=0.5, en;q=0.9", LangEN},
+		{"fr", LangRU},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error { return c.SendString(Language(c)) })
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Accept-Language", tt.header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 8)
+		n, _ := resp.Body.Read(buf)
+		if got := string(buf[:n]); got != tt.want {
+			t.Errorf("Accept-Language %q: got %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestHandle(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: Handle})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("requestid", "req-1")
+		return c.Next()
+	})
+	app.Get("/word", func(c *fiber.Ctx) error {
+		return New(CodeWordExists).WithDetails(map[string]any{"headword": "cat"})
+	})
+	app.Get("/fields", func(c *fiber.Ctx) error {
+		return New(CodeValidationFailed).WithFields([]FieldError{{Field: "name", Rule: "min_length", Param: "2"}})
+	})
+	app.Get("/boom", func(c *fiber.Ctx) error {
+		return errors.New("secret internals")
+	})
+
+	tests := []struct {
+		path, lang string
+		status     int
+		want       Response
+	}{
+		{"/word", "en", fiber.StatusConflict, Response{
+			Error: "The word cat is already in the dictionary", Code: CodeWordExists,
+			Details: map[string]any{"headword": "cat"}, RequestID: "req-1",
+		}},
+		{"/word", "ru", fiber.StatusConflict, Response{
+			Error: Message(CodeWordExists, LangRU, map[string]any{"headword": "cat"}), Code: CodeWordExists,
+			Details: map[string]any{"headword": "cat"}, RequestID: "req-1",
+		}},
+		{"/fields", "en", fiber.StatusBadRequest, Response{
+			Error: "Validation failed", Code: CodeValidationFailed, RequestID: "req-1",
+			Fields: []FieldError{{Field: "name", Rule: "min_length", Param: "2", Message: "Must be at least 2 characters long"}},
+		}},
+		{"/boom", "en", fiber.StatusInternalServerError, Response{
+			Error: Message(CodeInternal, LangEN, nil), Code: CodeInternal, RequestID: "req-1",
+		}},
+		{"/missing", "en", fiber.StatusNotFound, Response{
+			Error: Message(CodeNotFound, LangEN, nil), Code: CodeNotFound, RequestID: "req-1",
+		}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+		req.Header.Set("Accept-Language", tt.lang)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Response
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(tt.want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s (%s):\n got %s\nwant %s", tt.path, tt.lang, gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestFromValidation(t *testing.T) {
+	req := struct {
+		Email string `json:"email" validate:"required,email"`
+	}{Email: "nope"}
+
+	err := FromValidation(utils.ValidateStruct(req))
+	if err.Code != CodeValidationFailed || err.StatusCode() != fiber.StatusBadRequest {
+		t.Fatalf("got %s/%d", err.Code, err.StatusCode())
+	}
+	if len(err.Fields) != 1 || err.Fields[0] != (FieldError{Field: "email", Rule: "email"}) {
+		t.Errorf("fields = %+v", err.Fields)
+	}
+
+	if err := FromValidation(errors.New("unsupported")); err.Code != CodeValidationFailed || err.Fields != nil {
+		t.Errorf("plain error: %+v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package errors
+
+import (
+	"strings"
+
+	"engkids/pkg/utils"
+)
+
+// FieldError — ошибка проверки одного поля запроса. Field — имя поля в JSON
+// (для вложенных — путь вида children[0].age), Rule — нарушенное правило
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// WithFields добавляет ошибки полей
+func (e *Error) WithFields(fields []FieldError) *Error {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
+// FromValidation превращает ошибку utils.ValidateStruct в ошибку
+// VALIDATION_FAILED с ошибками полей. Сообщения подставляются при
+// формировании ответа на языке клиента
+func FromValidation(err error) *Error {
+	violations, ok := utils.Violations(err)
+	if !ok {
+		return Wrap(CodeValidationFailed, err)
+	}
+	fields := make([]FieldError, 0, len(violations))
+	for _, v := range violations {
+		fields = append(fields, FieldError{Field: v.Field, Rule: v.Rule, Param: v.Param})
+	}
+	return New(CodeValidationFailed).WithFields(fields)
+}
+
+var fieldMessages = map[string]map[string]string{
+	LangRU: {
+		"required":   "Обязательное поле",
+		"email":      "Некорректный email",
+		"min_length": "Минимальная длина — {param} символов",
+		"max_length": "Максимальная длина — {param} символов",
+		"len_length": "Длина должна быть {param} символов",
+		"min_items":  "Нужно не меньше {param} элементов",
+		"max_items":  "Допустимо не больше {param} элементов",
+		"min":        "Значение должно быть не меньше {param}",
+		"max":        "Значение должно быть не больше {param}",
+		"gte":        "Значение должно быть не меньше {param}",
+		"lte":        "Значение должно быть не больше {param}",
+		"gt":         "Значение должно быть больше {param}",
+		"lt":         "Значение должно быть меньше {param}",
+		"oneof":      "Допустимые значения: {param}",
+		"url":        "Некорректный URL",
+		"uuid":       "Некорректный UUID",
+		"password":   "Пароль должен быть не короче {param} символов и содержать буквы и цифры",
+		"child_age":  "Возраст ребёнка должен быть от {param} лет",
+		"cefr":       "Уровень должен быть одним из: {param}",
+		"lang_code":  "Укажите код языка ISO 639-1, например en или ru",
+		"eqfield":    "Значение должно совпадать с полем {param}",
+		"unique":     "Значение повторяется",
+		"media":      "Нужен ID загруженного файла: {param}",
+		"default":    "Некорректное значение",
+	},
+	LangEN: {
+		"required":   "This field is required",
+		"email":      "Invalid email address",
+		"min_length": "Must be at least {param} characters long",
+		"max_length": "Must be at most {param} characters long",
+		"len_length": "Must be exactly {param} characters long",
+		"min_items":  "Must contain at least {param} items",
+		"max_items":  "Must contain at most {param} items",
+		"min":        "Must be at least {param}",
+		"max":        "Must be at most {param}",
+		"gte":        "Must be at least {param}",
+		"lte":        "Must be at most {param}",
+		"gt":         "Must be greater than {param}",
+		"lt":         "Must be less than {param}",
+		"oneof":      "Must be one of: {param}",
+		"url":        "Invalid URL",
+		"uuid":       "Invalid UUID",
+		"password":   "Password must be at least {param} characters and contain letters and digits",
+		"child_age":  "Child age must be between {param} years",
+		"cefr":       "Level must be one of: {param}",
+		"lang_code":  "Use an ISO 639-1 language code such as en or ru",
+		"eqfield":    "Must match the {param} field",
+		"unique":     "Duplicate value",
+		"media":      "Must be the ID of an uploaded {param} file",
+		"default":    "Invalid value",
+	},
+}
+
+// FieldMessage возвращает текст ошибки поля на языке lang
+func FieldMessage(rule, param, lang string) string {
+	catalog, ok := fieldMessages[lang]
+	if !ok {
+		catalog = fieldMessages[Languages[0]]
+	}
+	msg, ok := catalog[rule]
+	if !ok {
+		msg = catalog["default"]
+	}
+	return strings.ReplaceAll(msg, "{param}", param)
+}
+
+// localizeFields заполняет Message у копий ошибок полей
+func localizeFields(fields []FieldError, lang string) []FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make([]FieldError, len(fields))
+	for i, f := range fields {
+		f.Message = FieldMessage(f.Rule, f.Param, lang)
+		out[i] = f
+	}
+	return out
+}
@@ -1,14 +1,66 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 )
 
-func Handle(c *fiber.Ctx, err error) error {
+// Response — тело ответа с ошибкой. Поле error оставлено строкой для
+// совместимости со старыми версиями приложения
+type Response struct {
+	Error     string         `json:"error"`
+	Code      Code           `json:"code"`
+	Details   map[string]any `json:"details,omitempty"`
+	Fields    []FieldError   `json:"fields,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+// Language выбирает язык сообщений по заголовку Accept-Language
+func Language(c *fiber.Ctx) string {
+	if lang := c.AcceptsLanguages(Languages...); lang != "" {
+		return lang
+	}
+	return Languages[0]
+}
+
+// From приводит любую ошибку к ошибке предметной области. Внутренние ошибки,
+// вызванные истёкшим или отменённым контекстом, отдаются как 504 и 503
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Code != CodeInternal {
+		return appErr
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(CodeTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return Wrap(CodeServiceUnavailable, err)
+	}
+	if appErr != nil {
+		return appErr
+	}
 	var ferr *fiber.Error
 	if errors.As(err, &ferr) {
-		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+		return &Error{Code: codeForStatus(ferr.Code), Status: ferr.Code, Err: err}
+	}
+	return Wrap(CodeInternal, err)
+}
+
+// Handle пишет ответ с ошибкой; подходит и как fiber.Config.ErrorHandler
+func Handle(c *fiber.Ctx, err error) error {
+	appErr := From(err)
+
+	lang := Language(c)
+	resp := Response{
+		Error:   Message(appErr.Code, lang, appErr.Details),
+		Code:    appErr.Code,
+		Details: appErr.Details,
+		Fields:  localizeFields(appErr.Fields, lang),
 	}
-	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Внутренняя ошибка"})
+	if rid, ok := c.Locals("requestid").(string); ok {
+		resp.RequestID = rid
+	}
+
+	return c.Status(appErr.StatusCode()).JSON(resp)
 }
@@ -0,0 +1,161 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Языки, на которые переведены сообщения; первый — язык по умолчанию
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+// Languages — поддерживаемые языки в порядке предпочтения по умолчанию
+var Languages = []string{LangRU, LangEN}
+
+var messages = map[string]map[Code]string{
+	LangRU: {
+		CodeBadRequest:         "Некорректный запрос",
+		CodeInvalidBody:        "Невозможно обработать данные",
+		CodeValidationFailed:   "Данные не прошли проверку",
+		CodeUnauthorized:       "Требуется авторизация",
+		CodeForbidden:          "Недостаточно прав",
+		CodeNotFound:           "Ресурс не найден",
+		CodeMethodNotAllowed:   "Метод не поддерживается",
+		CodeConflict:           "Конфликт данных",
+		CodePayloadTooLarge:    "Слишком большой запрос",
+		CodeTooManyRequests:    "Слишком много запросов, попробуйте позже",
+		CodeInternal:           "Внутренняя ошибка",
+		CodeServiceUnavailable: "Сервис временно недоступен",
+		CodeTimeout:            "Превышено время ожидания",
+
+		CodeAuthInvalidCredentials: "Неверный email или пароль",
+		CodeAuthUserExists:         "Пользователь уже существует",
+		CodeAuthTokenMissing:       "Необходим access токен",
+		CodeAuthTokenMalformed:     "Неверный формат Authorization",
+		CodeAuthTokenExpired:       "Access истёк, refresh не передан",
+		CodeAuthRefreshInvalid:     "Неверный или просроченный refresh токен",
+		CodeAuthForbidden:          "Недостаточно прав",
+
+		CodeChildNotFound:     "Ребёнок не найден",
+		CodeChildLimitReached: "Можно добавить не более {limit} детей",
+
+		CodeLogsInvalidQuery: "Неверный параметр {param}",
+		CodeLogsUnavailable:  "Не удалось получить логи",
+
+		CodeJobNotFound:     "Задача не найдена",
+		CodeJobInvalidState: "Действие недоступно для задачи в статусе {status}",
+		CodeJobInvalidQuery: "Неверный параметр {param}",
+
+		CodeGameNotFound:        "Игра не найдена",
+		CodeGameInvalidState:    "Действие недоступно на этапе {phase}",
+		CodeGameFull:            "В игре может быть не более {limit} игроков",
+		CodeGamePlayerExists:    "Ребёнок уже участвует в игре",
+		CodeGameNotPlayer:       "Ребёнок не участвует в игре",
+		CodeGameNotHost:         "Начать игру может только её создатель",
+		CodeGameNoPlayers:       "В игре пока нет игроков",
+		CodeGameRoundOver:       "Этот раунд уже закончился",
+		CodeGameAlreadyAnswered: "Ответ на этот вопрос уже принят",
+		CodeGameNotEnoughWords:  "В уроке недостаточно слов для игры: нужно хотя бы {min}",
+
+		CodeWordNotFound:       "Слово не найдено",
+		CodeWordExists:         "Слово {headword} уже есть в словаре",
+		CodeLessonWordNotFound: "Слова нет в этом уроке",
+
+		CodeSyncInvalidCursor:    "Некорректный курсор синхронизации, начните синхронизацию заново",
+		CodeSyncInvalidOperation: "Операция заполнена неверно: поле {param}",
+
+		CodeIdempotencyKeyReused:  "Ключ Idempotency-Key уже использован для другого запроса",
+		CodeIdempotencyInProgress: "Запрос с этим ключом Idempotency-Key ещё выполняется",
+
+		CodeMediaNotFound:        "Файл не найден",
+		CodeMediaTooLarge:        "Файл больше {limit} МБ",
+		CodeMediaUnsupportedType: "Формат {type} не поддерживается: загрузите PNG, JPEG, GIF, WebP, MP3, WAV или OGG",
+		CodeMediaInvalidFile:     "Файл повреждён и не открывается",
+		CodeMediaLinkInvalid:     "Ссылка на файл недействительна или устарела",
+	},
+	LangEN: {
+		CodeBadRequest:         "Bad request",
+		CodeInvalidBody:        "Unable to parse request body",
+		CodeValidationFailed:   "Validation failed",
+		CodeUnauthorized:       "Authentication required",
+		CodeForbidden:          "Insufficient permissions",
+		CodeNotFound:           "Resource not found",
+		CodeMethodNotAllowed:   "Method not allowed",
+		CodeConflict:           "Conflict",
+		CodePayloadTooLarge:    "Request is too large",
+		CodeTooManyRequests:    "Too many requests, try again later",
+		CodeInternal:           "Internal error",
+		CodeServiceUnavailable: "Service temporarily unavailable",
+		CodeTimeout:            "Request timed out",
+
+		CodeAuthInvalidCredentials: "Invalid email or password",
+		CodeAuthUserExists:         "User already exists",
+		CodeAuthTokenMissing:       "Access token is required",
+		CodeAuthTokenMalformed:     "Malformed Authorization header",
+		CodeAuthTokenExpired:       "Access token expired and no refresh token was provided",
+		CodeAuthRefreshInvalid:     "Invalid or expired refresh token",
+		CodeAuthForbidden:          "Insufficient permissions",
+
+		CodeChildNotFound:     "Child not found",
+		CodeChildLimitReached: "You can add at most {limit} children",
+
+		CodeLogsInvalidQuery: "Invalid parameter {param}",
+		CodeLogsUnavailable:  "Failed to retrieve logs",
+
+		CodeJobNotFound:     "Job not found",
+		CodeJobInvalidState: "Action is not available for a job in status {status}",
+		CodeJobInvalidQuery: "Invalid parameter {param}",
+
+		CodeGameNotFound:        "Game not found",
+		CodeGameInvalidState:    "Action is not available during the {phase} phase",
+		CodeGameFull:            "A game can have at most {limit} players",
+		CodeGamePlayerExists:    "The child has already joined the game",
+		CodeGameNotPlayer:       "The child is not playing in this game",
+		CodeGameNotHost:         "Only the host can start the game",
+		CodeGameNoPlayers:       "The game has no players yet",
+		CodeGameRoundOver:       "This round is already over",
+		CodeGameAlreadyAnswered: "An answer to this question has already been accepted",
+		CodeGameNotEnoughWords:  "The lesson does not have enough words for a game: at least {min} are needed",
+
+		CodeWordNotFound:       "Word not found",
+		CodeWordExists:         "The word {headword} is already in the dictionary",
+		CodeLessonWordNotFound: "The word is not part of this lesson",
+
+		CodeSyncInvalidCursor:    "Invalid sync cursor, start a full sync",
+		CodeSyncInvalidOperation: "Invalid operation: field {param}",
+
+		CodeIdempotencyKeyReused:  "The Idempotency-Key has already been used for a different request",
+		CodeIdempotencyInProgress: "A request with this Idempotency-Key is still in progress",
+
+		CodeMediaNotFound:        "File not found",
+		CodeMediaTooLarge:        "The file is larger than {limit} MB",
+		CodeMediaUnsupportedType: "The {type} format is not supported: upload PNG, JPEG, GIF, WebP, MP3, WAV or OGG",
+		CodeMediaInvalidFile:     "The file is damaged and cannot be opened",
+		CodeMediaLinkInvalid:     "The file link is invalid or has expired",
+	},
+}
+
+// Message возвращает текст ошибки на языке lang, подставляя details вместо {ключ}
+func Message(code Code, lang string, details map[string]any) string {
+	catalog, ok := messages[lang]
+	if !ok {
+		catalog = messages[Languages[0]]
+	}
+	msg, ok := catalog[code]
+	if !ok {
+		if msg, ok = messages[Languages[0]][code]; !ok {
+			msg = catalog[CodeInternal]
+		}
+	}
+
+	if len(details) > 0 && strings.Contains(msg, "{") {
+		pairs := make([]string, 0, len(details)*2)
+		for k, v := range details {
+			pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+		}
+		msg = strings.NewReplacer(pairs...).Replace(msg)
+	}
+	return msg
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Handler обрабатывает событие в виде JSON из outbox
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type subscription struct {
+	name   string
+	handle Handler
+}
+
+// Bus — шина событий внутри процесса
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string][]subscription
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: map[string][]subscription{}}
+}
+
+// Subscribe регистрирует обработчик событий типа eventType. name попадает в
+// логи и ошибки доставки
+func (b *Bus) Subscribe(eventType, name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[eventType] = append(b.subs[eventType], subscription{name: name, handle: h})
+}
+
+// Subscribe регистрирует типизированный обработчик: тип события берётся из E,
+// payload декодируется в E
+func Subscribe[E Event](b *Bus, name string, fn func(ctx context.Context, e E) error) {
+	var zero E
+	b.Subscribe(zero.EventType(), name, func(ctx context.Context, payload json.RawMessage) error {
+		var e E
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return fmt.Errorf("decode %s: %w", zero.EventType(), err)
+		}
+		return fn(ctx, e)
+	})
+}
+
+// Deliver вызывает всех подписчиков события. Ошибки подписчиков объединяются;
+// при ошибке событие будет доставлено повторно всем подписчикам
+func (b *Bus) Deliver(ctx context.Context, eventType string, payload json.RawMessage) error {
+	b.mu.RLock()
+	subs := b.subs[eventType]
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, s := range subs {
+		if err := s.handle(ctx, payload); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/logger"
+	"engkids/pkg/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DispatcherOptions — настройки доставки событий
+type DispatcherOptions struct {
+	PollInterval time.Duration     // как часто проверять outbox без Notify
+	BatchSize    int               // сколько событий забирать за раз
+	MaxAttempts  int               // после стольких неудач событие переходит в dead
+	MinBackoff   time.Duration     // пауза перед первой повторной попыткой
+	MaxBackoff   time.Duration     // максимальная пауза между попытками
+	LeaseTimeout time.Duration     // через сколько забранное, но не отмеченное событие доставляется снова
+	Metrics      *metrics.Registry // nil — метрики не публикуются
+}
+
+func (o *DispatcherOptions) setDefaults() {
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 10 * time.Minute
+	}
+	if o.LeaseTimeout <= 0 {
+		o.LeaseTimeout = 5 * time.Minute
+	}
+	if o.Metrics == nil {
+		o.Metrics = metrics.NewRegistry()
+	}
+}
+
+// Dispatcher забирает события из outbox и доставляет их в шину
+type Dispatcher struct {
+	repos *repositories.Repositories
+	bus   *Bus
+	opts  DispatcherOptions
+	wake  chan struct{}
+
+	delivered *metrics.CounterVec
+}
+
+func NewDispatcher(repos *repositories.Repositories, bus *Bus, opts DispatcherOptions) *Dispatcher {
+	opts.setDefaults()
+	return &Dispatcher{
+		repos: repos,
+		bus:   bus,
+		opts:  opts,
+		wake:  make(chan struct{}, 1),
+		delivered: opts.Metrics.NewCounterVec(
+			"engkids_outbox_deliveries_total",
+			"Количество попыток доставки событий из outbox.",
+			"type", "result",
+		),
+	}
+}
+
+// Notify будит диспетчер, не дожидаясь PollInterval. Вызывается после коммита
+// транзакции с событием
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run доставляет события, пока не отменён ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// пока outbox отдаёт полные пачки, забираем следующие без паузы
+		for {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).WithError(err).Error("Failed to dispatch outbox events")
+			}
+			if err != nil || n < d.opts.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DispatchOnce доставляет одну пачку событий и возвращает их количество.
+// Забранные события откладываются на LeaseTimeout, поэтому несколько
+// экземпляров приложения не доставляют одно событие одновременно. Доставка
+// идёт вне транзакции: медленный подписчик не держит блокировки в базе.
+// Ошибка сохранения одного события не останавливает пачку: иначе остальные
+// ждали бы окончания аренды недоставленными
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	due, err := d.repos.Outbox.ClaimDue(ctx, time.Now(), d.opts.LeaseTimeout, d.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for i := range due {
+		d.deliver(ctx, &due[i])
+		if err := d.repos.Outbox.Update(ctx, &due[i]); err != nil {
+			errs = append(errs, fmt.Errorf("outbox event %d: %w", due[i].ID, err))
+		}
+	}
+	return len(due), errors.Join(errs...)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, e *models.OutboxEvent) {
+	e.Attempts++
+	err := d.bus.Deliver(ctx, e.Type, e.Payload)
+	if err == nil {
+		now := time.Now()
+		e.Status, e.ProcessedAt, e.LastError = models.OutboxProcessed, &now, ""
+		d.delivered.WithLabelValues(e.Type, "ok").Inc()
+		return
+	}
+
+	e.LastError = err.Error()
+	log := logger.FromContext(ctx).WithError(err).WithFields(logrus.Fields{
+		"event_id":   e.ID,
+		"event_type": e.Type,
+		"attempts":   e.Attempts,
+	})
+	if e.Attempts >= d.opts.MaxAttempts {
+		e.Status = models.OutboxDead
+		d.delivered.WithLabelValues(e.Type, "dead").Inc()
+		log.Error("Outbox event moved to dead state")
+		return
+	}
+	e.NextAttemptAt = time.Now().Add(d.backoff(e.Attempts))
+	d.delivered.WithLabelValues(e.Type, "retry").Inc()
+	log.Warn("Outbox event delivery failed, will retry")
+}
+
+// backoff — экспоненциальная пауза с джиттером перед попыткой attempts+1
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.opts.MinBackoff
+	for i := 1; i < attempts && b < d.opts.MaxBackoff; i++ {
+		b *= 2
+	}
+	if b > d.opts.MaxBackoff {
+		b = d.opts.MaxBackoff
+	}
+	return b/2 + time.Duration(rand.Int63n(int64(b/2)+1))
+}
@@ -0,0 +1,215 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/internal/repositories/memory"
+)
+
+// outboxEvent забирает событие id, если к моменту at его пора доставлять
+func outboxEvent(t *testing.T, repos *repositories.Repositories, id uint, at time.Time) models.OutboxEvent {
+	t.Helper()
+	due, err := repos.Outbox.ClaimDue(context.Background(), at, time.Minute, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range due {
+		if e.ID == id {
+			return e
+		}
+	}
+	return models.OutboxEvent{}
+}
+
+func TestDispatcherRetriesUntilDelivered(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	bus := NewBus()
+
+	var got []UserRegistered
+	fail := true
+	Subscribe(bus, "welcome", func(_ context.Context, e UserRegistered) error {
+		if fail {
+			fail = false
+			return errors.New("mail server down")
+		}
+		got = append(got, e)
+		return nil
+	})
+
+	if err := Record(ctx, repos.Outbox, UserRegistered{UserID: 7, Role: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(repos, bus, DispatcherOptions{MinBackoff: time.Hour, MaxBackoff: 2 * time.Hour})
+	if n, err := d.DispatchOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("first dispatch: n=%d err=%v", n, err)
+	}
+	// до истечения паузы событие не забирается
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("event redelivered before backoff: %d", n)
+	}
+	if e := outboxEvent(t, repos, 1, time.Now().Add(20*time.Minute)); e.ID != 0 {
+		t.Fatalf("backoff not applied: %v", e.NextAttemptAt)
+	}
+	retry := outboxEvent(t, repos, 1, time.Now().Add(24*time.Hour))
+	if retry.Status != models.OutboxPending || retry.Attempts != 1 || retry.LastError == "" {
+		t.Fatalf("unexpected state after failure: %+v", retry)
+	}
+
+	retry.NextAttemptAt = time.Now()
+	if err := repos.Outbox.Update(ctx, &retry); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.DispatchOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("retry dispatch: n=%d err=%v", n, err)
+	}
+	if len(got) != 1 || got[0].UserID != 7 {
+		t.Fatalf("delivered %+v", got)
+	}
+	if e := outboxEvent(t, repos, 1, time.Now().Add(24*time.Hour)); e.ID != 0 {
+		t.Fatalf("processed event is still due: %+v", e)
+	}
+}
+
+func TestDispatcherLeasesClaimedEvents(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	bus := NewBus()
+	other := NewDispatcher(repos, bus, DispatcherOptions{})
+
+	// пока идёт доставка, второй экземпляр не видит событие и может
+	// работать с outbox: доставка не держит транзакцию
+	var concurrent int
+	delivered := 0
+	Subscribe(bus, "welcome", func(ctx context.Context, e UserRegistered) error {
+		delivered++
+		n, err := other.DispatchOnce(ctx)
+		if err != nil {
+			return err
+		}
+		concurrent += n
+		return nil
+	})
+	if err := Record(ctx, repos.Outbox, UserRegistered{UserID: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(repos, bus, DispatcherOptions{LeaseTimeout: time.Hour})
+	if n, err := d.DispatchOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("dispatch: n=%d err=%v", n, err)
+	}
+	if delivered != 1 || concurrent != 0 {
+		t.Fatalf("delivered %d times, %d concurrently", delivered, concurrent)
+	}
+
+	// забранное, но не отмеченное событие возвращается после истечения аренды
+	if err := Record(ctx, repos.Outbox, UserRegistered{UserID: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if due, err := repos.Outbox.ClaimDue(ctx, time.Now(), time.Hour, 10); err != nil || len(due) != 1 {
+		t.Fatalf("claim: %v %v", due, err)
+	}
+	if e := outboxEvent(t, repos, 2, time.Now().Add(30*time.Minute)); e.ID != 0 {
+		t.Fatalf("leased event claimed twice: %+v", e)
+	}
+	if e := outboxEvent(t, repos, 2, time.Now().Add(2*time.Hour)); e.ID != 2 {
+		t.Fatal("event was not released after the lease expired")
+	}
+}
+
+// failingOutbox не сохраняет состояние события failID
+type failingOutbox struct {
+	repositories.OutboxRepository
+	failID uint
+}
+
+func (o failingOutbox) Update(ctx context.Context, e *models.OutboxEvent) error {
+	if e.ID == o.failID {
+		return errors.New("connection reset")
+	}
+	return o.OutboxRepository.Update(ctx, e)
+}
+
+// Ошибка сохранения одного события не оставляет остальные из пачки
+// забранными до конца аренды
+func TestDispatcherContinuesAfterUpdateError(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	bus := NewBus()
+	var got []uint
+	Subscribe(bus, "welcome", func(_ context.Context, e UserRegistered) error {
+		got = append(got, e.UserID)
+		return nil
+	})
+	for _, id := range []uint{7, 8, 9} {
+		if err := Record(ctx, repos.Outbox, UserRegistered{UserID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broken := *repos
+	broken.Outbox = failingOutbox{OutboxRepository: repos.Outbox, failID: 1}
+	d := NewDispatcher(&broken, bus, DispatcherOptions{LeaseTimeout: time.Hour})
+	if n, err := d.DispatchOnce(ctx); err == nil || n != 3 {
+		t.Fatalf("dispatch: n=%d err=%v", n, err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("delivered %v", got)
+	}
+	// сохранились все события, кроме первого; оно вернётся после аренды
+	if due, err := repos.Outbox.ClaimDue(ctx, time.Now().Add(2*time.Hour), time.Hour, 10); err != nil || len(due) != 1 || due[0].ID != 1 {
+		t.Fatalf("due after lease: %+v %v", due, err)
+	}
+}
+
+func TestDispatcherMovesEventToDead(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	bus := NewBus()
+	bus.Subscribe(TypeLessonCompleted, "broken", func(context.Context, json.RawMessage) error {
+		return errors.New("always fails")
+	})
+	if err := Record(ctx, repos.Outbox, LessonCompleted{ChildID: 1, LessonID: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(repos, bus, DispatcherOptions{MaxAttempts: 1})
+	if _, err := d.DispatchOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("dead event dispatched again")
+	}
+}
+
+func TestRecordRollsBackWithTransaction(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+
+	err := repos.Tx.Transaction(ctx, func(tx *repositories.Repositories) error {
+		if err := tx.Users.Create(ctx, &models.User{Email: "parent@example.com"}); err != nil {
+			return err
+		}
+		if err := Record(ctx, tx.Outbox, UserRegistered{UserID: 1}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if n, _ := NewDispatcher(repos, NewBus(), DispatcherOptions{}).DispatchOnce(ctx); n != 0 {
+		t.Fatalf("rolled back event was dispatched")
+	}
+	if _, err := repos.Users.FindByEmail(ctx, "parent@example.com"); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("rolled back user exists: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package events — доменные события и их доставка. Сервис записывает событие
+// в outbox в той же транзакции, что и изменение данных (Record), а Dispatcher
+// после коммита доставляет его подписчикам шины не менее одного раза.
+// Подписчики поэтому должны быть идемпотентными
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+)
+
+// Типы событий. Значения хранятся в outbox, менять их нельзя
+const (
+	TypeUserRegistered  = "user.registered"
+	TypeLessonCompleted = "lesson.completed"
+	TypeBadgeUnlocked   = "badge.unlocked"
+	TypeStreakAtRisk    = "streak.at_risk"
+)
+
+// Event — доменное событие; сериализуется в JSON
+type Event interface {
+	EventType() string
+}
+
+// UserRegistered — зарегистрирован новый пользователь
+type UserRegistered struct {
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (UserRegistered) EventType() string { return TypeUserRegistered }
+
+// LessonCompleted — ребёнок прошёл урок
+type LessonCompleted struct {
+	ChildID     uint      `json:"child_id"`
+	LessonID    uint      `json:"lesson_id"`
+	Score       int       `json:"score"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+func (LessonCompleted) EventType() string { return TypeLessonCompleted }
+
+// BadgeUnlocked — ребёнок получил награду
+type BadgeUnlocked struct {
+	ChildID    uint      `json:"child_id"`
+	Badge      string    `json:"badge"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+}
+
+func (BadgeUnlocked) EventType() string { return TypeBadgeUnlocked }
+
+// StreakAtRisk — ребёнок сегодня ещё не занимался, и серия прервётся в полночь
+type StreakAtRisk struct {
+	ChildID uint   `json:"child_id"`
+	Streak  int    `json:"streak"`
+	Date    string `json:"date"` // день, который нужно не пропустить, YYYY-MM-DD
+}
+
+func (StreakAtRisk) EventType() string { return TypeStreakAtRisk }
+
+// Notifier будит доставку событий после коммита транзакции, чтобы подписчики
+// не ждали следующего опроса outbox
+type Notifier interface {
+	Notify()
+}
+
+// Record записывает событие в outbox. Вызывается внутри транзакции
+// repositories.Transactor, чтобы событие сохранилось вместе с изменением
+func Record(ctx context.Context, outbox repositories.OutboxRepository, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", e.EventType(), err)
+	}
+	return outbox.Add(ctx, &models.OutboxEvent{Type: e.EventType(), Payload: payload})
+}
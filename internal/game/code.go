@@ -0,0 +1,35 @@
+package game
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// Код игры: символы без похожих друг на друга 0/O и 1/I
+const (
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	CodeLength   = 6
+)
+
+// NewCode возвращает случайный код для входа в игру
+func NewCode() string {
+	buf := make([]byte, CodeLength)
+	rand.Read(buf)
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(buf)
+}
+
+// ValidCode проверяет формат кода
+func ValidCode(code string) bool {
+	if len(code) != CodeLength {
+		return false
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(codeAlphabet, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+// Package game — правила викторины по словам урока для нескольких игроков.
+// Game — детерминированный конечный автомат: текущее время передаётся в каждый
+// метод, поэтому игру можно проиграть в тестах без таймеров и сокетов.
+// Хранение сессий, таймеры и рассылка состояния — в services.GameService
+package game
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Phase — этап игры
+type Phase string
+
+const (
+	PhaseLobby    Phase = "lobby"    // игроки собираются
+	PhaseQuestion Phase = "question" // идёт раунд, принимаются ответы
+	PhaseReveal   Phase = "reveal"   // раунд окончен, показан правильный ответ
+	PhaseFinished Phase = "finished"
+)
+
+// Очки за правильный ответ: база и бонус за скорость, который линейно
+// убывает до нуля к концу раунда и округляется до 10 очков
+const (
+	BasePoints  = 500
+	SpeedPoints = 500
+)
+
+// Ошибки действий игроков
+var (
+	ErrWrongPhase      = errors.New("action is not allowed in this phase")
+	ErrFull            = errors.New("game is full")
+	ErrAlreadyJoined   = errors.New("player already joined")
+	ErrNotPlayer       = errors.New("not a player of this game")
+	ErrNotHost         = errors.New("only the host can start the game")
+	ErrNoPlayers       = errors.New("game has no players")
+	ErrRoundOver       = errors.New("round is over")
+	ErrAlreadyAnswered = errors.New("already answered")
+	ErrInvalidOption   = errors.New("invalid option")
+)
+
+// Config — параметры игры
+type Config struct {
+	QuestionTime time.Duration // время на ответ
+	RevealTime   time.Duration // пауза с правильным ответом перед следующим вопросом
+	MaxPlayers   int
+}
+
+// Player — участник игры: ребёнок, за которого отвечает родитель ParentID
+type Player struct {
+	ChildID  uint
+	ParentID uint
+	Name     string
+	Score    int
+	Correct  int
+}
+
+type answer struct {
+	option int
+	points int
+}
+
+// Game — состояние одной игры. Методы не потокобезопасны
+type Game struct {
+	Code     string
+	HostID   uint // пользователь, создавший игру
+	LessonID uint
+
+	cfg       Config
+	questions []Question
+	players   []*Player
+
+	phase      Phase
+	round      int // номер текущего вопроса с 1; 0 — в лобби
+	roundStart time.Time
+	deadline   time.Time
+	answers    map[uint]answer // ответы текущего раунда по ChildID
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// New создаёт игру в лобби
+func New(code string, hostID, lessonID uint, cfg Config, questions []Question) *Game {
+	return &Game{
+		Code:      code,
+		HostID:    hostID,
+		LessonID:  lessonID,
+		cfg:       cfg,
+		questions: questions,
+		phase:     PhaseLobby,
+		answers:   map[uint]answer{},
+	}
+}
+
+// Phase возвращает текущий этап
+func (g *Game) Phase() Phase { return g.phase }
+
+// Deadline — когда Tick сменит этап; нулевое время в лобби и после окончания
+func (g *Game) Deadline() time.Time { return g.deadline }
+
+// Join добавляет игрока в лобби
+func (g *Game) Join(p Player) error {
+	if g.phase != PhaseLobby {
+		return ErrWrongPhase
+	}
+	if g.player(p.ChildID) != nil {
+		return ErrAlreadyJoined
+	}
+	if g.cfg.MaxPlayers > 0 && len(g.players) >= g.cfg.MaxPlayers {
+		return ErrFull
+	}
+	p.Score, p.Correct = 0, 0
+	g.players = append(g.players, &p)
+	return nil
+}
+
+// Leave убирает игрока из лобби. После старта игрок остаётся в таблице
+func (g *Game) Leave(childID uint) error {
+	if g.phase != PhaseLobby {
+		return ErrWrongPhase
+	}
+	for i, p := range g.players {
+		if p.ChildID == childID {
+			g.players = append(g.players[:i], g.players[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotPlayer
+}
+
+// Player возвращает копию игрока или nil
+func (g *Game) Player(childID uint) *Player {
+	if p := g.player(childID); p != nil {
+		cp := *p
+		return &cp
+	}
+	return nil
+}
+
+func (g *Game) player(childID uint) *Player {
+	for _, p := range g.players {
+		if p.ChildID == childID {
+			return p
+		}
+	}
+	return nil
+}
+
+// Start начинает первый раунд. Начать игру может только её создатель
+func (g *Game) Start(userID uint, now time.Time) error {
+	if g.phase != PhaseLobby {
+		return ErrWrongPhase
+	}
+	if userID != g.HostID {
+		return ErrNotHost
+	}
+	if len(g.players) == 0 {
+		return ErrNoPlayers
+	}
+	g.StartedAt = now
+	g.ask(1, now)
+	return nil
+}
+
+// Answer принимает ответ игрока на вопрос round (с 1). Когда ответили все, раунд
+// заканчивается, не дожидаясь таймера
+func (g *Game) Answer(childID uint, round, option int, now time.Time) error {
+	if g.phase != PhaseQuestion {
+		if g.phase == PhaseReveal && round == g.round {
+			return ErrRoundOver
+		}
+		return ErrWrongPhase
+	}
+	p := g.player(childID)
+	if p == nil {
+		return ErrNotPlayer
+	}
+	if round != g.round || !now.Before(g.deadline) {
+		return ErrRoundOver
+	}
+	if _, ok := g.answers[childID]; ok {
+		return ErrAlreadyAnswered
+	}
+	q := g.questions[g.round-1]
+	if option < 0 || option >= len(q.Options) {
+		return ErrInvalidOption
+	}
+
+	a := answer{option: option}
+	if option == q.Answer {
+		a.points = Points(now.Sub(g.roundStart), g.cfg.QuestionTime)
+		p.Score += a.points
+		p.Correct++
+	}
+	g.answers[childID] = a
+
+	if len(g.answers) == len(g.players) {
+		g.reveal(now)
+	}
+	return nil
+}
+
+// Tick переводит игру на следующий этап, если наступил дедлайн, и сообщает,
+// изменилось ли состояние. За один вызов — не больше одного перехода
+func (g *Game) Tick(now time.Time) bool {
+	if g.deadline.IsZero() || now.Before(g.deadline) {
+		return false
+	}
+	switch g.phase {
+	case PhaseQuestion:
+		g.reveal(now)
+	case PhaseReveal:
+		if g.round < len(g.questions) {
+			g.ask(g.round+1, now)
+		} else {
+			g.phase, g.deadline, g.FinishedAt = PhaseFinished, time.Time{}, now
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (g *Game) ask(round int, now time.Time) {
+	g.phase, g.round = PhaseQuestion, round
+	g.roundStart, g.deadline = now, now.Add(g.cfg.QuestionTime)
+	clear(g.answers)
+}
+
+func (g *Game) reveal(now time.Time) {
+	g.phase, g.deadline = PhaseReveal, now.Add(g.cfg.RevealTime)
+}
+
+// Points — очки за правильный ответ через elapsed после начала раунда длиной limit
+func Points(elapsed, limit time.Duration) int {
+	if limit <= 0 {
+		return BasePoints
+	}
+	remaining := min(max(limit-elapsed, 0), limit)
+	bonus := float64(SpeedPoints) * float64(remaining) / float64(limit)
+	return BasePoints + int(math.Round(bonus/10))*10
+}
+
+// Leaderboard возвращает игроков по убыванию очков; при равенстве выше тот,
+// у кого больше правильных ответов, затем — кто раньше вошёл в игру
+func (g *Game) Leaderboard() []Player {
+	out := make([]Player, len(g.players))
+	for i, p := range g.players {
+		out[i] = *p
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Correct > out[j].Correct
+	})
+	return out
+}
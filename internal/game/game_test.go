@@ -0,0 +1,212 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+func testGame(t *testing.T) *Game {
+	t.Helper()
+	questions := []Question{
+		{Prompt: "cat", Options: []string{"кошка", "собака"}, Answer: 0},
+		{Prompt: "dog", Options: []string{"кошка", "собака"}, Answer: 1},
+	}
+	g := New("ABC123", 1, 7, Config{QuestionTime: 10 * time.Second, RevealTime: 3 * time.Second, MaxPlayers: 2}, questions)
+	for _, p := range []Player{{ChildID: 10, ParentID: 1, Name: "Аня"}, {ChildID: 20, ParentID: 2, Name: "Боря"}} {
+		if err := g.Join(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestGameFlow(t *testing.T) {
+	g := testGame(t)
+	if err := g.Join(Player{ChildID: 30}); !errors.Is(err, ErrFull) {
+		t.Fatalf("join full game: %v", err)
+	}
+	if err := g.Start(2, t0); !errors.Is(err, ErrNotHost) {
+		t.Fatalf("start by guest: %v", err)
+	}
+	if err := g.Start(1, t0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Join(Player{ChildID: 30}); !errors.Is(err, ErrWrongPhase) {
+		t.Fatalf("join started game: %v", err)
+	}
+
+	// раунд 1: Аня отвечает верно через 2 с, Боря ошибается — раунд заканчивается досрочно
+	if err := g.Answer(10, 1, 0, t0.Add(2*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Answer(10, 1, 0, t0.Add(3*time.Second)); !errors.Is(err, ErrAlreadyAnswered) {
+		t.Fatalf("second answer: %v", err)
+	}
+	if err := g.Answer(20, 1, 5, t0.Add(3*time.Second)); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("invalid option: %v", err)
+	}
+	if err := g.Answer(20, 1, 1, t0.Add(4*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if g.Phase() != PhaseReveal || !g.Deadline().Equal(t0.Add(7*time.Second)) {
+		t.Fatalf("phase %s, deadline %v", g.Phase(), g.Deadline())
+	}
+	if err := g.Answer(20, 1, 0, t0.Add(5*time.Second)); !errors.Is(err, ErrRoundOver) {
+		t.Fatalf("answer after reveal: %v", err)
+	}
+
+	s := g.State()
+	if *s.Question.Answer != 0 || s.Players[0].Name != "Аня" || s.Players[0].Score != 900 || *s.Players[0].Points != 900 || *s.Players[1].Points != 0 {
+		t.Fatalf("reveal state: %+v", s)
+	}
+
+	// раунд 2: никто не ответил до таймера
+	if g.Tick(t0.Add(6 * time.Second)) {
+		t.Fatal("tick before deadline changed state")
+	}
+	if !g.Tick(t0.Add(7*time.Second)) || g.Phase() != PhaseQuestion {
+		t.Fatalf("phase %s, want question", g.Phase())
+	}
+	if s := g.State(); s.Round != 2 || s.Question.Answer != nil || s.Question.Prompt != "dog" {
+		t.Fatalf("question state: %+v", s.Question)
+	}
+	if err := g.Answer(20, 2, 1, t0.Add(17*time.Second)); !errors.Is(err, ErrRoundOver) {
+		t.Fatalf("answer at deadline: %v", err)
+	}
+	g.Tick(t0.Add(17 * time.Second))
+	g.Tick(t0.Add(20 * time.Second))
+	if g.Phase() != PhaseFinished || !g.FinishedAt.Equal(t0.Add(20*time.Second)) {
+		t.Fatalf("phase %s, finished at %v", g.Phase(), g.FinishedAt)
+	}
+	if g.Tick(t0.Add(time.Hour)) {
+		t.Fatal("finished game changed state")
+	}
+
+	board := g.Leaderboard()
+	if board[0].ChildID != 10 || board[0].Correct != 1 || board[1].Score != 0 {
+		t.Fatalf("leaderboard: %+v", board)
+	}
+}
+
+func TestLobby(t *testing.T) {
+	g := testGame(t)
+	if err := g.Join(Player{ChildID: 10}); !errors.Is(err, ErrAlreadyJoined) {
+		t.Fatalf("rejoin: %v", err)
+	}
+	if err := g.Leave(10); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Leave(10); !errors.Is(err, ErrNotPlayer) {
+		t.Fatalf("leave twice: %v", err)
+	}
+	if err := g.Leave(20); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Start(1, t0); !errors.Is(err, ErrNoPlayers) {
+		t.Fatalf("start empty game: %v", err)
+	}
+	if s := g.State(); s.Phase != PhaseLobby || s.Deadline != nil || len(s.Players) != 0 {
+		t.Fatalf("lobby state: %+v", s)
+	}
+}
+
+func TestPoints(t *testing.T) {
+	limit := 10 * time.Second
+	cases := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{0, 1000},
+		{-time.Second, 1000},
+		{50 * time.Millisecond, 1000},
+		{5 * time.Second, 750},
+		{limit, 500},
+		{2 * limit, 500},
+	}
+	for _, tc := range cases {
+		if got := Points(tc.elapsed, limit); got != tc.want {
+			t.Errorf("Points(%v) = %d, want %d", tc.elapsed, got, tc.want)
+		}
+	}
+}
+
+func TestRanks(t *testing.T) {
+	board := []Player{{Score: 900, Correct: 1}, {Score: 900, Correct: 1}, {Score: 900}, {}}
+	if got := Ranks(board); !reflect.DeepEqual(got, []int{1, 1, 3, 4}) {
+		t.Fatalf("ranks = %v", got)
+	}
+}
+
+func TestBuildQuestions(t *testing.T) {
+	words := []Word{
+		{"cat", "кошка"}, {"dog", "собака"}, {"fox", "лиса"},
+		{"pup", "собака"}, // тот же перевод — отбрасывается
+		{"owl", ""},
+	}
+	build := func() []Question {
+		q, err := BuildQuestions(words, 5, 4, rand.New(rand.NewPCG(1, 2)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+
+	questions := build()
+	if !reflect.DeepEqual(questions, build()) {
+		t.Fatal("same seed gave different questions")
+	}
+	if len(questions) != 3 {
+		t.Fatalf("got %d questions, want 3", len(questions))
+	}
+	translations := map[string]string{"cat": "кошка", "dog": "собака", "fox": "лиса"}
+	seen := map[string]bool{}
+	for _, q := range questions {
+		if len(q.Options) != 3 || q.Options[q.Answer] != translations[q.Prompt] || seen[q.Prompt] {
+			t.Errorf("bad question %+v", q)
+		}
+		seen[q.Prompt] = true
+	}
+
+	if _, err := BuildQuestions(words[:1], 5, 4, rand.New(rand.NewPCG(1, 2))); !errors.Is(err, ErrNotEnoughWords) {
+		t.Fatalf("one word: %v", err)
+	}
+}
+
+// Игра, сохранённая посреди раунда, продолжается с того же места: ответы
+// и очки не теряются, правильный ответ по-прежнему скрыт
+func TestGameJSONRoundTrip(t *testing.T) {
+	g := testGame(t)
+	if err := g.Start(1, t0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Answer(10, 1, 0, t0.Add(2*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Game
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.State(), g.State()) {
+		t.Fatalf("state changed:\n got %+v\nwant %+v", restored.State(), g.State())
+	}
+	if err := restored.Answer(10, 1, 0, t0.Add(3*time.Second)); !errors.Is(err, ErrAlreadyAnswered) {
+		t.Fatalf("answers lost: %v", err)
+	}
+	if err := restored.Answer(20, 1, 1, t0.Add(4*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Phase() != PhaseReveal || restored.Player(10).Score != g.Player(10).Score {
+		t.Fatalf("restored game: phase %s, players %+v", restored.Phase(), restored.Leaderboard())
+	}
+}
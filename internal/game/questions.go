@@ -0,0 +1,76 @@
+package game
+
+import (
+	"errors"
+	"math/rand/v2"
+)
+
+// MinWords — сколько слов с переводом нужно уроку для игры
+const MinWords = 2
+
+// ErrNotEnoughWords — в уроке меньше MinWords слов
+var ErrNotEnoughWords = errors.New("not enough words for a game")
+
+// Word — слово урока и его перевод на язык игроков
+type Word struct {
+	Text        string
+	Translation string
+}
+
+// Question — слово и варианты перевода; Answer — индекс правильного
+type Question struct {
+	Prompt  string
+	Options []string
+	Answer  int
+}
+
+// BuildQuestions выбирает rounds случайных слов и к каждому options вариантов
+// перевода; неправильные варианты — переводы других слов урока. Если слов
+// меньше, вопросов и вариантов будет меньше. При одном и том же rng результат одинаковый
+func BuildQuestions(words []Word, rounds, options int, rng *rand.Rand) ([]Question, error) {
+	words = uniqueTranslations(words)
+	if len(words) < MinWords {
+		return nil, ErrNotEnoughWords
+	}
+	rounds = min(rounds, len(words))
+	options = max(min(options, len(words)), 2)
+
+	order := rng.Perm(len(words))
+	questions := make([]Question, 0, rounds)
+	for _, i := range order[:rounds] {
+		q := Question{Prompt: words[i].Text, Options: []string{words[i].Translation}}
+		for _, j := range rng.Perm(len(words)) {
+			if len(q.Options) == options {
+				break
+			}
+			if j != i {
+				q.Options = append(q.Options, words[j].Translation)
+			}
+		}
+		rng.Shuffle(len(q.Options), func(a, b int) {
+			q.Options[a], q.Options[b] = q.Options[b], q.Options[a]
+		})
+		for k, opt := range q.Options {
+			if opt == words[i].Translation {
+				q.Answer = k
+			}
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+// uniqueTranslations отбрасывает слова без перевода и с повторяющимся
+// переводом: два одинаковых варианта ответа сделали бы вопрос нерешаемым
+func uniqueTranslations(words []Word) []Word {
+	seen := make(map[string]bool, len(words))
+	out := make([]Word, 0, len(words))
+	for _, w := range words {
+		if w.Text == "" || w.Translation == "" || seen[w.Translation] {
+			continue
+		}
+		seen[w.Translation] = true
+		out = append(out, w)
+	}
+	return out
+}
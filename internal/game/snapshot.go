@@ -0,0 +1,86 @@
+package game
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// snapshot — полное состояние Game в JSON. В отличие от State в нём есть
+// правильные ответы и ответы игроков, поэтому наружу он не отдаётся: игра
+// хранится в базе между запросами, и продолжить её может любой экземпляр
+type snapshot struct {
+	Code       string                  `json:"code"`
+	HostID     uint                    `json:"host_id"`
+	LessonID   uint                    `json:"lesson_id"`
+	Config     Config                  `json:"config"`
+	Questions  []Question              `json:"questions"`
+	Players    []Player                `json:"players"`
+	Phase      Phase                   `json:"phase"`
+	Round      int                     `json:"round"`
+	RoundStart time.Time               `json:"round_start"`
+	Deadline   time.Time               `json:"deadline"`
+	Answers    map[uint]answerSnapshot `json:"answers"`
+	StartedAt  time.Time               `json:"started_at"`
+	FinishedAt time.Time               `json:"finished_at"`
+}
+
+type answerSnapshot struct {
+	Option int `json:"option"`
+	Points int `json:"points"`
+}
+
+// MarshalJSON сохраняет игру целиком, вместе с ответами текущего раунда
+func (g *Game) MarshalJSON() ([]byte, error) {
+	s := snapshot{
+		Code:       g.Code,
+		HostID:     g.HostID,
+		LessonID:   g.LessonID,
+		Config:     g.cfg,
+		Questions:  g.questions,
+		Players:    make([]Player, len(g.players)),
+		Phase:      g.phase,
+		Round:      g.round,
+		RoundStart: g.roundStart,
+		Deadline:   g.deadline,
+		Answers:    make(map[uint]answerSnapshot, len(g.answers)),
+		StartedAt:  g.StartedAt,
+		FinishedAt: g.FinishedAt,
+	}
+	for i, p := range g.players {
+		s.Players[i] = *p
+	}
+	for childID, a := range g.answers {
+		s.Answers[childID] = answerSnapshot{Option: a.option, Points: a.points}
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON восстанавливает игру, сохранённую MarshalJSON
+func (g *Game) UnmarshalJSON(data []byte) error {
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*g = Game{
+		Code:       s.Code,
+		HostID:     s.HostID,
+		LessonID:   s.LessonID,
+		cfg:        s.Config,
+		questions:  s.Questions,
+		players:    make([]*Player, len(s.Players)),
+		phase:      s.Phase,
+		round:      s.Round,
+		roundStart: s.RoundStart,
+		deadline:   s.Deadline,
+		answers:    make(map[uint]answer, len(s.Answers)),
+		StartedAt:  s.StartedAt,
+		FinishedAt: s.FinishedAt,
+	}
+	for i := range s.Players {
+		g.players[i] = &s.Players[i]
+	}
+	for childID, a := range s.Answers {
+		g.answers[childID] = answer{option: a.Option, points: a.Points}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package game
+
+import "time"
+
+// State — снимок игры для клиентов. Правильный ответ виден только после
+// окончания раунда
+type State struct {
+	Code     string         `json:"code"`
+	LessonID uint           `json:"lesson_id"`
+	Phase    Phase          `json:"phase"`
+	Round    int            `json:"round"`
+	Rounds   int            `json:"rounds"`
+	Deadline *time.Time     `json:"deadline,omitempty"`
+	Question *QuestionState `json:"question,omitempty"`
+	// Players — в лобби в порядке входа, дальше — по местам
+	Players []PlayerState `json:"players"`
+}
+
+// QuestionState — текущий вопрос
+type QuestionState struct {
+	Prompt  string   `json:"prompt"`
+	Options []string `json:"options"`
+	Answer  *int     `json:"answer,omitempty"`
+}
+
+// PlayerState — игрок в таблице
+type PlayerState struct {
+	ChildID  uint   `json:"child_id"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	Correct  int    `json:"correct"`
+	Rank     int    `json:"rank,omitempty"`
+	Answered bool   `json:"answered"` // ответил на текущий вопрос
+	Points   *int   `json:"points,omitempty"`
+}
+
+// State возвращает снимок игры
+func (g *Game) State() State {
+	s := State{
+		Code:     g.Code,
+		LessonID: g.LessonID,
+		Phase:    g.phase,
+		Round:    g.round,
+		Rounds:   len(g.questions),
+		Players:  []PlayerState{},
+	}
+	if !g.deadline.IsZero() {
+		deadline := g.deadline
+		s.Deadline = &deadline
+	}
+
+	revealed := g.phase == PhaseReveal
+	if g.phase == PhaseQuestion || revealed {
+		q := g.questions[g.round-1]
+		s.Question = &QuestionState{Prompt: q.Prompt, Options: q.Options}
+		if revealed {
+			s.Question.Answer = &q.Answer
+		}
+	}
+
+	if g.phase == PhaseLobby {
+		for _, p := range g.players {
+			s.Players = append(s.Players, PlayerState{ChildID: p.ChildID, Name: p.Name})
+		}
+		return s
+	}
+	board := g.Leaderboard()
+	ranks := Ranks(board)
+	for i, p := range board {
+		ps := PlayerState{ChildID: p.ChildID, Name: p.Name, Score: p.Score, Correct: p.Correct, Rank: ranks[i]}
+		if a, ok := g.answers[p.ChildID]; ok && g.phase != PhaseFinished {
+			ps.Answered = true
+			if revealed {
+				points := a.points
+				ps.Points = &points
+			}
+		}
+		s.Players = append(s.Players, ps)
+	}
+	return s
+}
+
+// Ranks возвращает места игроков из Leaderboard; при равных очках и числе
+// правильных ответов место общее
+func Ranks(board []Player) []int {
+	ranks := make([]int, len(board))
+	for i, p := range board {
+		if i > 0 && p.Score == board[i-1].Score && p.Correct == board[i-1].Correct {
+			ranks[i] = ranks[i-1]
+		} else {
+			ranks[i] = i + 1
+		}
+	}
+	return ranks
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/realtime"
+	"engkids/internal/repositories"
+	"engkids/pkg/jwt"
+	"engkids/pkg/logger"
+	"engkids/pkg/websocket"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// sseRetry — через сколько EventSource переподключается после обрыва
+const sseRetry = 3 * time.Second
+
+// EventsHandler — Server-Sent Events для сетей, где WebSocket недоступен.
+// Поток подключается к хабу как обычный клиент комнаты семьи
+type EventsHandler struct {
+	Hub *websocket.Hub
+	Log repositories.UserEventRepository
+
+	done chan struct{}
+	once sync.Once
+}
+
+func NewEventsHandler(hub *websocket.Hub, log repositories.UserEventRepository) *EventsHandler {
+	return &EventsHandler{Hub: hub, Log: log, done: make(chan struct{})}
+}
+
+// Close завершает открытые потоки. Вызывается до остановки HTTP-сервера,
+// который иначе ждал бы их окончания
+func (h *EventsHandler) Close() {
+	h.once.Do(func() { close(h.done) })
+}
+
+// Stream godoc
+// @Summary Real-time events over SSE
+// @Description Server-Sent Events fallback for /ws with the same envelope: {"v":1,"type":"...","id":N,"seq":N,"payload":{...}}.
+// @Description Family events carry an SSE id; on reconnect send Last-Event-ID (or ?last_event_id=) to receive missed events.
+// @Description If they are no longer in the log, a "resync" message tells the client to reload its data.
+// @Description EventSource cannot set headers, so the access token may be passed as ?token=.
+// @Tags realtime
+// @Produce text/event-stream
+// @Param token query string false "Access token, if there is no Authorization header"
+// @Param last_event_id query int false "Last received event id, if there is no Last-Event-ID header"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} errors.Response
+// @Failure 401 {object} errors.Response
+// @Router /api/events/stream [get]
+func (h *EventsHandler) Stream(c *fiber.Ctx) error {
+	claims, err := streamClaims(c)
+	if err != nil {
+		return err
+	}
+	lastID, err := lastEventID(c)
+	if err != nil {
+		return err
+	}
+
+	// в хаб — до чтения журнала, чтобы не потерять события между ними;
+	// повторы отсеиваются по ID
+	room := realtime.FamilyRoom(claims.UserID)
+	stream := realtime.NewStream()
+	client := h.Hub.Register(stream, room)
+	go client.Serve(nil)
+
+	backlog, resync, err := h.backlog(c.UserContext(), claims.UserID, lastID)
+	if err != nil {
+		stream.Close()
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	ready, err := realtime.Encode(realtime.TypeReady, 0, realtime.ReadyPayload{UserID: claims.UserID, Rooms: []string{room}})
+	if err != nil {
+		stream.Close()
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+
+	// контекст запроса завершится раньше потока
+	log := logger.FromCtx(c).WithContext(context.Background()).WithField(logger.FieldUserID, claims.UserID)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.write(w, stream, ready, backlog, resync, log)
+	})
+	return nil
+}
+
+// backlog возвращает события после lastID или resync, если часть из них уже
+// вытеснена из журнала
+func (h *EventsHandler) backlog(ctx context.Context, userID, lastID uint) ([]models.UserEvent, bool, error) {
+	if lastID == 0 {
+		return nil, false, nil
+	}
+	ok, err := h.Log.Exists(ctx, userID, lastID)
+	if err != nil || !ok {
+		return nil, err == nil, err
+	}
+	events, err := h.Log.ListAfter(ctx, userID, lastID)
+	return events, false, err
+}
+
+func (h *EventsHandler) write(w *bufio.Writer, stream *realtime.Stream, ready []byte, backlog []models.UserEvent, resync bool, log *logrus.Entry) {
+	defer stream.Close()
+	log.Info("Event stream connected")
+	defer log.Info("Event stream disconnected")
+
+	w.WriteString("retry: " + strconv.FormatInt(sseRetry.Milliseconds(), 10) + "\n\n")
+	writeSSE(w, 0, ready)
+	if resync {
+		msg, _ := realtime.Encode(realtime.TypeResync, 0, struct{}{})
+		writeSSE(w, 0, msg)
+	}
+	var last uint
+	for _, e := range backlog {
+		msg, err := json.Marshal(realtime.EventEnvelope(e))
+		if err != nil {
+			continue
+		}
+		writeSSE(w, e.ID, msg)
+		last = e.ID
+	}
+	if w.Flush() != nil {
+		return
+	}
+
+	for {
+		select {
+		case f := <-stream.Frames():
+			if f.Ping {
+				w.WriteString(": ping\n\n")
+			} else {
+				var env realtime.Envelope
+				if json.Unmarshal(f.Msg, &env) == nil && env.ID != 0 {
+					if env.ID <= last {
+						continue // уже отправлено из журнала
+					}
+					last = env.ID
+				}
+				writeSSE(w, env.ID, f.Msg)
+			}
+			// ошибка записи — клиент отключился
+			if w.Flush() != nil {
+				return
+			}
+		case <-stream.Done():
+			return
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// writeSSE пишет сообщение; JSON без переводов строк укладывается в одну строку data
+func writeSSE(w *bufio.Writer, id uint, msg []byte) {
+	if id != 0 {
+		w.WriteString("id: " + strconv.FormatUint(uint64(id), 10) + "\n")
+	}
+	w.WriteString("data: ")
+	w.Write(msg)
+	w.WriteString("\n\n")
+}
+
+// streamClaims проверяет токен из Authorization или ?token=
+func streamClaims(c *fiber.Ctx) (*jwt.Claims, error) {
+	token := c.Query("token")
+	if header := c.Get(fiber.HeaderAuthorization); header != "" {
+		scheme, value, ok := strings.Cut(header, " ")
+		if !ok || scheme != "Bearer" {
+			return nil, apperrors.New(apperrors.CodeAuthTokenMalformed)
+		}
+		token = value
+	}
+	if token == "" {
+		return nil, apperrors.New(apperrors.CodeAuthTokenMissing)
+	}
+	return validateAccessToken(token)
+}
+
+// lastEventID читает Last-Event-ID, который EventSource шлёт при переподключении
+func lastEventID(c *fiber.Ctx) (uint, error) {
+	raw := c.Get("Last-Event-ID", c.Query("last_event_id"))
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, apperrors.New(apperrors.CodeBadRequest).WithDetails(map[string]any{"param": "Last-Event-ID"})
+	}
+	return uint(id), nil
+}
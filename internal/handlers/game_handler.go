@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"engkids/internal/dto"
+	"engkids/internal/errors"
+	"engkids/internal/services"
+	"engkids/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultGamesLimit = 20
+	maxGamesLimit     = 100
+)
+
+type GameHandler struct {
+	Service *services.GameService
+}
+
+func NewGameHandler(service *services.GameService) *GameHandler {
+	return &GameHandler{Service: service}
+}
+
+// Create godoc
+// @Summary Create a quiz game
+// @Description Creates a multiplayer vocabulary quiz in the lobby with questions from the lesson words.
+// @Description Share the returned code; players receive game.state over /ws after game.subscribe.
+// @Tags games
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.CreateGameRequest true "Lesson and options"
+// @Success 201 {object} game.State
+// @Failure 400 {object} errors.Response
+// @Failure 422 {object} errors.Response
+// @Router /api/games [post]
+func (h *GameHandler) Create(c *fiber.Ctx) error {
+	var req dto.CreateGameRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+	if req.Language == "" {
+		req.Language = errors.LangRU
+	}
+	state, err := h.Service.Create(c.UserContext(), userID(c), req.LessonID, req.Language, req.Rounds)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(state)
+}
+
+// Get godoc
+// @Summary Quiz game state
+// @Tags games
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Game code"
+// @Success 200 {object} game.State
+// @Failure 404 {object} errors.Response
+// @Router /api/games/{code} [get]
+func (h *GameHandler) Get(c *fiber.Ctx) error {
+	state, err := h.Service.State(c.UserContext(), c.Params("code"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(state)
+}
+
+// Join godoc
+// @Summary Join a quiz game
+// @Description Adds the user's child to the game lobby.
+// @Tags games
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Game code"
+// @Param body body dto.JoinGameRequest true "Child"
+// @Success 200 {object} game.State
+// @Failure 404 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/games/{code}/players [post]
+func (h *GameHandler) Join(c *fiber.Ctx) error {
+	var req dto.JoinGameRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+	withChild(c, req.ChildID)
+	state, err := h.Service.Join(c.UserContext(), userID(c), c.Params("code"), req.ChildID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(state)
+}
+
+// Leave godoc
+// @Summary Leave a quiz game lobby
+// @Tags games
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Game code"
+// @Param childId path int true "Child ID"
+// @Success 200 {object} game.State
+// @Failure 403 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/games/{code}/players/{childId} [delete]
+func (h *GameHandler) Leave(c *fiber.Ctx) error {
+	childID, err := c.ParamsInt("childId")
+	if err != nil || childID <= 0 {
+		return errors.New(errors.CodeGameNotPlayer)
+	}
+	withChild(c, uint(childID))
+	state, err := h.Service.Leave(c.UserContext(), userID(c), c.Params("code"), uint(childID))
+	if err != nil {
+		return err
+	}
+	return c.JSON(state)
+}
+
+// Start godoc
+// @Summary Start a quiz game
+// @Description Starts the first round. Only the user who created the game can start it.
+// @Tags games
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Game code"
+// @Success 200 {object} game.State
+// @Failure 403 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/games/{code}/start [post]
+func (h *GameHandler) Start(c *fiber.Ctx) error {
+	state, err := h.Service.Start(c.UserContext(), userID(c), c.Params("code"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(state)
+}
+
+// Answer godoc
+// @Summary Answer the current question
+// @Description Correct answers score 500 points plus up to 500 for speed. The round ends when everyone has answered or time is up.
+// @Tags games
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Game code"
+// @Param body body dto.GameAnswerRequest true "Answer"
+// @Success 200 {object} game.State
+// @Failure 403 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/games/{code}/answers [post]
+func (h *GameHandler) Answer(c *fiber.Ctx) error {
+	var req dto.GameAnswerRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+	withChild(c, req.ChildID)
+	state, err := h.Service.Answer(c.UserContext(), userID(c), c.Params("code"), req.ChildID, req.Round, *req.Option)
+	if err != nil {
+		return err
+	}
+	return c.JSON(state)
+}
+
+// History godoc
+// @Summary Finished quiz games of a child
+// @Description Final leaderboards, newest first.
+// @Tags games
+// @Produce json
+// @Security BearerAuth
+// @Param child_id query int true "Child ID"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {array} models.GameResult
+// @Failure 400 {object} errors.Response
+// @Failure 404 {object} errors.Response
+// @Router /api/games/history [get]
+func (h *GameHandler) History(c *fiber.Ctx) error {
+	childID := c.QueryInt("child_id")
+	if childID <= 0 {
+		return errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "child_id"})
+	}
+	limit := c.QueryInt("limit", defaultGamesLimit)
+	if limit <= 0 || limit > maxGamesLimit {
+		return errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "limit"})
+	}
+	withChild(c, uint(childID))
+	results, err := h.Service.History(c.UserContext(), userID(c), uint(childID), limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(results)
+}
+
+// userID — пользователь, которого аутентифицировал middleware Protected
+func userID(c *fiber.Ctx) uint {
+	id, _ := c.Locals("userID").(uint)
+	return id
+}
+
+// withChild добавляет ребёнка, с которым работает запрос, в логгер запроса
+func withChild(c *fiber.Ctx, childID uint) {
+	logger.AddFields(c.UserContext(), logrus.Fields{logger.FieldChildID: childID})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"engkids/internal/errors"
+	"engkids/internal/jobs"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultJobsLimit = 50
+	maxJobsLimit     = 500
+)
+
+var jobStatuses = []string{models.JobQueued, models.JobRunning, models.JobSucceeded, models.JobDead, models.JobCanceled}
+
+type JobHandler struct {
+	Jobs *jobs.Client
+}
+
+func NewJobHandler(client *jobs.Client) *JobHandler {
+	return &JobHandler{Jobs: client}
+}
+
+// List godoc
+// @Summary List background jobs
+// @Description Background jobs, newest first. Admin only.
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param queue query string false "Queue name"
+// @Param status query string false "queued, running, succeeded, dead or canceled"
+// @Param type query string false "Job type"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.Job
+// @Failure 400 {object} errors.Response
+// @Failure 401 {object} errors.Response
+// @Failure 403 {object} errors.Response
+// @Router /api/admin/jobs [get]
+func (h *JobHandler) List(c *fiber.Ctx) error {
+	filter := repositories.JobFilter{
+		Queue:  c.Query("queue"),
+		Status: c.Query("status"),
+		Type:   c.Query("type"),
+		Limit:  c.QueryInt("limit", defaultJobsLimit),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Status != "" && !slices.Contains(jobStatuses, filter.Status) {
+		return invalidJobQuery("status")
+	}
+	if filter.Limit <= 0 || filter.Limit > maxJobsLimit {
+		return invalidJobQuery("limit")
+	}
+	if filter.Offset < 0 {
+		return invalidJobQuery("offset")
+	}
+
+	list, err := h.Jobs.List(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(list)
+}
+
+// Retry godoc
+// @Summary Retry a job
+// @Description Re-queues a dead or canceled job with a fresh attempt counter. Admin only.
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 404 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/admin/jobs/{id}/retry [post]
+func (h *JobHandler) Retry(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return errors.New(errors.CodeJobNotFound)
+	}
+	job, err := h.Jobs.Retry(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(job)
+}
+
+// Cancel godoc
+// @Summary Cancel a job
+// @Description Cancels a job that has not started yet. Admin only.
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 404 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/admin/jobs/{id}/cancel [post]
+func (h *JobHandler) Cancel(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return errors.New(errors.CodeJobNotFound)
+	}
+	job, err := h.Jobs.Cancel(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(job)
+}
+
+func invalidJobQuery(param string) error {
+	return errors.New(errors.CodeJobInvalidQuery).WithDetails(map[string]any{"param": param})
+}
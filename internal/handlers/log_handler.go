@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"engkids/internal/errors"
+	"engkids/pkg/logger"
+	"engkids/pkg/logstore"
+	stderrors "errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetLogs godoc
+// @Summary Get application logs
+// @Description Search application logs (Elasticsearch or local files), newest first. Admin only.
+// @Tags logs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start time (RFC3339)"
+// @Param to query string false "End time (RFC3339)"
+// @Param level query string false "Log level (info, warn, error)"
+// @Param request_id query string false "Request ID"
+// @Param user_id query string false "User ID"
+// @Param path query string false "Request path prefix"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} logstore.Page
+// @Failure 400 {object} errors.Response
+// @Failure 401 {object} errors.Response
+// @Failure 403 {object} errors.Response
+// @Failure 503 {object} errors.Response
+// @Router /api/logs [get]
+func GetLogs(store logstore.LogStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		q := logstore.Query{
+			Level:     c.Query("level"),
+			RequestID: c.Query("request_id"),
+			UserID:    c.Query("user_id"),
+			Path:      c.Query("path"),
+			Limit:     c.QueryInt("limit", logstore.DefaultLimit),
+			Cursor:    c.Query("cursor"),
+		}
+
+		var err error
+		if q.From, err = parseTime(c.Query("from")); err != nil {
+			return errors.New(errors.CodeLogsInvalidQuery).WithDetails(map[string]any{"param": "from"})
+		}
+		if q.To, err = parseTime(c.Query("to")); err != nil {
+			return errors.New(errors.CodeLogsInvalidQuery).WithDetails(map[string]any{"param": "to"})
+		}
+
+		page, err := store.Search(c.UserContext(), q)
+		if stderrors.Is(err, logstore.ErrInvalidCursor) {
+			return errors.New(errors.CodeLogsInvalidQuery).WithDetails(map[string]any{"param": "cursor"})
+		}
+		if err != nil {
+			logger.FromCtx(c).WithError(err).Error("Failed to search logs")
+			return errors.Wrap(errors.CodeLogsUnavailable, err)
+		}
+
+		return c.JSON(page)
+	}
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"engkids/internal/errors"
+	"engkids/internal/services"
+	"engkids/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MediaHandler struct {
+	Service *services.MediaService
+}
+
+func NewMediaHandler(service *services.MediaService) *MediaHandler {
+	return &MediaHandler{Service: service}
+}
+
+// Upload godoc
+// @Summary Upload an image or audio file
+// @Description Content editors only. The format is detected from the file contents: PNG, JPEG, GIF, WebP, MP3, WAV or OGG.
+// @Description A file that was already uploaded is not stored again: the existing record is returned with status 200.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "File"
+// @Success 201 {object} dto.MediaAsset
+// @Success 200 {object} dto.MediaAsset
+// @Failure 400 {object} errors.Response
+// @Failure 403 {object} errors.Response
+// @Failure 413 {object} errors.Response
+// @Failure 415 {object} errors.Response
+// @Failure 422 {object} errors.Response
+// @Router /api/media [post]
+func (h *MediaHandler) Upload(c *fiber.Ctx) error {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "file"})
+	}
+	limit := h.Service.MaxUploadSize()
+	if fh.Size > limit {
+		return errors.New(errors.CodeMediaTooLarge).WithDetails(map[string]any{"limit": limit >> 20})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return errors.Wrap(errors.CodeInternal, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return errors.Wrap(errors.CodeInternal, err)
+	}
+
+	asset, created, err := h.Service.Upload(c.UserContext(), userID(c), data)
+	if err != nil {
+		return err
+	}
+	if created {
+		c.Status(fiber.StatusCreated)
+	}
+	return c.JSON(asset)
+}
+
+// Get godoc
+// @Summary Uploaded file
+// @Description Returns the file with fresh download links.
+// @Tags media
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Media ID"
+// @Success 200 {object} dto.MediaAsset
+// @Failure 404 {object} errors.Response
+// @Router /api/media/{id} [get]
+func (h *MediaHandler) Get(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return errors.New(errors.CodeMediaNotFound)
+	}
+	asset, err := h.Service.Get(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(asset)
+}
+
+// Download godoc
+// @Summary Download a file by signed link
+// @Description Serves files of the local storage. Links come from the url and thumbnail_url fields of a media file.
+// @Tags media
+// @Produce octet-stream
+// @Param key path string true "File key"
+// @Param expires query int true "Link expiry, Unix seconds"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 403 {object} errors.Response
+// @Failure 404 {object} errors.Response
+// @Router /media/{key} [get]
+func (h *MediaHandler) Download(c *fiber.Ctx) error {
+	expires := c.Query(storage.ExpiresParam)
+	rc, obj, err := h.Service.Open(c.UserContext(), c.Params("*"), expires, c.Query(storage.SignatureParam))
+	if err != nil {
+		return err
+	}
+	// кэш не должен пережить ссылку
+	maxAge := int64(0)
+	if unix, err := strconv.ParseInt(expires, 10, 64); err == nil {
+		maxAge = max(unix-time.Now().Unix(), 0)
+	}
+	c.Set(fiber.HeaderContentType, obj.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.FormatInt(maxAge, 10))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.SendStream(rc, int(obj.Size))
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"engkids/internal/errors"
+	"engkids/internal/scheduler"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultRunsLimit = 50
+	maxRunsLimit     = 500
+)
+
+type SchedulerHandler struct {
+	Scheduler *scheduler.Scheduler
+}
+
+func NewSchedulerHandler(s *scheduler.Scheduler) *SchedulerHandler {
+	return &SchedulerHandler{Scheduler: s}
+}
+
+// Runs godoc
+// @Summary List scheduled task runs
+// @Description Maintenance task runs with status and duration, newest first. Admin only.
+// @Tags scheduler
+// @Produce json
+// @Security BearerAuth
+// @Param task query string false "Task name, e.g. purge_refresh_tokens"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Success 200 {array} models.ScheduledRun
+// @Failure 400 {object} errors.Response
+// @Failure 401 {object} errors.Response
+// @Failure 403 {object} errors.Response
+// @Router /api/admin/scheduler/runs [get]
+func (h *SchedulerHandler) Runs(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", defaultRunsLimit)
+	if limit <= 0 || limit > maxRunsLimit {
+		return errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "limit"})
+	}
+	runs, err := h.Scheduler.History(c.UserContext(), c.Query("task"), limit)
+	if err != nil {
+		return errors.Wrap(errors.CodeInternal, err)
+	}
+	return c.JSON(runs)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"engkids/internal/dto"
+	"engkids/internal/errors"
+	"engkids/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SyncHandler struct {
+	Service *services.SyncService
+}
+
+func NewSyncHandler(service *services.SyncService) *SyncHandler {
+	return &SyncHandler{Service: service}
+}
+
+// Sync godoc
+// @Summary Offline sync
+// @Description Applies operations recorded by the app without network (lesson attempts, word review grades, setting changes)
+// @Description and returns the children's data changed since the cursor. Operations are idempotent by client ID:
+// @Description a resent operation gets status duplicate. Conflicts: the best lesson score and the latest review or setting win.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.SyncRequest true "Operations and cursor"
+// @Success 200 {object} dto.SyncResponse
+// @Failure 400 {object} errors.Response
+// @Router /api/sync [post]
+func (h *SyncHandler) Sync(c *fiber.Ctx) error {
+	var req dto.SyncRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+	resp, err := h.Service.Sync(c.UserContext(), userID(c), &req)
+	if err != nil {
+		return err
+	}
+	lang := errors.Language(c)
+	for i, r := range resp.Results {
+		if r.Err != nil {
+			resp.Results[i].Code = r.Err.Code
+			resp.Results[i].Error = errors.Message(r.Err.Code, lang, r.Err.Details)
+			resp.Results[i].Details = r.Err.Details
+		}
+	}
+	return c.JSON(resp)
+}
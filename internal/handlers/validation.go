@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"engkids/internal/errors"
+	"engkids/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseAndValidate разбирает тело запроса в dst и проверяет правила validate
+func parseAndValidate(c *fiber.Ctx, dst any) error {
+	if err := c.BodyParser(dst); err != nil {
+		return errors.Wrap(errors.CodeInvalidBody, err)
+	}
+	if err := utils.ValidateStruct(dst); err != nil {
+		return errors.FromValidation(err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"engkids/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type profileRequest struct {
+	Password string `json:"password" validate:"required,password"`
+	Level    string `json:"level" validate:"required,cefr"`
+	Lang     string `json:"lang" validate:"required,lang_code"`
+	Children []struct {
+		Name string `json:"name" validate:"required,min=2"`
+		Age  int    `json:"age" validate:"child_age"`
+	} `json:"children" validate:"dive"`
+}
+
+func TestParseAndValidateFieldErrors(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.Handle})
+	app.Post("/", func(c *fiber.Ctx) error {
+		var req profileRequest
+		if err := parseAndValidate(c, &req); err != nil {
+			return err
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	body := `{"password":"abcdefgh","level":"D1","lang":"zz","children":[{"name":"A","age":15}]}`
+	req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	raw, _ := io.ReadAll(resp.Body)
+	var out errors.Response
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Code != errors.CodeValidationFailed {
+		t.Fatalf("code = %s", out.Code)
+	}
+
+	want := map[string]string{
+		"password":         "password",
+		"level":            "cefr",
+		"lang":             "lang_code",
+		"children[0].name": "min_length",
+		"children[0].age":  "child_age",
+	}
+	got := map[string]errors.FieldError{}
+	for _, f := range out.Fields {
+		got[f.Field] = f
+	}
+	for field, rule := range want {
+		f, ok := got[field]
+		if !ok {
+			t.Errorf("missing error for %s in %s", field, raw)
+			continue
+		}
+		if f.Rule != rule || f.Message == "" {
+			t.Errorf("%s: rule=%q message=%q", field, f.Rule, f.Message)
+		}
+	}
+	if m := got["children[0].name"].Message; m != "Must be at least 2 characters long" {
+		t.Errorf("unexpected message %q", m)
+	}
+}
+
+func TestParseAndValidateInvalidBody(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.Handle})
+	app.Post("/", func(c *fiber.Ctx) error {
+		var req profileRequest
+		return parseAndValidate(c, &req)
+	})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(`{"password":`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out errors.Response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest || out.Code != errors.CodeInvalidBody {
+		t.Fatalf("status = %d, code = %s", resp.StatusCode, out.Code)
+	}
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"engkids/internal/dto"
+	"engkids/internal/errors"
+	"engkids/internal/repositories"
+	"engkids/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultWordsLimit = 50
+	maxWordsLimit     = 200
+)
+
+type WordHandler struct {
+	Service *services.WordService
+}
+
+func NewWordHandler(service *services.WordService) *WordHandler {
+	return &WordHandler{Service: service}
+}
+
+// List godoc
+// @Summary Search the dictionary
+// @Description Words with translations in alphabetical order.
+// @Tags words
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Beginning of the word"
+// @Param tag query string false "Topic tag"
+// @Param part_of_speech query string false "Part of speech"
+// @Param difficulty query string false "CEFR level"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} dto.Word
+// @Failure 400 {object} errors.Response
+// @Router /api/words [get]
+func (h *WordHandler) List(c *fiber.Ctx) error {
+	filter := repositories.WordFilter{
+		Query:        c.Query("q"),
+		Tag:          c.Query("tag"),
+		PartOfSpeech: c.Query("part_of_speech"),
+		Difficulty:   c.Query("difficulty"),
+		Limit:        c.QueryInt("limit", defaultWordsLimit),
+		Offset:       c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > maxWordsLimit {
+		return errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "limit"})
+	}
+	if filter.Offset < 0 {
+		return errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "offset"})
+	}
+	words, err := h.Service.List(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(words)
+}
+
+// Get godoc
+// @Summary Dictionary word
+// @Tags words
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Word ID"
+// @Success 200 {object} dto.Word
+// @Failure 404 {object} errors.Response
+// @Router /api/words/{id} [get]
+func (h *WordHandler) Get(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return errors.New(errors.CodeWordNotFound)
+	}
+	word, err := h.Service.Get(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(word)
+}
+
+// Create godoc
+// @Summary Add a word to the dictionary
+// @Description Content editors only.
+// @Tags words
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.WordRequest true "Word"
+// @Success 201 {object} dto.Word
+// @Failure 400 {object} errors.Response
+// @Failure 403 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/words [post]
+func (h *WordHandler) Create(c *fiber.Ctx) error {
+	var req dto.WordRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+	word, err := h.Service.Create(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(word)
+}
+
+// Update godoc
+// @Summary Replace a dictionary word
+// @Description Replaces all fields and translations. Content editors only.
+// @Tags words
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Word ID"
+// @Param body body dto.WordRequest true "Word"
+// @Success 200 {object} dto.Word
+// @Failure 400 {object} errors.Response
+// @Failure 404 {object} errors.Response
+// @Failure 409 {object} errors.Response
+// @Router /api/words/{id} [put]
+func (h *WordHandler) Update(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return errors.New(errors.CodeWordNotFound)
+	}
+	var req dto.WordRequest
+	if err := parseAndValidate(c, &req); err != nil {
+		return err
+	}
+	word, err := h.Service.Update(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(word)
+}
+
+// Delete godoc
+// @Summary Delete a dictionary word
+// @Description Removes the word from all lessons together with children's reviews. Content editors only.
+// @Tags words
+// @Security BearerAuth
+// @Param id path int true "Word ID"
+// @Success 204
+// @Failure 404 {object} errors.Response
+// @Router /api/words/{id} [delete]
+func (h *WordHandler) Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return errors.New(errors.CodeWordNotFound)
+	}
+	if err := h.Service.Delete(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// LessonWords godoc
+// @Summary Words of a lesson
+// @Tags words
+// @Produce json
+// @Security BearerAuth
+// @Param lessonId path int true "Lesson ID"
+// @Success 200 {array} dto.Word
+// @Failure 400 {object} errors.Response
+// @Router /api/lessons/{lessonId}/words [get]
+func (h *WordHandler) LessonWords(c *fiber.Ctx) error {
+	lessonID, err := lessonParam(c)
+	if err != nil {
+		return err
+	}
+	words, err := h.Service.LessonWords(c.UserContext(), lessonID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(words)
+}
+
+// Link godoc
+// @Summary Add a word to a lesson
+// @Description Adding a word twice changes nothing. Content editors only.
+// @Tags words
+// @Security BearerAuth
+// @Param lessonId path int true "Lesson ID"
+// @Param wordId path int true "Word ID"
+// @Success 204
+// @Failure 404 {object} errors.Response
+// @Router /api/lessons/{lessonId}/words/{wordId} [put]
+func (h *WordHandler) Link(c *fiber.Ctx) error {
+	lessonID, err := lessonParam(c)
+	if err != nil {
+		return err
+	}
+	wordID, err := c.ParamsInt("wordId")
+	if err != nil || wordID <= 0 {
+		return errors.New(errors.CodeWordNotFound)
+	}
+	if err := h.Service.LinkLesson(c.UserContext(), lessonID, uint(wordID)); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Unlink godoc
+// @Summary Remove a word from a lesson
+// @Description The word stays in the dictionary. Content editors only.
+// @Tags words
+// @Security BearerAuth
+// @Param lessonId path int true "Lesson ID"
+// @Param wordId path int true "Word ID"
+// @Success 204
+// @Failure 404 {object} errors.Response
+// @Router /api/lessons/{lessonId}/words/{wordId} [delete]
+func (h *WordHandler) Unlink(c *fiber.Ctx) error {
+	lessonID, err := lessonParam(c)
+	if err != nil {
+		return err
+	}
+	wordID, err := c.ParamsInt("wordId")
+	if err != nil || wordID <= 0 {
+		return errors.New(errors.CodeLessonWordNotFound)
+	}
+	if err := h.Service.UnlinkLesson(c.UserContext(), lessonID, uint(wordID)); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Progress godoc
+// @Summary Child's progress on lesson words
+// @Description Leitner box, number of reviews and next review date for every word of the lesson.
+// @Tags words
+// @Produce json
+// @Security BearerAuth
+// @Param lessonId path int true "Lesson ID"
+// @Param child_id query int true "Child ID"
+// @Success 200 {object} dto.LessonWordsProgress
+// @Failure 400 {object} errors.Response
+// @Failure 404 {object} errors.Response
+// @Router /api/lessons/{lessonId}/progress [get]
+func (h *WordHandler) Progress(c *fiber.Ctx) error {
+	lessonID, err := lessonParam(c)
+	if err != nil {
+		return err
+	}
+	childID := c.QueryInt("child_id")
+	if childID <= 0 {
+		return errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "child_id"})
+	}
+	withChild(c, uint(childID))
+	progress, err := h.Service.LessonProgress(c.UserContext(), userID(c), uint(childID), lessonID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(progress)
+}
+
+// lessonParam — номер урока из пути
+func lessonParam(c *fiber.Ctx) (uint, error) {
+	id, err := c.ParamsInt("lessonId")
+	if err != nil || id <= 0 {
+		return 0, errors.New(errors.CodeBadRequest).WithDetails(map[string]any{"param": "lessonId"})
+	}
+	return uint(id), nil
+}
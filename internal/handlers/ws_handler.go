@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	apperrors "engkids/internal/errors"
+	"engkids/internal/game"
+	"engkids/internal/realtime"
+	"engkids/pkg/jwt"
+	"engkids/pkg/logger"
+	"engkids/pkg/websocket"
+
+	ws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	gojwt "github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+// wsAuthTimeout — сколько ждать сообщения auth, если токен не передан в запросе
+const wsAuthTimeout = 10 * time.Second
+
+type WSHandler struct {
+	Hub      *websocket.Hub
+	upgrader ws.FastHTTPUpgrader
+}
+
+func NewWSHandler(hub *websocket.Hub) *WSHandler {
+	return &WSHandler{
+		Hub: hub,
+		upgrader: ws.FastHTTPUpgrader{
+			// приложение и API на разных доменах, как и для CORS
+			CheckOrigin: func(*fasthttp.RequestCtx) bool { return true },
+		},
+	}
+}
+
+// Connect godoc
+// @Summary Real-time events
+// @Description Upgrades to WebSocket and streams family events: lesson.completed, badge.unlocked, streak.at_risk.
+// @Description Authenticate with ?token=<access token> or send {"v":1,"type":"auth","payload":{"token":"..."}} first.
+// @Description Every server message is {"v":1,"type":"...","seq":N,"payload":{...}}; seq grows by one per room.
+// @Description Send {"v":1,"type":"game.subscribe","payload":{"code":"ABC123"}} to receive game.state of a quiz, game.unsubscribe to stop.
+// @Tags realtime
+// @Param token query string false "Access token"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 401 {object} errors.Response
+// @Failure 426 {object} errors.Response
+// @Router /ws [get]
+func (h *WSHandler) Connect(c *fiber.Ctx) error {
+	if !ws.FastHTTPIsWebSocketUpgrade(c.Context()) {
+		return apperrors.New(apperrors.CodeBadRequest).WithStatus(fiber.StatusUpgradeRequired)
+	}
+
+	// неверный токен в запросе отклоняем до upgrade обычным HTTP-ответом
+	var claims *jwt.Claims
+	if token := c.Query("token"); token != "" {
+		var err error
+		if claims, err = validateAccessToken(token); err != nil {
+			return err
+		}
+	}
+
+	lang := apperrors.Language(c)
+	// контекст запроса завершится раньше соединения
+	log := logger.FromCtx(c).WithContext(context.Background())
+	return h.upgrader.Upgrade(c.Context(), func(conn *ws.Conn) {
+		h.serve(conn, claims, lang, log)
+	})
+}
+
+func (h *WSHandler) serve(conn *ws.Conn, claims *jwt.Claims, lang string, log *logrus.Entry) {
+	if claims == nil {
+		var err error
+		if claims, err = h.authenticate(conn); err != nil {
+			rejectWS(conn, apperrors.From(err), lang)
+			return
+		}
+	}
+
+	rooms := []string{realtime.FamilyRoom(claims.UserID)}
+	ready, err := realtime.Encode(realtime.TypeReady, 0, realtime.ReadyPayload{UserID: claims.UserID, Rooms: rooms})
+	if err != nil {
+		conn.Close()
+		return
+	}
+	// до регистрации в хабе других писателей у соединения нет
+	if err := conn.WriteMessage(ws.TextMessage, ready); err != nil {
+		conn.Close()
+		return
+	}
+
+	log = log.WithField(logger.FieldUserID, claims.UserID)
+	log.Info("WebSocket connected")
+	client := h.Hub.Register(conn, rooms...)
+	client.Serve(func(msg []byte) { h.handle(client, msg) })
+	log.Info("WebSocket disconnected")
+}
+
+// handle обрабатывает сообщения клиента после аутентификации. Неизвестные и
+// некорректные сообщения игнорируются
+func (h *WSHandler) handle(client *websocket.Client, msg []byte) {
+	var env realtime.Envelope
+	var payload realtime.GamePayload
+	if json.Unmarshal(msg, &env) != nil || json.Unmarshal(env.Payload, &payload) != nil || !game.ValidCode(payload.Code) {
+		return
+	}
+	switch env.Type {
+	case realtime.TypeGameSubscribe:
+		h.Hub.Join(client, realtime.GameRoom(payload.Code))
+	case realtime.TypeGameUnsubscribe:
+		h.Hub.Leave(client, realtime.GameRoom(payload.Code))
+	}
+}
+
+// authenticate ждёт первое сообщение с токеном
+func (h *WSHandler) authenticate(conn *ws.Conn) (*jwt.Claims, error) {
+	conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeAuthTokenMissing, err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	var env realtime.Envelope
+	var auth realtime.AuthPayload
+	if json.Unmarshal(msg, &env) != nil || env.Type != realtime.TypeAuth || json.Unmarshal(env.Payload, &auth) != nil {
+		return nil, apperrors.New(apperrors.CodeAuthTokenMissing)
+	}
+	return validateAccessToken(auth.Token)
+}
+
+func validateAccessToken(token string) (*jwt.Claims, error) {
+	claims, err := jwt.ValidateToken(token)
+	if err == nil {
+		return claims, nil
+	}
+	var verr *gojwt.ValidationError
+	if errors.As(err, &verr) && verr.Errors&gojwt.ValidationErrorExpired != 0 {
+		return nil, apperrors.Wrap(apperrors.CodeAuthTokenExpired, err)
+	}
+	return nil, apperrors.Wrap(apperrors.CodeAuthTokenMalformed, err)
+}
+
+// rejectWS отправляет ошибку и закрывает соединение с кодом 1008
+func rejectWS(conn *ws.Conn, err *apperrors.Error, lang string) {
+	defer conn.Close()
+	msg, encErr := realtime.Encode(realtime.TypeError, 0, realtime.ErrorPayload{
+		Code:    string(err.Code),
+		Message: apperrors.Message(err.Code, lang, err.Details),
+	})
+	if encErr != nil {
+		return
+	}
+	deadline := time.Now().Add(time.Second)
+	conn.SetWriteDeadline(deadline)
+	if conn.WriteMessage(ws.TextMessage, msg) == nil {
+		_ = conn.WriteControl(ws.CloseMessage, ws.FormatCloseMessage(ws.ClosePolicyViolation, string(err.Code)), deadline)
+	}
+}
@@ -0,0 +1,136 @@
+// Package jobs — очередь фоновых задач на Postgres. Задачи ставятся в очередь
+// через Client (или Enqueue внутри транзакции), выполняются Worker'ом в том же
+// процессе или в отдельном (engkids worker). Захват задач — SELECT ... FOR UPDATE
+// SKIP LOCKED, поэтому воркеров может быть сколько угодно
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+)
+
+// DefaultQueue — очередь задач, для которых она не указана
+const DefaultQueue = "default"
+
+// DefaultMaxAttempts — число попыток по умолчанию
+const DefaultMaxAttempts = 5
+
+// Option — параметр постановки задачи
+type Option func(*models.Job)
+
+// Queue ставит задачу в очередь name
+func Queue(name string) Option {
+	return func(j *models.Job) { j.Queue = name }
+}
+
+// RunAt откладывает выполнение до t
+func RunAt(t time.Time) Option {
+	return func(j *models.Job) { j.RunAt = t }
+}
+
+// Delay откладывает выполнение на d
+func Delay(d time.Duration) Option {
+	return func(j *models.Job) { j.RunAt = time.Now().Add(d) }
+}
+
+// MaxAttempts задаёт число попыток до перехода в dead
+func MaxAttempts(n int) Option {
+	return func(j *models.Job) { j.MaxAttempts = n }
+}
+
+// Enqueue ставит задачу в очередь через repo. Внутри транзакции
+// repositories.Transactor задача появится только вместе с остальными изменениями
+func Enqueue(ctx context.Context, repo repositories.JobRepository, jobType string, payload any, opts ...Option) (*models.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s payload: %w", jobType, err)
+	}
+	job := &models.Job{
+		Queue:       DefaultQueue,
+		Type:        jobType,
+		Payload:     raw,
+		Status:      models.JobQueued,
+		RunAt:       time.Now(),
+		MaxAttempts: DefaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if err := repo.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Client ставит задачи в очередь и управляет ими из админки
+type Client struct {
+	jobs repositories.JobRepository
+}
+
+func NewClient(jobs repositories.JobRepository) *Client {
+	return &Client{jobs: jobs}
+}
+
+// Enqueue ставит задачу в очередь
+func (c *Client) Enqueue(ctx context.Context, jobType string, payload any, opts ...Option) (*models.Job, error) {
+	return Enqueue(ctx, c.jobs, jobType, payload, opts...)
+}
+
+// List возвращает задачи, новые первыми
+func (c *Client) List(ctx context.Context, filter repositories.JobFilter) ([]models.Job, error) {
+	jobs, err := c.jobs.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return jobs, nil
+}
+
+// Retry снова ставит в очередь задачу, завершившуюся неудачей или отменённую.
+// Счётчик попыток обнуляется
+func (c *Client) Retry(ctx context.Context, id uint) (*models.Job, error) {
+	return c.transition(ctx, id, func(j *models.Job) {
+		j.Status, j.Attempts, j.RunAt, j.FinishedAt = models.JobQueued, 0, time.Now(), nil
+	}, models.JobDead, models.JobCanceled)
+}
+
+// Cancel отменяет задачу, которая ещё не начала выполняться
+func (c *Client) Cancel(ctx context.Context, id uint) (*models.Job, error) {
+	return c.transition(ctx, id, func(j *models.Job) {
+		now := time.Now()
+		j.Status, j.FinishedAt = models.JobCanceled, &now
+	}, models.JobQueued)
+}
+
+func (c *Client) transition(ctx context.Context, id uint, change func(*models.Job), from ...string) (*models.Job, error) {
+	job, err := c.jobs.FindByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, apperrors.New(apperrors.CodeJobNotFound)
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+
+	status := job.Status
+	change(job)
+	switch err := c.jobs.Transition(ctx, job, from...); {
+	case err == nil:
+		return job, nil
+	case errors.Is(err, repositories.ErrNotFound):
+		return nil, apperrors.New(apperrors.CodeJobNotFound)
+	case errors.Is(err, repositories.ErrConflict):
+		// статус успел смениться: сообщаем актуальный
+		if current, ferr := c.jobs.FindByID(ctx, id); ferr == nil {
+			status = current.Status
+		}
+		return nil, apperrors.New(apperrors.CodeJobInvalidState).WithDetails(map[string]any{"status": status})
+	default:
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+}
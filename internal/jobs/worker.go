@@ -0,0 +1,271 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/logger"
+	"engkids/pkg/metrics"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler выполняет задачу; payload — JSON, с которым она поставлена в очередь
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// permanentError — ошибка, после которой повторять задачу бессмысленно
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как неисправимую: задача сразу переходит в dead
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// WorkerOptions — настройки воркера
+type WorkerOptions struct {
+	Queues       map[string]int    // очередь -> сколько задач выполнять параллельно
+	PollInterval time.Duration     // пауза, когда очередь пуста
+	JobTimeout   time.Duration     // дедлайн одной попытки
+	MinBackoff   time.Duration     // пауза перед первой повторной попыткой
+	MaxBackoff   time.Duration     // максимальная пауза между попытками
+	Metrics      *metrics.Registry // nil — метрики не публикуются
+}
+
+func (o *WorkerOptions) setDefaults() {
+	if len(o.Queues) == 0 {
+		o.Queues = map[string]int{DefaultQueue: 1}
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.JobTimeout <= 0 {
+		o.JobTimeout = 10 * time.Minute
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = 10 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.Metrics == nil {
+		o.Metrics = metrics.NewRegistry()
+	}
+}
+
+// Worker выполняет задачи зарегистрированных типов
+type Worker struct {
+	jobs     repositories.JobRepository
+	opts     WorkerOptions
+	id       string
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	processed *metrics.CounterVec
+	duration  *metrics.HistogramVec
+}
+
+func NewWorker(jobs repositories.JobRepository, opts WorkerOptions) *Worker {
+	opts.setDefaults()
+	host, _ := os.Hostname()
+	return &Worker{
+		jobs:     jobs,
+		opts:     opts,
+		id:       fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8]),
+		handlers: map[string]Handler{},
+		processed: opts.Metrics.NewCounterVec(
+			"engkids_jobs_processed_total",
+			"Количество выполненных попыток фоновых задач.",
+			"queue", "type", "result",
+		),
+		duration: opts.Metrics.NewHistogramVec(
+			"engkids_job_duration_seconds",
+			"Время выполнения фоновой задачи в секундах.",
+			nil,
+			"queue", "type",
+		),
+	}
+}
+
+// Handle регистрирует обработчик задач типа jobType
+func (w *Worker) Handle(jobType string, h Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = h
+}
+
+// Handle регистрирует типизированный обработчик: payload декодируется в P
+func Handle[P any](w *Worker, jobType string, fn func(ctx context.Context, payload P) error) {
+	w.Handle(jobType, func(ctx context.Context, raw json.RawMessage) error {
+		var p P
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return Permanent(fmt.Errorf("decode %s payload: %w", jobType, err))
+		}
+		return fn(ctx, p)
+	})
+}
+
+// Run выполняет задачи, пока не отменён ctx, и дожидается завершения текущих
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for queue, concurrency := range w.opts.Queues {
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.loop(ctx, queue)
+			}()
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.reap(ctx)
+	}()
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context, queue string) {
+	for ctx.Err() == nil {
+		worked, err := w.RunOnce(ctx, queue)
+		if err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).WithError(err).WithField("queue", queue).Error("Failed to claim job")
+		}
+		if worked && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+// reap разбирает задачи воркеров, которые упали посреди выполнения
+func (w *Worker) reap(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		w.reapStale(ctx, time.Now().Add(-2*w.opts.JobTimeout))
+	}
+}
+
+// reapStale возвращает в очередь задачи, захваченные раньше lockedBefore.
+// Задача, которая роняет воркер, исчерпывает попытки и уходит в dead, а не
+// перезапускается бесконечно
+func (w *Worker) reapStale(ctx context.Context, lockedBefore time.Time) {
+	requeued, dead, err := w.jobs.RequeueStale(ctx, lockedBefore)
+	log := logger.FromContext(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.WithError(err).Error("Failed to requeue stale jobs")
+		}
+		return
+	}
+	if requeued > 0 {
+		log.WithField("count", requeued).Warn("Requeued stale jobs")
+	}
+	if dead > 0 {
+		log.WithField("count", dead).Error("Stale jobs moved to dead state")
+	}
+}
+
+// RunOnce захватывает и выполняет одну задачу очереди. worked — была ли задача
+func (w *Worker) RunOnce(ctx context.Context, queue string) (worked bool, err error) {
+	claimed, err := w.jobs.Claim(ctx, queue, w.id, time.Now(), 1)
+	if err != nil || len(claimed) == 0 {
+		return false, err
+	}
+	job := &claimed[0]
+	w.execute(ctx, job)
+	// состояние сохраняем, даже если воркер останавливается
+	return true, w.jobs.Transition(context.WithoutCancel(ctx), job, models.JobRunning)
+}
+
+func (w *Worker) execute(ctx context.Context, job *models.Job) {
+	log := logger.FromContext(ctx).WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"queue":    job.Queue,
+		"attempt":  job.Attempts,
+	})
+
+	w.mu.RLock()
+	handle, ok := w.handlers[job.Type]
+	w.mu.RUnlock()
+
+	start := time.Now()
+	var err error
+	if !ok {
+		err = Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	} else {
+		err = w.call(ctx, handle, job)
+	}
+	w.duration.WithLabelValues(job.Queue, job.Type).Observe(time.Since(start).Seconds())
+
+	now := time.Now()
+	job.LockedBy, job.LockedAt = "", nil
+
+	var perm permanentError
+	switch {
+	case err == nil:
+		job.Status, job.FinishedAt, job.LastError = models.JobSucceeded, &now, ""
+		w.processed.WithLabelValues(job.Queue, job.Type, "succeeded").Inc()
+		log.Info("Job succeeded")
+	case ctx.Err() != nil:
+		// воркер останавливается: попытка не считается, задача вернётся в очередь
+		job.Status, job.RunAt, job.Attempts, job.LastError = models.JobQueued, now, job.Attempts-1, err.Error()
+		w.processed.WithLabelValues(job.Queue, job.Type, "interrupted").Inc()
+		log.WithError(err).Warn("Job interrupted by shutdown")
+	case errors.As(err, &perm) || job.Attempts >= job.MaxAttempts:
+		job.Status, job.FinishedAt, job.LastError = models.JobDead, &now, err.Error()
+		w.processed.WithLabelValues(job.Queue, job.Type, "dead").Inc()
+		log.WithError(err).Error("Job moved to dead state")
+	default:
+		job.Status, job.RunAt, job.LastError = models.JobQueued, now.Add(w.backoff(job.Attempts)), err.Error()
+		w.processed.WithLabelValues(job.Queue, job.Type, "retry").Inc()
+		log.WithError(err).Warn("Job failed, will retry")
+	}
+}
+
+// call выполняет обработчик с дедлайном и превращает панику в ошибку
+func (w *Worker) call(ctx context.Context, handle Handler, job *models.Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.JobTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handle(ctx, job.Payload)
+}
+
+// backoff — экспоненциальная пауза с джиттером перед попыткой attempts+1
+func (w *Worker) backoff(attempts int) time.Duration {
+	b := w.opts.MinBackoff
+	for i := 1; i < attempts && b < w.opts.MaxBackoff; i++ {
+		b *= 2
+	}
+	if b > w.opts.MaxBackoff {
+		b = w.opts.MaxBackoff
+	}
+	return b/2 + time.Duration(rand.Int63n(int64(b/2)+1))
+}
@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/internal/repositories/memory"
+)
+
+type welcomeEmail struct {
+	UserID uint `json:"user_id"`
+}
+
+func findJob(t *testing.T, repos *repositories.Repositories, id uint) *models.Job {
+	t.Helper()
+	job, err := repos.Jobs.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestWorkerRetriesWithBackoffThenSucceeds(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	client := NewClient(repos.Jobs)
+	w := NewWorker(repos.Jobs, WorkerOptions{Queues: map[string]int{"emails": 1}, MinBackoff: time.Hour, MaxBackoff: 4 * time.Hour})
+
+	var sent []uint
+	calls := 0
+	Handle(w, "email.welcome", func(_ context.Context, p welcomeEmail) error {
+		calls++
+		if calls == 1 {
+			return errors.New("smtp unavailable")
+		}
+		sent = append(sent, p.UserID)
+		return nil
+	})
+
+	job, err := client.Enqueue(ctx, "email.welcome", welcomeEmail{UserID: 42}, Queue("emails"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// задачи другой очереди этот цикл не трогает
+	if worked, _ := w.RunOnce(ctx, DefaultQueue); worked {
+		t.Fatal("claimed job from another queue")
+	}
+
+	if worked, err := w.RunOnce(ctx, "emails"); !worked || err != nil {
+		t.Fatalf("first run: worked=%v err=%v", worked, err)
+	}
+	failed := findJob(t, repos, job.ID)
+	if failed.Status != models.JobQueued || failed.Attempts != 1 || failed.LastError == "" {
+		t.Fatalf("unexpected state after failure: %+v", failed)
+	}
+	if !failed.RunAt.After(time.Now().Add(20 * time.Minute)) {
+		t.Fatalf("backoff not applied: %v", failed.RunAt)
+	}
+	if worked, _ := w.RunOnce(ctx, "emails"); worked {
+		t.Fatal("job ran before backoff elapsed")
+	}
+
+	// переносим попытку на сейчас, как будто пауза истекла
+	failed.RunAt = time.Now()
+	if err := repos.Jobs.Transition(ctx, failed, models.JobQueued); err != nil {
+		t.Fatal(err)
+	}
+	if worked, err := w.RunOnce(ctx, "emails"); !worked || err != nil {
+		t.Fatalf("second run: worked=%v err=%v", worked, err)
+	}
+	done := findJob(t, repos, job.ID)
+	if done.Status != models.JobSucceeded || done.FinishedAt == nil || len(sent) != 1 || sent[0] != 42 {
+		t.Fatalf("job not completed: %+v sent=%v", done, sent)
+	}
+}
+
+func TestWorkerDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	client := NewClient(repos.Jobs)
+	w := NewWorker(repos.Jobs, WorkerOptions{})
+
+	w.Handle("flaky", func(context.Context, json.RawMessage) error { return errors.New("boom") })
+	w.Handle("broken", func(context.Context, json.RawMessage) error { return Permanent(errors.New("bad payload")) })
+	w.Handle("panics", func(context.Context, json.RawMessage) error { panic("oops") })
+
+	exhausted, _ := client.Enqueue(ctx, "flaky", nil, MaxAttempts(1))
+	permanent, _ := client.Enqueue(ctx, "broken", nil)
+	unknown, _ := client.Enqueue(ctx, "nobody.handles.this", nil)
+	panicked, _ := client.Enqueue(ctx, "panics", nil, MaxAttempts(1))
+
+	for i := 0; i < 4; i++ {
+		if _, err := w.RunOnce(ctx, DefaultQueue); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, j := range []*models.Job{exhausted, permanent, unknown, panicked} {
+		if got := findJob(t, repos, j.ID); got.Status != models.JobDead {
+			t.Errorf("%s: status %s, want dead", j.Type, got.Status)
+		}
+	}
+}
+
+func TestWorkerReapsStaleJobs(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	client := NewClient(repos.Jobs)
+	w := NewWorker(repos.Jobs, WorkerOptions{})
+
+	// воркер «упал» посреди обеих задач: у первой есть ещё попытка, вторая
+	// свою последнюю уже потратила
+	retry, _ := client.Enqueue(ctx, "crashes", nil, MaxAttempts(2))
+	exhausted, _ := client.Enqueue(ctx, "crashes", nil, MaxAttempts(1))
+	if claimed, err := repos.Jobs.Claim(ctx, DefaultQueue, "crashed-worker", time.Now(), 10); err != nil || len(claimed) != 2 {
+		t.Fatalf("claim: %v %v", claimed, err)
+	}
+
+	w.reapStale(ctx, time.Now().Add(time.Minute))
+
+	if got := findJob(t, repos, retry.ID); got.Status != models.JobQueued || got.LockedBy != "" || got.Attempts != 1 {
+		t.Errorf("job with attempts left: %+v", got)
+	}
+	got := findJob(t, repos, exhausted.ID)
+	if got.Status != models.JobDead || got.FinishedAt == nil || got.LastError != repositories.StaleJobError {
+		t.Errorf("exhausted job: %+v", got)
+	}
+
+	// повторный разбор не трогает задачи, которые уже не в running
+	if requeued, dead, err := repos.Jobs.RequeueStale(ctx, time.Now().Add(time.Minute)); err != nil || requeued != 0 || dead != 0 {
+		t.Errorf("second pass: requeued=%d dead=%d err=%v", requeued, dead, err)
+	}
+}
+
+func TestClientRetryAndCancel(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	client := NewClient(repos.Jobs)
+
+	job, _ := client.Enqueue(ctx, "report", map[string]int{"child_id": 1}, Delay(time.Hour))
+
+	if _, err := client.Retry(ctx, job.ID); !errors.Is(err, apperrors.New(apperrors.CodeJobInvalidState)) {
+		t.Fatalf("retry of queued job: %v", err)
+	}
+	canceled, err := client.Cancel(ctx, job.ID)
+	if err != nil || canceled.Status != models.JobCanceled {
+		t.Fatalf("cancel: %+v %v", canceled, err)
+	}
+	retried, err := client.Retry(ctx, job.ID)
+	if err != nil || retried.Status != models.JobQueued || retried.Attempts != 0 {
+		t.Fatalf("retry: %+v %v", retried, err)
+	}
+	if _, err := client.Cancel(ctx, 999); !errors.Is(err, apperrors.New(apperrors.CodeJobNotFound)) {
+		t.Fatalf("cancel of missing job: %v", err)
+	}
+}
@@ -1,31 +1,36 @@
 package middlewares
 
 import (
+	apperrors "engkids/internal/errors"
 	"engkids/internal/services"
 	"engkids/pkg/jwt"
+	"engkids/pkg/logger"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
 )
 
-var authService *services.AuthService
+// Auth проверяет access-токен и при необходимости обновляет его через AuthService
+type Auth struct {
+	service *services.AuthService
+}
 
-// InjectAuthService нужен для инициализации authService
-func InjectAuthService(s *services.AuthService) {
-	authService = s
+func NewAuth(service *services.AuthService) *Auth {
+	return &Auth{service: service}
 }
 
 // Protected middleware с авто-обновлением токена (для mobile)
-func Protected() fiber.Handler {
+func (a *Auth) Protected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return unauthorized("Необходим access токен")
+			return apperrors.New(apperrors.CodeAuthTokenMissing)
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			return unauthorized("Неверный формат Authorization")
+			return apperrors.New(apperrors.CodeAuthTokenMalformed)
 		}
 
 		accessToken := parts[1]
@@ -40,16 +45,12 @@ func Protected() fiber.Handler {
 		// access просрочен — пробуем refresh
 		refreshToken := c.Get("X-Refresh-Token")
 		if refreshToken == "" {
-			return unauthorized("Access истёк, refresh не передан")
-		}
-
-		if authService == nil {
-			return unauthorized("AuthService не инициализирован")
+			return apperrors.New(apperrors.CodeAuthTokenExpired)
 		}
 
-		resp, err := authService.Refresh(refreshToken)
+		resp, err := a.service.Refresh(c.UserContext(), refreshToken)
 		if err != nil {
-			return unauthorized("Refresh токен невалиден")
+			return err
 		}
 
 		// Обновляем токены клиенту
@@ -57,20 +58,32 @@ func Protected() fiber.Handler {
 		c.Set("X-New-Refresh-Token", resp.RefreshToken)
 
 		user := resp.User
-		c.Locals("userID", user.ID)
-		c.Locals("email", user.Email)
-		c.Locals("role", user.Role)
+		authenticate(c, user.ID, user.Email, user.Role)
 
 		return c.Next()
 	}
 }
 
+// RequireRole пропускает только пользователей с одной из ролей; ставится после Protected
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, r := range roles {
+			if role == r {
+				return c.Next()
+			}
+		}
+		return apperrors.New(apperrors.CodeAuthForbidden)
+	}
+}
+
 func setLocals(c *fiber.Ctx, claims *jwt.Claims) {
-	c.Locals("userID", claims.UserID)
-	c.Locals("email", claims.Email)
-	c.Locals("role", claims.Role)
+	authenticate(c, claims.UserID, claims.Email, claims.Role)
 }
 
-func unauthorized(msg string) error {
-	return fiber.NewError(fiber.StatusUnauthorized, msg)
+func authenticate(c *fiber.Ctx, userID uint, email, role string) {
+	c.Locals("userID", userID)
+	c.Locals("email", email)
+	c.Locals("role", role)
+	logger.AddFields(c.UserContext(), logrus.Fields{logger.FieldUserID: userID})
 }
@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// IdempotencyKeyHeader — ключ запроса, который клиент генерирует один раз и
+	// повторяет во всех ретраях
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader помечает ответ, повторённый из сохранённого
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKey     = 255
+	defaultIdempotencyTTL = 24 * time.Hour
+	// idempotencyLease — сколько ключ считается занятым выполняющимся запросом.
+	// Срок больше любого дедлайна запроса; если процесс упал посреди запроса,
+	// ключ освободится через lease, а не через ttl
+	idempotencyLease = time.Minute
+)
+
+// Idempotency защищает POST и PUT от повторов: первый ответ на запрос с
+// заголовком Idempotency-Key сохраняется на ttl и отдаётся на ретраи с тем же
+// ключом без повторного выполнения. Ключ принадлежит пользователю, поэтому
+// middleware ставится после Protected; запросы без авторизации выполняются
+// как обычно — их ключи общие, а ответы (например, с токенами) нельзя отдавать
+// тому, кто угадал ключ. Тот же ключ с другим методом, путём или телом — 422, ключ, запрос
+// с которым ещё выполняется, — 409. Ответы 5xx и паника обработчика не
+// сохраняются: после сбоя клиент может повторить запрос
+func Idempotency(repo repositories.IdempotencyRepository, ttl time.Duration) fiber.Handler {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		userID, _ := c.Locals("userID").(uint)
+		if key == "" || userID == 0 || (c.Method() != fiber.MethodPost && c.Method() != fiber.MethodPut) {
+			return c.Next()
+		}
+		if !validIdempotencyKey(key) {
+			return apperrors.New(apperrors.CodeBadRequest).WithDetails(map[string]any{"param": IdempotencyKeyHeader})
+		}
+
+		// строки Fiber указывают в буфер запроса, а ключ хранится дольше
+		key = strings.Clone(key)
+		ctx := c.UserContext()
+		now := time.Now()
+		record := &models.IdempotencyKey{UserID: userID, Key: key, RequestHash: requestHash(c), ExpiresAt: now.Add(idempotencyLease)}
+		err := repo.Reserve(ctx, record, now)
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return replay(c, repo, record)
+		}
+		if err != nil {
+			return apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+
+		// паника уходит в Recover выше, но ключ нельзя оставлять занятым
+		defer func() {
+			if r := recover(); r != nil {
+				release(c, repo, record)
+				panic(r)
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			// ответ из ошибки ещё не записан: записываем, чтобы сохранить его
+			// таким, каким его получит клиент. Ошибка на этом обработана, и
+			// дальше её не возвращаем, иначе Fiber запишет ответ второй раз
+			if herr := c.App().Config().ErrorHandler(c, err); herr != nil {
+				return herr
+			}
+			logger.AddFields(ctx, logrus.Fields{"error": err.Error()})
+		}
+		resp := c.Response()
+		if resp.StatusCode() >= fiber.StatusInternalServerError {
+			release(c, repo, record)
+			return nil
+		}
+		record.Status, record.ContentType = resp.StatusCode(), string(resp.Header.ContentType())
+		record.Body = bytes.Clone(resp.Body())
+		record.ExpiresAt = time.Now().Add(ttl)
+		// запрос мог прерваться по дедлайну, а ответ нужно сохранить в любом случае
+		if err := repo.Complete(context.WithoutCancel(ctx), record); err != nil {
+			logger.FromCtx(c).WithError(err).Error("Failed to store idempotent response")
+		}
+		return nil
+	}
+}
+
+// release освобождает ключ запроса, ответ на который не сохраняется
+func release(c *fiber.Ctx, repo repositories.IdempotencyRepository, record *models.IdempotencyKey) {
+	if err := repo.Delete(context.WithoutCancel(c.UserContext()), record.ID); err != nil {
+		logger.FromCtx(c).WithError(err).Error("Failed to release idempotency key")
+	}
+}
+
+// replay отдаёт сохранённый ответ на запрос с занятым ключом
+func replay(c *fiber.Ctx, repo repositories.IdempotencyRepository, record *models.IdempotencyKey) error {
+	stored, err := repo.Find(c.UserContext(), record.UserID, record.Key)
+	if errors.Is(err, repositories.ErrNotFound) {
+		// первый запрос завершился ошибкой 5xx и освободил ключ
+		return apperrors.New(apperrors.CodeIdempotencyInProgress)
+	}
+	if err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	if stored.RequestHash != record.RequestHash {
+		return apperrors.New(apperrors.CodeIdempotencyKeyReused)
+	}
+	if stored.Status == 0 {
+		return apperrors.New(apperrors.CodeIdempotencyInProgress)
+	}
+
+	logger.FromCtx(c).WithField("status", stored.Status).Info("Replaying idempotent response")
+	c.Set(IdempotentReplayedHeader, "true")
+	if stored.ContentType != "" {
+		c.Set(fiber.HeaderContentType, stored.ContentType)
+	}
+	return c.Status(stored.Status).Send(stored.Body)
+}
+
+// requestHash — отпечаток запроса: тот же ключ допустим только для того же
+// метода, пути с параметрами и тела
+func requestHash(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// validIdempotencyKey — ключ из видимых ASCII-символов, не длиннее 255
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKey {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
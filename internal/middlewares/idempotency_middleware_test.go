@@ -0,0 +1,161 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIdempotency(t *testing.T) {
+	repos := memory.New()
+	app := fiber.New(fiber.Config{ErrorHandler: apperrors.Handle})
+	var leaked []error
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil && c.Path() == "/conflict" {
+			leaked = append(leaked, err)
+		}
+		return err
+	})
+	app.Use(Recover())
+	app.Use(func(c *fiber.Ctx) error {
+		if id, err := strconv.Atoi(c.Get("X-User")); err == nil {
+			c.Locals("userID", uint(id))
+		}
+		return c.Next()
+	})
+	app.Use(Idempotency(repos.Idempotency, 50*time.Millisecond))
+
+	calls := map[string]int{}
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		calls["orders"]++
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"order": calls["orders"]})
+	})
+	app.Put("/conflict", func(c *fiber.Ctx) error {
+		calls["conflict"]++
+		return apperrors.New(apperrors.CodeConflict)
+	})
+	app.Post("/broken", func(c *fiber.Ctx) error {
+		calls["broken"]++
+		return apperrors.Wrap(apperrors.CodeInternal, io.ErrUnexpectedEOF)
+	})
+	app.Post("/panic", func(c *fiber.Ctx) error {
+		calls["panic"]++
+		panic("boom")
+	})
+
+	send := func(method, path, key, user, body string) (int, string, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(raw), resp.Header.Get(IdempotentReplayedHeader)
+	}
+
+	status, body, replayed := send(fiber.MethodPost, "/orders", "k1", "1", `{"item":1}`)
+	if status != fiber.StatusCreated || body != `{"order":1}` || replayed != "" {
+		t.Fatalf("first request: %d %s %q", status, body, replayed)
+	}
+	status, body, replayed = send(fiber.MethodPost, "/orders", "k1", "1", `{"item":1}`)
+	if status != fiber.StatusCreated || body != `{"order":1}` || replayed != "true" {
+		t.Fatalf("retry: %d %s %q", status, body, replayed)
+	}
+
+	// тот же ключ у другого пользователя — другой запрос
+	if _, body, _ = send(fiber.MethodPost, "/orders", "k1", "2", `{"item":1}`); body != `{"order":2}` {
+		t.Fatalf("other user: %s", body)
+	}
+	// тот же ключ с другим телом или параметрами запроса
+	for _, req := range [][2]string{{"/orders", `{"item":2}`}, {"/orders?coupon=1", `{"item":1}`}} {
+		status, body, _ = send(fiber.MethodPost, req[0], "k1", "1", req[1])
+		var resp apperrors.Response
+		_ = json.Unmarshal([]byte(body), &resp)
+		if status != fiber.StatusUnprocessableEntity || resp.Code != apperrors.CodeIdempotencyKeyReused {
+			t.Fatalf("reused key on %s: %d %s", req[0], status, body)
+		}
+	}
+	// без ключа запрос выполняется как обычно
+	if _, body, _ = send(fiber.MethodPost, "/orders", "", "1", `{"item":1}`); body != `{"order":3}` {
+		t.Fatalf("without key: %s", body)
+	}
+	// ответы на запросы без авторизации не сохраняются и не повторяются
+	for _, want := range []string{`{"order":4}`, `{"order":5}`} {
+		if _, body, replayed = send(fiber.MethodPost, "/orders", "anon", "", `{"item":1}`); body != want || replayed != "" {
+			t.Fatalf("anonymous request: %s %q, want %s", body, replayed, want)
+		}
+	}
+
+	// ответ с ошибкой 4xx тоже повторяется
+	for range 2 {
+		if status, body, _ = send(fiber.MethodPut, "/conflict", "k2", "1", `{}`); status != fiber.StatusConflict {
+			t.Fatalf("conflict: %d %s", status, body)
+		}
+	}
+	if calls["conflict"] != 1 {
+		t.Errorf("conflict handler ran %d times", calls["conflict"])
+	}
+	// ошибка уже записана в ответ и дальше не передаётся
+	if len(leaked) != 0 {
+		t.Errorf("errors returned after rendering: %v", leaked)
+	}
+
+	// после 5xx ключ освобождается, и ретрай выполняет запрос заново
+	for range 2 {
+		if status, _, _ = send(fiber.MethodPost, "/broken", "k3", "1", `{}`); status != fiber.StatusInternalServerError {
+			t.Fatalf("broken: %d", status)
+		}
+	}
+	if calls["broken"] != 2 {
+		t.Errorf("broken handler ran %d times", calls["broken"])
+	}
+	// паника тоже освобождает ключ, а не оставляет его занятым
+	for range 2 {
+		if status, _, _ = send(fiber.MethodPost, "/panic", "k5", "1", `{}`); status != fiber.StatusInternalServerError {
+			t.Fatalf("panic: %d", status)
+		}
+	}
+	if calls["panic"] != 2 {
+		t.Errorf("panicking handler ran %d times", calls["panic"])
+	}
+
+	// ключ запроса, который ещё выполняется
+	sum := sha256.Sum256([]byte("POST\x00/orders\x00{}"))
+	busy := &models.IdempotencyKey{UserID: 1, Key: "k4", RequestHash: hex.EncodeToString(sum[:]), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.Idempotency.Reserve(context.Background(), busy, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if status, _, _ = send(fiber.MethodPost, "/orders", "k4", "1", `{}`); status != fiber.StatusConflict {
+		t.Fatalf("key in progress: %d", status)
+	}
+
+	if status, _, _ = send(fiber.MethodPost, "/orders", strings.Repeat("x", 256), "1", `{}`); status != fiber.StatusBadRequest {
+		t.Fatalf("long key: %d", status)
+	}
+
+	// по истечении срока ключ можно использовать снова
+	time.Sleep(60 * time.Millisecond)
+	if _, body, replayed = send(fiber.MethodPost, "/orders", "k1", "1", `{"item":1}`); body != `{"order":6}` || replayed != "" {
+		t.Fatalf("expired key: %s %q", body, replayed)
+	}
+}
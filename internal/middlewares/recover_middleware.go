@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	apperrors "engkids/internal/errors"
+	"engkids/pkg/logger"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// Recover перехватывает панику в обработчике, пишет её со стеком в лог запроса
+// и превращает в INTERNAL_ERROR, чтобы клиент получил обычный ответ с ошибкой.
+// Подключается после LoggingMiddleware и Registry.Middleware, чтобы запрос
+// попал в журнал и метрики со статусом 500
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			logger.FromCtx(c).WithFields(logrus.Fields{
+				"panic": fmt.Sprint(r),
+				"stack": string(debug.Stack()),
+			}).Error("panic recovered")
+			err = apperrors.Wrap(apperrors.CodeInternal, fmt.Errorf("panic: %v", r))
+		}()
+		return c.Next()
+	}
+}
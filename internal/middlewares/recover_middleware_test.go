@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperrors "engkids/internal/errors"
+	"engkids/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestApp(out *bytes.Buffer) *fiber.App {
+	l := logrus.New()
+	l.SetOutput(out)
+	l.SetFormatter(&logrus.JSONFormatter{})
+
+	app := fiber.New(fiber.Config{ErrorHandler: apperrors.Handle})
+	app.Use(requestid.New())
+	app.Use(logger.LoggingMiddleware(l))
+	app.Use(Recover())
+	app.Get("/panic", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func doRequest(t *testing.T, app *fiber.App, method, path string) (int, apperrors.Response) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(method, path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	var body apperrors.Response
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("%s %s: response is not an error envelope: %s", method, path, raw)
+	}
+	return resp.StatusCode, body
+}
+
+func TestRecoverReturnsEnvelopeAndLogsStack(t *testing.T) {
+	var out bytes.Buffer
+	app := newTestApp(&out)
+
+	status, body := doRequest(t, app, fiber.MethodGet, "/panic")
+	if status != fiber.StatusInternalServerError || body.Code != apperrors.CodeInternal {
+		t.Fatalf("status=%d code=%s", status, body.Code)
+	}
+	if body.RequestID == "" {
+		t.Fatal("request_id missing in envelope")
+	}
+
+	var panicLogged, accessLogged bool
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("bad log line %q", line)
+		}
+		if entry["request_id"] != body.RequestID {
+			continue
+		}
+		switch entry["msg"] {
+		case "panic recovered":
+			stack, _ := entry["stack"].(string)
+			panicLogged = entry["panic"] == "boom" && strings.Contains(stack, "recover_middleware_test.go")
+		case "request failed":
+			accessLogged = entry["status"] == float64(fiber.StatusInternalServerError)
+		}
+	}
+	if !panicLogged || !accessLogged {
+		t.Fatalf("panic=%v access=%v:\n%s", panicLogged, accessLogged, out.String())
+	}
+}
+
+func TestUnknownRoutesReturnEnvelope(t *testing.T) {
+	var out bytes.Buffer
+	app := newTestApp(&out)
+
+	cases := []struct {
+		method, path string
+		status       int
+		code         apperrors.Code
+	}{
+		{fiber.MethodGet, "/missing", fiber.StatusNotFound, apperrors.CodeNotFound},
+		{fiber.MethodPost, "/ok", fiber.StatusMethodNotAllowed, apperrors.CodeMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		status, body := doRequest(t, app, tc.method, tc.path)
+		if status != tc.status || body.Code != tc.code || body.Error == "" {
+			t.Errorf("%s %s: status=%d body=%+v", tc.method, tc.path, status, body)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	apperrors "engkids/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RouteTimeout — дедлайн для запросов, путь которых начинается с Prefix
+type RouteTimeout struct {
+	Prefix  string
+	Timeout time.Duration
+}
+
+// Timeout ограничивает время обработки запроса: кладёт в c.UserContext()
+// контекст с дедлайном, который сервисы передают в запросы к БД. Дедлайн берётся
+// из самого длинного подходящего префикса routes, иначе — def; 0 отключает его.
+// Обработчик не прерывается принудительно: запросы к БД отменяются по контексту,
+// а ошибка превращается в 504 TIMEOUT
+func Timeout(def time.Duration, routes []RouteTimeout) fiber.Handler {
+	routes = append([]RouteTimeout(nil), routes...)
+	sort.SliceStable(routes, func(i, j int) bool { return len(routes[i].Prefix) > len(routes[j].Prefix) })
+
+	return func(c *fiber.Ctx) error {
+		d := def
+		for _, r := range routes {
+			if strings.HasPrefix(c.Path(), r.Prefix) {
+				d = r.Timeout
+				break
+			}
+		}
+		if d <= 0 {
+			return c.Next()
+		}
+
+		parent := c.UserContext()
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		// восстанавливаем контекст: ErrorHandler и внешние middleware работают уже после дедлайна
+		c.SetUserContext(parent)
+
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, apperrors.New(apperrors.CodeTimeout)) {
+			return apperrors.Wrap(apperrors.CodeTimeout, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "engkids/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTimeoutReturns504WhenDeadlineExceeded(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: apperrors.Handle})
+	app.Use(Timeout(time.Second, []RouteTimeout{
+		{Prefix: "/api", Timeout: time.Second},
+		{Prefix: "/api/slow", Timeout: 20 * time.Millisecond},
+	}))
+	// имитирует запрос к БД, который уважает контекст
+	query := func(c *fiber.Ctx) error {
+		select {
+		case <-c.UserContext().Done():
+			return apperrors.Wrap(apperrors.CodeInternal, c.UserContext().Err())
+		case <-time.After(200 * time.Millisecond):
+			return c.SendStatus(fiber.StatusOK)
+		}
+	}
+	app.Get("/api/slow", query)
+	app.Get("/api/fast", query)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/slow", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	var body apperrors.Response
+	_ = json.Unmarshal(raw, &body)
+	if resp.StatusCode != fiber.StatusGatewayTimeout || body.Code != apperrors.CodeTimeout {
+		t.Fatalf("slow: %d %s", resp.StatusCode, raw)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/api/fast", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("fast: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// GameSession — идущая викторина. Состояние хранится в базе, чтобы запросы
+// по коду игры мог обслужить любой экземпляр приложения. Version растёт с
+// каждым сохранением и защищает от одновременных изменений
+type GameSession struct {
+	Code      string          `gorm:"primaryKey"`
+	State     json.RawMessage `gorm:"type:jsonb;not null"` // game.Game в JSON
+	Phase     string          `gorm:"not null"`
+	Deadline  *time.Time      `gorm:"index"` // когда игру пора перевести на следующий этап или удалить брошенное лобби
+	Version   int             `gorm:"not null"`
+	CreatedAt time.Time       `gorm:"not null;index"`
+	UpdatedAt time.Time
+}
+
+// GameResult — итоги сыгранной викторины
+type GameResult struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	Code       string       `json:"code" gorm:"not null"`
+	HostID     uint         `json:"host_id" gorm:"not null"` // пользователь, создавший игру
+	LessonID   uint         `json:"lesson_id" gorm:"not null"`
+	Rounds     int          `json:"rounds"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+	Players    []GamePlayer `json:"players" gorm:"foreignKey:GameID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// GamePlayer — место ребёнка в итоговой таблице
+type GamePlayer struct {
+	ID      uint   `json:"-" gorm:"primaryKey"`
+	GameID  uint   `json:"-" gorm:"not null;index"`
+	ChildID uint   `json:"child_id" gorm:"not null;index"`
+	Name    string `json:"name"`
+	Rank    int    `json:"rank"`
+	Score   int    `json:"score"`
+	Correct int    `json:"correct"`
+}
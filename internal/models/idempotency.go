@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey — ответ на запрос с заголовком Idempotency-Key, который
+// повторяется на ретраи клиента. Пока запрос выполняется, Status равен 0, а
+// ExpiresAt — короткий срок аренды ключа вместо срока хранения ответа
+type IdempotencyKey struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_keys_key,priority:1"` // 0 — запрос без авторизации
+	Key         string    `json:"key" gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_key,priority:2"`
+	RequestHash string    `json:"request_hash" gorm:"size:64;not null"` // SHA-256 метода, пути и тела
+	Status      int       `json:"status" gorm:"not null;default:0"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"-"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
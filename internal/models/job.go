@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Состояния фоновой задачи
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead" // попытки исчерпаны или ошибка неисправима
+	JobCanceled  = "canceled"
+)
+
+// Job — фоновая задача в очереди на Postgres
+type Job struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	Queue       string          `json:"queue" gorm:"not null;default:'default';index:idx_jobs_claim,priority:1"`
+	Type        string          `json:"type" gorm:"not null;index"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status      string          `json:"status" gorm:"not null;default:'queued';index:idx_jobs_claim,priority:2"`
+	RunAt       time.Time       `json:"run_at" gorm:"not null;index:idx_jobs_claim,priority:3"`
+	Attempts    int             `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"not null;default:5"`
+	LastError   string          `json:"last_error,omitempty"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
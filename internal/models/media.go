@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Виды медиафайлов
+const (
+	MediaImage = "image"
+	MediaAudio = "audio"
+)
+
+// MediaAsset — загруженная картинка или аудиозапись. Одинаковые файлы
+// хранятся один раз: повторная загрузка находит запись по хешу содержимого
+type MediaAsset struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Hash         string    `json:"hash" gorm:"size:64;not null;uniqueIndex"` // SHA-256 содержимого, hex
+	Kind         string    `json:"kind" gorm:"size:16;not null"`
+	ContentType  string    `json:"content_type" gorm:"size:64;not null"`
+	Size         int64     `json:"size" gorm:"not null"`
+	Width        int       `json:"width,omitempty"` // только у картинок
+	Height       int       `json:"height,omitempty"`
+	Key          string    `json:"-" gorm:"size:512;not null"`            // ключ файла в хранилище
+	ThumbnailKey string    `json:"-" gorm:"size:512;not null;default:''"` // пусто — миниатюры нет
+	UploadedBy   uint      `json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
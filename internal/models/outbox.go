@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Состояния события в outbox
+const (
+	OutboxPending   = "pending"
+	OutboxProcessed = "processed"
+	OutboxDead      = "dead" // попытки доставки исчерпаны
+)
+
+// OutboxEvent — доменное событие, записанное в той же транзакции, что и
+// изменение данных. Диспетчер доставляет его подписчикам после коммита
+type OutboxEvent struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	Type          string          `json:"type" gorm:"not null;index"`
+	Payload       json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status        string          `json:"status" gorm:"not null;default:'pending';index:idx_outbox_due,priority:1"`
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"not null;index:idx_outbox_due,priority:2"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RealtimeMessage — сообщение WebSocket, не поместившееся в payload NOTIFY.
+// Экземпляры читают его по ID из уведомления; старые записи удаляются
+type RealtimeMessage struct {
+	ID        uint      `gorm:"primaryKey"`
+	Room      string    `gorm:"not null"`
+	Payload   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Итоги запуска периодической задачи
+const (
+	ScheduledRunRunning   = "running"
+	ScheduledRunSucceeded = "succeeded"
+	ScheduledRunFailed    = "failed"
+)
+
+// ScheduledRun — запись о запуске задачи планировщика
+type ScheduledRun struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Task        string     `json:"task" gorm:"not null;index:idx_scheduled_runs_task,priority:1"`
+	Instance    string     `json:"instance"` // экземпляр приложения, который был лидером
+	Status      string     `json:"status" gorm:"not null"`
+	ScheduledAt time.Time  `json:"scheduled_at" gorm:"not null;index:idx_scheduled_runs_task,priority:2"`
+	StartedAt   time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	DurationMs  int64      `json:"duration_ms"`
+	Error       string     `json:"error,omitempty"`
+}
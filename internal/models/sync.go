@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SyncOperation — операция офлайн-синхронизации, которую сервер уже принял.
+// ClientID генерирует приложение; повторная отправка той же операции
+// распознаётся по нему и ничего не меняет
+type SyncOperation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_sync_operations_client,priority:1"`
+	ClientID  string    `json:"client_id" gorm:"size:64;not null;uniqueIndex:idx_sync_operations_client,priority:2"`
+	Type      string    `json:"type" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WordReview — повторение слова ребёнком по карточкам Лейтнера: верный ответ
+// переносит слово в следующую коробку, ошибка — обратно в первую
+type WordReview struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ChildID    uint      `json:"child_id" gorm:"not null;uniqueIndex:idx_word_reviews_child_word,priority:1"`
+	WordID     uint      `json:"word_id" gorm:"not null;uniqueIndex:idx_word_reviews_child_word,priority:2"`
+	Box        int       `json:"box" gorm:"not null;default:1"`
+	Grade      int       `json:"grade" gorm:"not null"` // последняя оценка, 0–5
+	Reviews    int       `json:"reviews" gorm:"not null;default:0"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+	DueAt      time.Time `json:"due_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"index"`
+}
+
+// ChildSetting — настройка приложения ребёнка. ChangedAt — время изменения на
+// устройстве: из двух офлайн-изменений остаётся более позднее
+type ChildSetting struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	ChildID   uint            `json:"child_id" gorm:"not null;uniqueIndex:idx_child_settings_key,priority:1"`
+	Key       string          `json:"key" gorm:"size:64;not null;uniqueIndex:idx_child_settings_key,priority:2"`
+	Value     json.RawMessage `json:"value" gorm:"type:jsonb;not null" swaggertype:"object"`
+	ChangedAt time.Time       `json:"changed_at"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"index"`
+}
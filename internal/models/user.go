@@ -17,14 +17,17 @@ type User struct {
 }
 
 type Child struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Name      string         `json:"name" gorm:"not null"`
-	Age       int            `json:"age"`
-	ParentID  uint           `json:"parent_id"`
-	Parent    User           `json:"-" gorm:"foreignKey:ParentID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"not null"`
+	Age          int            `json:"age"`
+	ParentID     uint           `json:"parent_id"`
+	Parent       User           `json:"-" gorm:"foreignKey:ParentID"`
+	Streak       int            `json:"streak" gorm:"not null;default:0"` // дней подряд с пройденными уроками
+	BestStreak   int            `json:"best_streak" gorm:"not null;default:0"`
+	LastActiveOn *time.Time     `json:"last_active_on" gorm:"type:date"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 type Progress struct {
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// UserEvent — событие, отправленное в комнату семьи. Журнал ограничен
+// последними событиями пользователя и нужен, чтобы переподключившийся клиент
+// SSE получил пропущенное по Last-Event-ID
+type UserEvent struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	UserID    uint            `json:"user_id" gorm:"not null;index:idx_user_events_user,priority:1"`
+	Type      string          `json:"type" gorm:"not null"`
+	Payload   json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	CreatedAt time.Time       `json:"created_at"`
+}
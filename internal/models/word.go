@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultWordDifficulty — уровень слова, если редактор его не указал
+const DefaultWordDifficulty = "A1"
+
+// Word — слово из словаря уроков. Картинка и произношение хранятся как ID
+// медиафайлов: подписанные ссылки на них истекают и строятся при чтении.
+// Уникальны написание вместе с частью речи: run (verb) и run (noun) — разные слова
+type Word struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	Headword       string            `json:"headword" gorm:"not null;uniqueIndex:idx_words_headword_pos,priority:1"`
+	PartOfSpeech   string            `json:"part_of_speech" gorm:"size:16;not null;default:'';uniqueIndex:idx_words_headword_pos,priority:2"`
+	Difficulty     string            `json:"difficulty" gorm:"size:8;not null;default:'A1';index"` // уровень CEFR
+	Tags           StringList        `json:"tags" gorm:"type:jsonb;not null;default:'[]'"`         // темы: animals, food, ...
+	Examples       StringList        `json:"examples" gorm:"type:jsonb;not null;default:'[]'"`     // примеры предложений
+	ImageMediaID   *uint             `json:"image_media_id"`                                       // картинка
+	AudioUKMediaID *uint             `json:"audio_uk_media_id"`                                    // британское произношение
+	AudioUSMediaID *uint             `json:"audio_us_media_id"`                                    // американское произношение
+	Translations   []WordTranslation `json:"translations" gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// WordTranslation — перевод слова на родной язык ребёнка
+type WordTranslation struct {
+	ID       uint   `json:"-" gorm:"primaryKey"`
+	WordID   uint   `json:"-" gorm:"not null;uniqueIndex:idx_word_translations_lang,priority:1"`
+	Language string `json:"language" gorm:"not null;uniqueIndex:idx_word_translations_lang,priority:2"`
+	Text     string `json:"text" gorm:"not null"`
+}
+
+// Translation возвращает перевод на язык lang или пустую строку
+func (w *Word) Translation(lang string) string {
+	for _, t := range w.Translations {
+		if t.Language == lang {
+			return t.Text
+		}
+	}
+	return ""
+}
+
+// LessonWord — слово, которое изучается в уроке
+type LessonWord struct {
+	LessonID uint `gorm:"primaryKey"`
+	WordID   uint `gorm:"primaryKey;index"`
+	Word     Word `gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE"`
+}
+
+// StringList — список строк в колонке jsonb. Пустой список хранится и
+// отдаётся в JSON как [], а не null
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	raw, err := json.Marshal([]string(l))
+	return string(raw), err
+}
+
+func (l *StringList) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("scan StringList from %T", src)
+	}
+	return json.Unmarshal(raw, (*[]string)(l))
+}
+
+func (l StringList) MarshalJSON() ([]byte, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(l))
+}
@@ -0,0 +1,101 @@
+// Package realtime доставляет доменные события в открытые WebSocket-соединения
+// родителей. Сообщения упакованы в версионированный конверт Envelope и
+// рассылаются через websocket.Hub в комнату семьи или игры
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Version — версия формата сообщений. Меняется при несовместимых изменениях
+// конверта или payload; клиент проверяет её в каждом сообщении
+const Version = 1
+
+// Типы сообщений
+const (
+	// от клиента
+	TypeAuth            = "auth"
+	TypeGameSubscribe   = "game.subscribe"
+	TypeGameUnsubscribe = "game.unsubscribe"
+
+	// от сервера
+	TypeReady           = "ready"
+	TypeError           = "error"
+	TypeLessonCompleted = "lesson.completed"
+	TypeBadgeUnlocked   = "badge.unlocked"
+	TypeStreakAtRisk    = "streak.at_risk"
+	TypeGameState       = "game.state"
+	// TypeResync — пропущенных событий уже нет в журнале, клиенту нужно
+	// перезагрузить данные целиком
+	TypeResync = "resync"
+)
+
+// Envelope — сообщение WebSocket и SSE. Seq растёт на единицу в пределах комнаты
+// для открытого соединения; по пропуску клиент понимает, что часть сообщений
+// потеряна. Сообщения вне комнат (ready, error) идут без seq. ID — номер
+// события в журнале пользователя, есть только у событий семьи; по нему SSE
+// продолжает поток после переподключения
+type Envelope struct {
+	V       int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      uint            `json:"id,omitempty"`
+	Seq     uint64          `json:"seq,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// AuthPayload — первое сообщение клиента, если токен не передан в ?token=
+type AuthPayload struct {
+	Token string `json:"token"`
+}
+
+// GamePayload — подписка на комнату игры или отписка от неё
+type GamePayload struct {
+	Code string `json:"code"`
+}
+
+// ReadyPayload — ответ на успешную аутентификацию
+type ReadyPayload struct {
+	UserID uint     `json:"user_id"`
+	Rooms  []string `json:"rooms"`
+}
+
+// ErrorPayload — ошибка перед закрытием соединения
+type ErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Encode упаковывает payload в конверт
+func Encode(msgType string, seq uint64, payload any) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s payload: %w", msgType, err)
+	}
+	return json.Marshal(Envelope{V: Version, Type: msgType, Seq: seq, Payload: raw})
+}
+
+// Stamp проставляет номер seq в конверт; используется как websocket.Options.Prepare.
+// Сообщения не в формате Envelope отправляются как есть
+func Stamp(_ string, seq uint64, msg []byte) []byte {
+	var env Envelope
+	if err := json.Unmarshal(msg, &env); err != nil || env.V == 0 {
+		return msg
+	}
+	env.Seq = seq
+	stamped, err := json.Marshal(env)
+	if err != nil {
+		return msg
+	}
+	return stamped
+}
+
+// FamilyRoom — комната родителя и его детей
+func FamilyRoom(parentID uint) string {
+	return fmt.Sprintf("family:%d", parentID)
+}
+
+// GameRoom — комната викторины. Код игры служит и пропуском в комнату
+func GameRoom(code string) string {
+	return "game:" + code
+}
@@ -0,0 +1,179 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"engkids/pkg/logger"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	// maxNotifyPayload — предел payload NOTIFY в Postgres (8000 байт) с запасом
+	maxNotifyPayload = 7900
+	// refPrefix отмечает уведомление со ссылкой на realtime_messages вместо сообщения
+	refPrefix = "#"
+	// messageTTL — сколько хранить большие сообщения; получатели читают их сразу
+	messageTTL = 10 * time.Minute
+)
+
+// PostgresPubSub — websocket.PubSub поверх LISTEN/NOTIFY. Сообщение вместе с
+// комнатой передаётся в payload уведомления; если оно не помещается,
+// сохраняется в realtime_messages, а в уведомлении передаётся только ID
+type PostgresPubSub struct {
+	db      *sql.DB
+	channel string
+	// reconnect — пауза перед повторным LISTEN после обрыва соединения
+	reconnect time.Duration
+}
+
+// NewPostgresPubSub создаёт pub/sub на канале channel, например "engkids_ws"
+func NewPostgresPubSub(db *sql.DB, channel string) *PostgresPubSub {
+	return &PostgresPubSub{db: db, channel: channel, reconnect: time.Second}
+}
+
+func (p *PostgresPubSub) Publish(ctx context.Context, room string, msg []byte) error {
+	payload := frame(room, msg)
+	if len(payload) > maxNotifyPayload {
+		var id int64
+		err := p.db.QueryRowContext(ctx,
+			"INSERT INTO realtime_messages (room, payload, created_at) VALUES ($1, $2, now()) RETURNING id",
+			room, string(msg)).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("store realtime message: %w", err)
+		}
+		payload = refPrefix + strconv.FormatInt(id, 10)
+	}
+	if _, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", p.channel, payload); err != nil {
+		return fmt.Errorf("notify %s: %w", p.channel, err)
+	}
+	return nil
+}
+
+// Subscribe выполняет LISTEN на выделенном соединении. После обрыва подписка
+// восстанавливается; сообщения, отправленные за это время, теряются
+func (p *PostgresPubSub) Subscribe(ctx context.Context, deliver func(room string, msg []byte)) error {
+	conn, err := p.listen(ctx)
+	if err != nil {
+		return err
+	}
+
+	go p.prune(ctx)
+	go func() {
+		log := logger.FromContext(ctx).WithField("channel", p.channel)
+		for {
+			err := p.receive(ctx, conn, deliver)
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.WithError(err).Warn("LISTEN connection lost, resubscribing")
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(p.reconnect):
+				}
+				if conn, err = p.listen(ctx); err == nil {
+					break
+				}
+				log.WithError(err).Warn("Failed to resubscribe")
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *PostgresPubSub) listen(ctx context.Context) (*sql.Conn, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", p.channel, err)
+	}
+	// имя канала — идентификатор, а не параметр запроса
+	if _, err := conn.ExecContext(ctx, "LISTEN "+quoteIdent(p.channel)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("listen %s: %w", p.channel, err)
+	}
+	return conn, nil
+}
+
+// receive читает уведомления, пока соединение живо
+func (p *PostgresPubSub) receive(ctx context.Context, conn *sql.Conn, deliver func(room string, msg []byte)) error {
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("LISTEN requires the pgx driver")
+		}
+		for {
+			n, err := c.Conn().WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			room, msg, err := p.resolve(ctx, n.Payload)
+			if err != nil {
+				logger.FromContext(ctx).WithError(err).Error("Failed to read realtime message")
+				continue
+			}
+			deliver(room, msg)
+		}
+	})
+}
+
+// resolve разбирает payload уведомления, при необходимости читая сообщение по ID
+func (p *PostgresPubSub) resolve(ctx context.Context, payload string) (string, []byte, error) {
+	ref, isRef := strings.CutPrefix(payload, refPrefix)
+	if !isRef {
+		return unframe(payload)
+	}
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid message reference %q", payload)
+	}
+	var room, msg string
+	err = p.db.QueryRowContext(ctx, "SELECT room, payload FROM realtime_messages WHERE id = $1", id).Scan(&room, &msg)
+	if err != nil {
+		return "", nil, fmt.Errorf("fetch realtime message %d: %w", id, err)
+	}
+	return room, []byte(msg), nil
+}
+
+// prune удаляет большие сообщения, которые все экземпляры уже получили
+func (p *PostgresPubSub) prune(ctx context.Context) {
+	ticker := time.NewTicker(messageTTL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		_, err := p.db.ExecContext(ctx, "DELETE FROM realtime_messages WHERE created_at < $1", time.Now().Add(-messageTTL))
+		if err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).WithError(err).Warn("Failed to prune realtime messages")
+		}
+	}
+}
+
+// frame упаковывает комнату и сообщение в payload уведомления: "room\nmessage"
+func frame(room string, msg []byte) string {
+	return room + "\n" + string(msg)
+}
+
+func unframe(payload string) (string, []byte, error) {
+	room, msg, ok := strings.Cut(payload, "\n")
+	if !ok {
+		return "", nil, fmt.Errorf("malformed notification %q", payload)
+	}
+	return room, []byte(msg), nil
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,70 @@
+package realtime
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"engkids/config"
+	"engkids/pkg/database"
+)
+
+// TestPostgresPubSub выполняется только с настоящим Postgres (TEST_DB_HOST и
+// остальные переменные, как у apptest)
+func TestPostgresPubSub(t *testing.T) {
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+	db, err := database.ConnectDB(config.DB{
+		Host:     host,
+		Port:     config.GetEnv("TEST_DB_PORT", "5432"),
+		User:     config.GetEnv("TEST_DB_USER", "postgres"),
+		Password: config.GetEnv("TEST_DB_PASSWORD", ""),
+		Name:     config.GetEnv("TEST_DB_NAME", "engkids_test"),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.CloseDB(db, nil) })
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type message struct{ room, msg string }
+	publisher := NewPostgresPubSub(sqlDB, "engkids_test_ws")
+	var received []chan message
+	for range 2 {
+		ch := make(chan message, 10)
+		received = append(received, ch)
+		err := NewPostgresPubSub(sqlDB, "engkids_test_ws").Subscribe(t.Context(), func(room string, msg []byte) {
+			ch <- message{room, string(msg)}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// второе сообщение не помещается в NOTIFY и передаётся по ID
+	large := `"` + strings.Repeat("x", maxNotifyPayload) + `"`
+	for _, m := range []message{{"family:1", `{"small":true}`}, {"family:2", large}} {
+		if err := publisher.Publish(t.Context(), m.room, []byte(m.msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, ch := range received {
+		for _, want := range []message{{"family:1", `{"small":true}`}, {"family:2", large}} {
+			select {
+			case got := <-ch:
+				if got != want {
+					t.Errorf("subscriber %d: got %.40q in %s, want %.40q in %s", i, got.msg, got.room, want.msg, want.room)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("subscriber %d: no message", i)
+			}
+		}
+	}
+}
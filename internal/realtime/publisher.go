@@ -0,0 +1,98 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"engkids/internal/events"
+	"engkids/internal/game"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/websocket"
+)
+
+// DefaultEventLogSize — сколько последних событий пользователя хранится в журнале
+const DefaultEventLogSize = 100
+
+// Publisher отправляет сообщения в комнаты через хаб. Номера seq проставляет
+// хаб каждого экземпляра при рассылке (Stamp), поэтому они идут подряд для
+// клиента, к какому бы экземпляру он ни был подключён
+type Publisher struct {
+	hub     *websocket.Hub
+	log     repositories.UserEventRepository
+	logSize int
+}
+
+// NewPublisher создаёт издателя. События семьи записываются в log, где
+// хранятся logSize последних событий пользователя (0 — DefaultEventLogSize)
+func NewPublisher(hub *websocket.Hub, log repositories.UserEventRepository, logSize int) *Publisher {
+	if logSize <= 0 {
+		logSize = DefaultEventLogSize
+	}
+	return &Publisher{hub: hub, log: log, logSize: logSize}
+}
+
+// Publish отправляет сообщение msgType участникам комнаты room
+func (p *Publisher) Publish(ctx context.Context, room, msgType string, payload any) error {
+	msg, err := Encode(msgType, 0, payload)
+	if err != nil {
+		return err
+	}
+	return p.hub.Publish(ctx, room, msg)
+}
+
+// PublishToUser записывает событие в журнал пользователя и отправляет его в
+// комнату семьи с номером из журнала
+func (p *Publisher) PublishToUser(ctx context.Context, userID uint, msgType string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", msgType, err)
+	}
+	event := &models.UserEvent{UserID: userID, Type: msgType, Payload: raw}
+	if err := p.log.Append(ctx, event, p.logSize); err != nil {
+		return fmt.Errorf("append user event: %w", err)
+	}
+	msg, err := json.Marshal(EventEnvelope(*event))
+	if err != nil {
+		return err
+	}
+	return p.hub.Publish(ctx, FamilyRoom(userID), msg)
+}
+
+// EventEnvelope упаковывает событие из журнала в конверт
+func EventEnvelope(e models.UserEvent) Envelope {
+	return Envelope{V: Version, Type: e.Type, ID: e.ID, Payload: e.Payload}
+}
+
+// PublishGame рассылает состояние викторины в её комнату
+func (p *Publisher) PublishGame(ctx context.Context, state game.State) error {
+	return p.Publish(ctx, GameRoom(state.Code), TypeGameState, state)
+}
+
+// Subscribe пересылает события о детях в комнаты их семей. Доставка
+// WebSocket не гарантирована: если родитель не подключён, сообщение теряется
+func Subscribe(bus *events.Bus, children repositories.ChildRepository, p *Publisher) {
+	toFamily := func(ctx context.Context, childID uint, msgType string, payload any) error {
+		child, err := children.FindByID(ctx, childID)
+		if err != nil {
+			// ребёнка удалили, пока событие ждало доставки
+			if errors.Is(err, repositories.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		return p.PublishToUser(ctx, child.ParentID, msgType, payload)
+	}
+
+	events.Subscribe(bus, "realtime.lesson_completed", func(ctx context.Context, e events.LessonCompleted) error {
+		return toFamily(ctx, e.ChildID, TypeLessonCompleted, e)
+	})
+	events.Subscribe(bus, "realtime.badge_unlocked", func(ctx context.Context, e events.BadgeUnlocked) error {
+		return toFamily(ctx, e.ChildID, TypeBadgeUnlocked, e)
+	})
+	events.Subscribe(bus, "realtime.streak_at_risk", func(ctx context.Context, e events.StreakAtRisk) error {
+		return toFamily(ctx, e.ChildID, TypeStreakAtRisk, e)
+	})
+}
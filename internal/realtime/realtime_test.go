@@ -0,0 +1,65 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+
+	"engkids/internal/repositories/memory"
+	"engkids/pkg/websocket"
+)
+
+func TestStamp(t *testing.T) {
+	msg, err := Encode(TypeLessonCompleted, 0, map[string]int{"child_id": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(Stamp("family:1", 42, msg), &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.V != Version || env.Type != TypeLessonCompleted || env.Seq != 42 || string(env.Payload) != `{"child_id":7}` {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+
+	if got := Stamp("room", 1, []byte("plain text")); string(got) != "plain text" {
+		t.Errorf("non-envelope message changed: %q", got)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	room, msg, err := unframe(frame("family:1", []byte("{\"a\":\"line\\nbreak\"}")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room != "family:1" || string(msg) != "{\"a\":\"line\\nbreak\"}" {
+		t.Errorf("got %q %q", room, msg)
+	}
+	if _, _, err := unframe("no separator"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestPublishToUserKeepsBoundedLog(t *testing.T) {
+	repos := memory.New()
+	p := NewPublisher(websocket.NewHub(websocket.Options{}), repos.UserEvents, 2)
+	for i := range 3 {
+		if err := p.PublishToUser(t.Context(), 1, TypeStreakAtRisk, map[string]int{"streak": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.PublishToUser(t.Context(), 2, TypeStreakAtRisk, map[string]int{"streak": 9}); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := repos.UserEvents.ListAfter(t.Context(), 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 || log[0].ID != 2 || string(log[1].Payload) != `{"streak":2}` {
+		t.Fatalf("log: %+v", log)
+	}
+	if ok, _ := repos.UserEvents.Exists(t.Context(), 1, 1); ok {
+		t.Error("oldest event was not evicted")
+	}
+}
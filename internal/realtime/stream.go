@@ -0,0 +1,104 @@
+package realtime
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	ws "github.com/fasthttp/websocket"
+)
+
+// ErrStreamClosed — поток закрыт клиентом или сервером
+var ErrStreamClosed = errors.New("stream closed")
+
+// Frame — сообщение для отправки в поток; Ping — keepalive без данных
+type Frame struct {
+	Msg  []byte
+	Ping bool
+}
+
+// Stream — поток SSE в роли websocket.Conn: хаб пишет в него как в обычное
+// соединение (с теми же комнатами, seq и отключением медленных клиентов), а
+// HTTP-обработчик забирает кадры из Frames и пишет их в ответ
+type Stream struct {
+	frames chan Frame
+	done   chan struct{}
+	once   sync.Once
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func NewStream() *Stream {
+	return &Stream{frames: make(chan Frame), done: make(chan struct{})}
+}
+
+// Frames — кадры в порядке отправки хабом
+func (s *Stream) Frames() <-chan Frame { return s.frames }
+
+// Done закрывается вместе с потоком
+func (s *Stream) Done() <-chan struct{} { return s.done }
+
+// ReadMessage блокируется до закрытия: клиент SSE ничего не присылает
+func (s *Stream) ReadMessage() (int, []byte, error) {
+	<-s.done
+	return 0, nil, io.EOF
+}
+
+// WriteMessage ждёт не дольше дедлайна из SetWriteDeadline. Дедлайн
+// действует на один кадр: хаб ставит новый перед каждой записью, и
+// просроченный не должен достаться следующему кадру
+func (s *Stream) WriteMessage(_ int, data []byte) error {
+	s.mu.Lock()
+	deadline := s.deadline
+	s.deadline = time.Time{}
+	s.mu.Unlock()
+	return s.send(Frame{Msg: data}, deadline)
+}
+
+// WriteControl превращает ping в keepalive, а close — в закрытие потока
+func (s *Stream) WriteControl(messageType int, _ []byte, deadline time.Time) error {
+	switch messageType {
+	case ws.PingMessage:
+		return s.send(Frame{Ping: true}, deadline)
+	case ws.CloseMessage:
+		return s.Close()
+	}
+	return nil
+}
+
+// send ждёт, пока обработчик заберёт кадр, не дольше дедлайна записи
+func (s *Stream) send(f Frame, deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case s.frames <- f:
+		return nil
+	case <-s.done:
+		return ErrStreamClosed
+	case <-timeout:
+		return ErrStreamClosed
+	}
+}
+
+func (s *Stream) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.deadline = t
+	s.mu.Unlock()
+	return nil
+}
+
+// Чтения нет, поэтому лимиты и дедлайны чтения не нужны
+func (s *Stream) SetReadLimit(int64)                {}
+func (s *Stream) SetReadDeadline(time.Time) error   { return nil }
+func (s *Stream) SetPongHandler(func(string) error) {}
+
+func (s *Stream) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return nil
+}
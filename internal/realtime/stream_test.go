@@ -0,0 +1,52 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	ws "github.com/fasthttp/websocket"
+)
+
+// Дедлайн последнего события не должен достаться keepalive после простоя,
+// иначе ping через раз проигрывает гонку таймеру и хаб отключает клиента
+func TestStreamPingAfterIdle(t *testing.T) {
+	s := NewStream()
+	defer s.Close()
+	go func() {
+		for {
+			select {
+			case <-s.Frames():
+			case <-s.Done():
+				return
+			}
+		}
+	}()
+
+	s.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+	if err := s.WriteMessage(ws.TextMessage, []byte("event")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	for i := range 100 {
+		if err := s.WriteControl(ws.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("ping %d: %v", i, err)
+		}
+	}
+	if err := s.WriteMessage(ws.TextMessage, []byte("event")); err != nil {
+		t.Fatalf("event after idle: %v", err)
+	}
+}
+
+func TestStreamWriteTimesOut(t *testing.T) {
+	s := NewStream()
+	defer s.Close()
+
+	if err := s.WriteControl(ws.PingMessage, nil, time.Now().Add(10*time.Millisecond)); err != ErrStreamClosed {
+		t.Fatalf("ping without a reader: %v", err)
+	}
+	s.SetWriteDeadline(time.Now().Add(10 * time.Millisecond))
+	if err := s.WriteMessage(ws.TextMessage, []byte("event")); err != ErrStreamClosed {
+		t.Fatalf("event without a reader: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type childRepository struct {
+	db *gorm.DB
+}
+
+func NewChildRepository(db *gorm.DB) ChildRepository {
+	return &childRepository{db: db}
+}
+
+func (r *childRepository) Create(ctx context.Context, child *models.Child) error {
+	return translate(r.db.WithContext(ctx).Create(child).Error)
+}
+
+func (r *childRepository) FindByID(ctx context.Context, id uint) (*models.Child, error) {
+	var child models.Child
+	if err := r.db.WithContext(ctx).First(&child, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &child, nil
+}
+
+func (r *childRepository) ListByParent(ctx context.Context, parentID uint) ([]models.Child, error) {
+	var children []models.Child
+	err := r.db.WithContext(ctx).Where("parent_id = ?", parentID).Order("id").Find(&children).Error
+	return children, translate(err)
+}
+
+func (r *childRepository) CountByParent(ctx context.Context, parentID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&models.Child{}).Where("parent_id = ?", parentID).Count(&n).Error
+	return n, translate(err)
+}
+
+func (r *childRepository) Update(ctx context.Context, child *models.Child) error {
+	result := r.db.WithContext(ctx).Model(child).Select("name", "age").Updates(child)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *childRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.Child{}, id)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted удаляет вместе с профилями их прогресс, повторения слов,
+// настройки и места в итогах игр
+func (r *childRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		children := tx.Unscoped().Model(&models.Child{}).Select("id").Where("deleted_at < ?", before)
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.Progress{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.WordReview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.ChildSetting{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.GamePlayer{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.Child{})
+		n = result.RowsAffected
+		return result.Error
+	})
+	return n, translate(err)
+}
+
+func (r *childRepository) ResetStreaks(ctx context.Context, activeSince time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Child{}).
+		Where("streak > 0 AND (last_active_on IS NULL OR last_active_on < ?)", activeSince.Format(time.DateOnly)).
+		Update("streak", 0)
+	return result.RowsAffected, translate(result.Error)
+}
+
+func (r *childRepository) ListStreaksAtRisk(ctx context.Context, today time.Time) ([]models.Child, error) {
+	var children []models.Child
+	err := r.db.WithContext(ctx).
+		Where("streak > 0 AND last_active_on < ?", today.Format(time.DateOnly)).
+		Order("id").Find(&children).Error
+	return children, translate(err)
+}
+
+func (r *childRepository) UpdateStreak(ctx context.Context, child *models.Child) error {
+	result := r.db.WithContext(ctx).Model(child).
+		Select("streak", "best_streak", "last_active_on", "updated_at").Updates(child)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type gameResultRepository struct {
+	db *gorm.DB
+}
+
+func NewGameResultRepository(db *gorm.DB) GameResultRepository {
+	return &gameResultRepository{db: db}
+}
+
+func (r *gameResultRepository) Create(ctx context.Context, result *models.GameResult) error {
+	return translate(r.db.WithContext(ctx).Create(result).Error)
+}
+
+func (r *gameResultRepository) ListByChild(ctx context.Context, childID uint, limit int) ([]models.GameResult, error) {
+	var results []models.GameResult
+	err := r.db.WithContext(ctx).
+		Preload("Players", func(db *gorm.DB) *gorm.DB { return db.Order("rank, id") }).
+		Where("id IN (?)", r.db.Model(&models.GamePlayer{}).Select("game_id").Where("child_id = ?", childID)).
+		Order("id DESC").Limit(limit).Find(&results).Error
+	return results, translate(err)
+}
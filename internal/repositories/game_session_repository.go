@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type gameSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewGameSessionRepository(db *gorm.DB) GameSessionRepository {
+	return &gameSessionRepository{db: db}
+}
+
+func (r *gameSessionRepository) Create(ctx context.Context, session *models.GameSession) error {
+	return translate(r.db.WithContext(ctx).Create(session).Error)
+}
+
+func (r *gameSessionRepository) FindByCode(ctx context.Context, code string) (*models.GameSession, error) {
+	var session models.GameSession
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&session).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+func (r *gameSessionRepository) Update(ctx context.Context, session *models.GameSession) error {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.GameSession{}).
+		Where("code = ? AND version = ?", session.Code, session.Version).
+		Updates(map[string]any{
+			"state":      session.State,
+			"phase":      session.Phase,
+			"deadline":   session.Deadline,
+			"version":    session.Version + 1,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(ctx, session.Code)
+	}
+	session.Version++
+	session.UpdatedAt = now
+	return nil
+}
+
+func (r *gameSessionRepository) Delete(ctx context.Context, session *models.GameSession) error {
+	result := r.db.WithContext(ctx).
+		Where("code = ? AND version = ?", session.Code, session.Version).
+		Delete(&models.GameSession{})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(ctx, session.Code)
+	}
+	return nil
+}
+
+func (r *gameSessionRepository) missingOrConflict(ctx context.Context, code string) error {
+	if _, err := r.FindByCode(ctx, code); err != nil {
+		return err
+	}
+	return ErrConflict
+}
+
+func (r *gameSessionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.GameSession, error) {
+	var sessions []models.GameSession
+	err := r.db.WithContext(ctx).
+		Where("deadline <= ?", now).
+		Order("deadline").Limit(limit).
+		Find(&sessions).Error
+	return sessions, translate(err)
+}
+
+func (r *gameSessionRepository) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&models.GameSession{}).Count(&n).Error
+	return n, translate(err)
+}
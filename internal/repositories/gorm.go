@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// pgUniqueViolation — код ошибки Postgres при нарушении уникального индекса
+const pgUniqueViolation = "23505"
+
+// NewGorm создаёт хранилища поверх GORM
+func NewGorm(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Users:         NewUserRepository(db),
+		RefreshTokens: NewRefreshTokenRepository(db),
+		Children:      NewChildRepository(db),
+		Progress:      NewProgressRepository(db),
+		Outbox:        NewOutboxRepository(db),
+		Jobs:          NewJobRepository(db),
+		ScheduledRuns: NewScheduledRunRepository(db),
+		Words:         NewWordRepository(db),
+		GameSessions:  NewGameSessionRepository(db),
+		GameResults:   NewGameResultRepository(db),
+		UserEvents:    NewUserEventRepository(db),
+		Reviews:       NewReviewRepository(db),
+		Settings:      NewSettingRepository(db),
+		Sync:          NewSyncRepository(db),
+		Idempotency:   NewIdempotencyRepository(db),
+		Media:         NewMediaRepository(db),
+		Tx:            gormTransactor{db: db},
+	}
+}
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func (t gormTransactor) Transaction(ctx context.Context, fn func(repos *Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewGorm(tx))
+	})
+}
+
+// translate переводит ошибки GORM и драйвера в ошибки пакета
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.Is(err, gorm.ErrDuplicatedKey) || (errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation) {
+		return errors.Join(ErrDuplicate, err)
+	}
+	return err
+}
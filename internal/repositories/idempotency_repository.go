@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyKey, now time.Time) error {
+	db := r.db.WithContext(ctx)
+	err := db.Where("user_id = ? AND key = ? AND expires_at <= ?", record.UserID, record.Key, now).
+		Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		return translate(err)
+	}
+	return translate(db.Create(record).Error)
+}
+
+func (r *idempotencyRepository) Find(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyKey) error {
+	result := r.db.WithContext(ctx).Model(record).Select("status", "content_type", "body", "expires_at").Updates(record)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, id uint) error {
+	return translate(r.db.WithContext(ctx).Delete(&models.IdempotencyKey{}, id).Error)
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, translate(result.Error)
+}
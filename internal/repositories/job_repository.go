@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	return translate(r.db.WithContext(ctx).Create(job).Error)
+}
+
+func (r *jobRepository) Claim(ctx context.Context, queue, workerID string, now time.Time, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE jobs
+		SET status = ?, attempts = attempts + 1, locked_by = ?, locked_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE queue = ? AND status = ? AND run_at <= ?
+			ORDER BY run_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.JobRunning, workerID, now, now,
+		queue, models.JobQueued, now, limit,
+	).Scan(&jobs).Error
+	return jobs, translate(err)
+}
+
+func (r *jobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (requeued, dead int64, err error) {
+	now := time.Now()
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stale := func() *gorm.DB {
+			return tx.Model(&models.Job{}).Where("status = ? AND locked_at < ?", models.JobRunning, lockedBefore)
+		}
+		result := stale().Where("attempts >= max_attempts").
+			Updates(map[string]any{
+				"status":      models.JobDead,
+				"locked_by":   "",
+				"locked_at":   nil,
+				"finished_at": now,
+				"last_error":  StaleJobError,
+				"updated_at":  now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		dead = result.RowsAffected
+
+		result = stale().
+			Updates(map[string]any{
+				"status":     models.JobQueued,
+				"locked_by":  "",
+				"locked_at":  nil,
+				"run_at":     now,
+				"updated_at": now,
+			})
+		requeued = result.RowsAffected
+		return result.Error
+	})
+	return requeued, dead, translate(err)
+}
+
+func (r *jobRepository) Transition(ctx context.Context, job *models.Job, from ...string) error {
+	result := r.db.WithContext(ctx).Model(job).
+		Where("status IN ?", from).
+		Select("status", "run_at", "attempts", "last_error", "locked_by", "locked_at", "finished_at").
+		Updates(job)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByID(ctx, job.ID); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *jobRepository) FindByID(ctx context.Context, id uint) (*models.Job, error) {
+	var job models.Job
+	if err := r.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) List(ctx context.Context, filter JobFilter) ([]models.Job, error) {
+	q := r.db.WithContext(ctx).Model(&models.Job{})
+	if filter.Queue != "" {
+		q = q.Where("queue = ?", filter.Queue)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		q = q.Where("type = ?", filter.Type)
+	}
+	var jobs []models.Job
+	err := q.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&jobs).Error
+	return jobs, translate(err)
+}
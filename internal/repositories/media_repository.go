@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type mediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) MediaRepository {
+	return &mediaRepository{db: db}
+}
+
+func (r *mediaRepository) Create(ctx context.Context, asset *models.MediaAsset) error {
+	return translate(r.db.WithContext(ctx).Create(asset).Error)
+}
+
+func (r *mediaRepository) FindByID(ctx context.Context, id uint) (*models.MediaAsset, error) {
+	var asset models.MediaAsset
+	if err := r.db.WithContext(ctx).First(&asset, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &asset, nil
+}
+
+func (r *mediaRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.MediaAsset, error) {
+	var assets []models.MediaAsset
+	if len(ids) == 0 {
+		return assets, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&assets).Error
+	return assets, translate(err)
+}
+
+func (r *mediaRepository) FindByHash(ctx context.Context, hash string) (*models.MediaAsset, error) {
+	var asset models.MediaAsset
+	if err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&asset).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &asset, nil
+}
@@ -0,0 +1,1265 @@
+// Package memory — хранилища в памяти для тестов сервисов без Postgres.
+// Повторяют ограничения схемы: уникальный email, один refresh-токен на
+// пользователя, мягкое удаление пользователей и детей
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// New создаёт набор хранилищ с общим состоянием
+func New() *repositories.Repositories {
+	s := &store{
+		nextID:   map[string]uint{},
+		users:    map[uint]models.User{},
+		tokens:   map[uint]models.RefreshToken{},
+		children: map[uint]models.Child{},
+		progress: map[uint]models.Progress{},
+		outbox:   map[uint]models.OutboxEvent{},
+		jobs:     map[uint]models.Job{},
+		runs:     map[uint]models.ScheduledRun{},
+		words:    map[uint]models.Word{},
+		lessons:  map[lessonWord]bool{},
+		sessions: map[string]models.GameSession{},
+		games:    map[uint]models.GameResult{},
+		events:   map[uint]models.UserEvent{},
+		reviews:  map[uint]models.WordReview{},
+		settings: map[uint]models.ChildSetting{},
+		syncOps:  map[uint]models.SyncOperation{},
+		idemKeys: map[uint]models.IdempotencyKey{},
+		media:    map[uint]models.MediaAsset{},
+	}
+	s.repos = &repositories.Repositories{
+		Users:         (*userRepository)(s),
+		RefreshTokens: (*refreshTokenRepository)(s),
+		Children:      (*childRepository)(s),
+		Progress:      (*progressRepository)(s),
+		Outbox:        (*outboxRepository)(s),
+		Jobs:          (*jobRepository)(s),
+		ScheduledRuns: (*scheduledRunRepository)(s),
+		Words:         (*wordRepository)(s),
+		GameSessions:  (*gameSessionRepository)(s),
+		GameResults:   (*gameResultRepository)(s),
+		UserEvents:    (*userEventRepository)(s),
+		Reviews:       (*reviewRepository)(s),
+		Settings:      (*settingRepository)(s),
+		Sync:          (*syncRepository)(s),
+		Idempotency:   (*idempotencyRepository)(s),
+		Media:         (*mediaRepository)(s),
+		Tx:            (*transactor)(s),
+	}
+	return s.repos
+}
+
+type store struct {
+	mu     sync.Mutex
+	nextID map[string]uint // последовательности по таблицам, как в Postgres
+
+	users    map[uint]models.User
+	tokens   map[uint]models.RefreshToken // по UserID
+	children map[uint]models.Child
+	progress map[uint]models.Progress
+	outbox   map[uint]models.OutboxEvent
+	jobs     map[uint]models.Job
+	runs     map[uint]models.ScheduledRun
+	words    map[uint]models.Word
+	lessons  map[lessonWord]bool
+	sessions map[string]models.GameSession // по коду игры
+	games    map[uint]models.GameResult
+	events   map[uint]models.UserEvent
+	reviews  map[uint]models.WordReview
+	settings map[uint]models.ChildSetting
+	syncOps  map[uint]models.SyncOperation
+	idemKeys map[uint]models.IdempotencyKey
+	media    map[uint]models.MediaAsset
+
+	repos *repositories.Repositories
+}
+
+func (s *store) id(table string) uint {
+	s.nextID[table]++
+	return s.nextID[table]
+}
+
+func sortByID[T any](items []T, id func(T) uint) {
+	sort.Slice(items, func(i, j int) bool { return id(items[i]) < id(items[j]) })
+}
+
+type userRepository store
+
+func (r *userRepository) Create(_ context.Context, user *models.User) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return repositories.ErrDuplicate
+		}
+	}
+	now := time.Now()
+	user.ID, user.CreatedAt, user.UpdatedAt = s.id("users"), now, now
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (r *userRepository) FindByID(_ context.Context, id uint) (*models.User, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil, repositories.ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r *userRepository) FindByEmail(_ context.Context, email string) (*models.User, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == email && !u.DeletedAt.Valid {
+			return &u, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *userRepository) List(_ context.Context) ([]models.User, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]models.User, 0, len(s.users))
+	for _, u := range s.users {
+		if !u.DeletedAt.Valid {
+			users = append(users, u)
+		}
+	}
+	sortByID(users, func(u models.User) uint { return u.ID })
+	return users, nil
+}
+
+// PurgeDeleted удаляет вместе с пользователями их детей со всеми данными,
+// refresh-токены, журнал событий, принятые операции синхронизации и
+// сохранённые ответы на запросы с Idempotency-Key
+func (r *userRepository) PurgeDeleted(_ context.Context, before time.Time) (int64, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, u := range s.users {
+		if !deletedBefore(u.DeletedAt, before) {
+			continue
+		}
+		for childID, c := range s.children {
+			if c.ParentID == id {
+				s.purgeChild(childID)
+			}
+		}
+		for eventID, e := range s.events {
+			if e.UserID == id {
+				delete(s.events, eventID)
+			}
+		}
+		for opID, op := range s.syncOps {
+			if op.UserID == id {
+				delete(s.syncOps, opID)
+			}
+		}
+		for keyID, k := range s.idemKeys {
+			if k.UserID == id {
+				delete(s.idemKeys, keyID)
+			}
+		}
+		delete(s.tokens, id)
+		delete(s.users, id)
+		n++
+	}
+	return n, nil
+}
+
+func deletedBefore(deletedAt gorm.DeletedAt, before time.Time) bool {
+	return deletedAt.Valid && deletedAt.Time.Before(before)
+}
+
+type refreshTokenRepository store
+
+func (r *refreshTokenRepository) Save(_ context.Context, token *models.RefreshToken) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userID, t := range s.tokens {
+		if t.Token == token.Token && userID != token.UserID {
+			return repositories.ErrDuplicate
+		}
+	}
+	if old, ok := s.tokens[token.UserID]; ok {
+		token.ID = old.ID
+	} else {
+		token.ID = s.id("refresh_tokens")
+	}
+	s.tokens[token.UserID] = *token
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByToken(_ context.Context, token string) (*models.RefreshToken, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tokens {
+		if t.Token == token {
+			return &t, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *refreshTokenRepository) DeleteByToken(_ context.Context, token string) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userID, t := range s.tokens {
+		if t.Token == token {
+			delete(s.tokens, userID)
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *refreshTokenRepository) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for userID, t := range s.tokens {
+		if t.ExpiresAt.Before(before) {
+			delete(s.tokens, userID)
+			n++
+		}
+	}
+	return n, nil
+}
+
+type childRepository store
+
+func (r *childRepository) Create(_ context.Context, child *models.Child) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	child.ID, child.CreatedAt, child.UpdatedAt = s.id("children"), now, now
+	s.children[child.ID] = *child
+	return nil
+}
+
+func (r *childRepository) FindByID(_ context.Context, id uint) (*models.Child, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.children[id]
+	if !ok || c.DeletedAt.Valid {
+		return nil, repositories.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *childRepository) ListByParent(_ context.Context, parentID uint) ([]models.Child, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var children []models.Child
+	for _, c := range s.children {
+		if c.ParentID == parentID && !c.DeletedAt.Valid {
+			children = append(children, c)
+		}
+	}
+	sortByID(children, func(c models.Child) uint { return c.ID })
+	return children, nil
+}
+
+func (r *childRepository) CountByParent(ctx context.Context, parentID uint) (int64, error) {
+	children, err := r.ListByParent(ctx, parentID)
+	return int64(len(children)), err
+}
+
+func (r *childRepository) Update(_ context.Context, child *models.Child) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.children[child.ID]
+	if !ok || c.DeletedAt.Valid {
+		return repositories.ErrNotFound
+	}
+	c.Name, c.Age, c.UpdatedAt = child.Name, child.Age, time.Now()
+	s.children[c.ID] = c
+	*child = c
+	return nil
+}
+
+func (r *childRepository) Delete(_ context.Context, id uint) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.children[id]
+	if !ok || c.DeletedAt.Valid {
+		return repositories.ErrNotFound
+	}
+	c.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	s.children[id] = c
+	return nil
+}
+
+// PurgeDeleted удаляет вместе с профилями их прогресс, повторения и настройки
+func (r *childRepository) PurgeDeleted(_ context.Context, before time.Time) (int64, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, c := range s.children {
+		if deletedBefore(c.DeletedAt, before) {
+			s.purgeChild(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *childRepository) ResetStreaks(_ context.Context, activeSince time.Time) (int64, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	since := activeSince.Format(time.DateOnly)
+	var n int64
+	for id, c := range s.children {
+		if c.Streak > 0 && (c.LastActiveOn == nil || c.LastActiveOn.Format(time.DateOnly) < since) {
+			c.Streak, c.UpdatedAt = 0, time.Now()
+			s.children[id] = c
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *childRepository) ListStreaksAtRisk(_ context.Context, today time.Time) ([]models.Child, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day := today.Format(time.DateOnly)
+	var children []models.Child
+	for _, c := range s.children {
+		if !c.DeletedAt.Valid && c.Streak > 0 && c.LastActiveOn != nil && c.LastActiveOn.Format(time.DateOnly) < day {
+			children = append(children, c)
+		}
+	}
+	sortByID(children, func(c models.Child) uint { return c.ID })
+	return children, nil
+}
+
+func (r *childRepository) UpdateStreak(_ context.Context, child *models.Child) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.children[child.ID]
+	if !ok || c.DeletedAt.Valid {
+		return repositories.ErrNotFound
+	}
+	c.Streak, c.BestStreak, c.LastActiveOn, c.UpdatedAt = child.Streak, child.BestStreak, child.LastActiveOn, time.Now()
+	s.children[c.ID] = c
+	*child = c
+	return nil
+}
+
+// purgeChild удаляет профиль, его прогресс, повторения, настройки и места в
+// итогах игр; вызывается под s.mu
+func (s *store) purgeChild(id uint) {
+	for progressID, p := range s.progress {
+		if p.ChildID == id {
+			delete(s.progress, progressID)
+		}
+	}
+	for reviewID, r := range s.reviews {
+		if r.ChildID == id {
+			delete(s.reviews, reviewID)
+		}
+	}
+	for settingID, st := range s.settings {
+		if st.ChildID == id {
+			delete(s.settings, settingID)
+		}
+	}
+	for gameID, g := range s.games {
+		g.Players = slices.DeleteFunc(slices.Clone(g.Players), func(p models.GamePlayer) bool { return p.ChildID == id })
+		s.games[gameID] = g
+	}
+	delete(s.children, id)
+}
+
+type progressRepository store
+
+func (r *progressRepository) Save(_ context.Context, progress *models.Progress) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if progress.ID == 0 {
+		progress.ID, progress.CreatedAt = s.id("progresses"), now
+	}
+	progress.UpdatedAt = now
+	s.progress[progress.ID] = *progress
+	return nil
+}
+
+func (r *progressRepository) FindByChildAndLesson(_ context.Context, childID, lessonID uint) (*models.Progress, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.progress {
+		if p.ChildID == childID && p.LessonID == lessonID {
+			return &p, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *progressRepository) ListByChild(_ context.Context, childID uint) ([]models.Progress, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []models.Progress
+	for _, p := range s.progress {
+		if p.ChildID == childID {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LessonID < list[j].LessonID })
+	return list, nil
+}
+
+func (r *progressRepository) ListChanged(_ context.Context, childIDs []uint, since time.Time) ([]models.Progress, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return changed(s.progress, childIDs, since, func(p models.Progress) (uint, uint, time.Time) {
+		return p.ID, p.ChildID, p.UpdatedAt
+	}), nil
+}
+
+// changed выбирает записи детей childIDs, изменённые позже since, по порядку ID
+func changed[T any](items map[uint]T, childIDs []uint, since time.Time, key func(T) (id, childID uint, updatedAt time.Time)) []T {
+	list := []T{}
+	for _, item := range items {
+		_, childID, updatedAt := key(item)
+		if slices.Contains(childIDs, childID) && updatedAt.After(since) {
+			list = append(list, item)
+		}
+	}
+	sortByID(list, func(item T) uint {
+		id, _, _ := key(item)
+		return id
+	})
+	return list
+}
+
+// transactor не изолирует параллельные транзакции, но откатывает изменения,
+// если fn вернула ошибку
+type transactor store
+
+func (t *transactor) Transaction(_ context.Context, fn func(repos *repositories.Repositories) error) error {
+	s := (*store)(t)
+	s.mu.Lock()
+	snapshot := store{
+		nextID:   maps.Clone(s.nextID),
+		users:    maps.Clone(s.users),
+		tokens:   maps.Clone(s.tokens),
+		children: maps.Clone(s.children),
+		progress: maps.Clone(s.progress),
+		outbox:   maps.Clone(s.outbox),
+		jobs:     maps.Clone(s.jobs),
+		runs:     maps.Clone(s.runs),
+		words:    maps.Clone(s.words),
+		lessons:  maps.Clone(s.lessons),
+		sessions: maps.Clone(s.sessions),
+		games:    maps.Clone(s.games),
+		events:   maps.Clone(s.events),
+		reviews:  maps.Clone(s.reviews),
+		settings: maps.Clone(s.settings),
+		syncOps:  maps.Clone(s.syncOps),
+		idemKeys: maps.Clone(s.idemKeys),
+		media:    maps.Clone(s.media),
+	}
+	s.mu.Unlock()
+
+	err := fn(s.repos)
+	if err != nil {
+		s.mu.Lock()
+		s.nextID = snapshot.nextID
+		s.users, s.tokens, s.children = snapshot.users, snapshot.tokens, snapshot.children
+		s.progress, s.outbox, s.jobs = snapshot.progress, snapshot.outbox, snapshot.jobs
+		s.runs, s.words, s.lessons, s.sessions, s.games = snapshot.runs, snapshot.words, snapshot.lessons, snapshot.sessions, snapshot.games
+		s.events, s.reviews, s.settings, s.syncOps = snapshot.events, snapshot.reviews, snapshot.settings, snapshot.syncOps
+		s.idemKeys, s.media = snapshot.idemKeys, snapshot.media
+		s.mu.Unlock()
+	}
+	return err
+}
+
+type outboxRepository store
+
+func (r *outboxRepository) Add(_ context.Context, event *models.OutboxEvent) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if event.Status == "" {
+		event.Status = models.OutboxPending
+	}
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = now
+	}
+	event.ID, event.CreatedAt = s.id("outbox_events"), now
+	s.outbox[event.ID] = *event
+	return nil
+}
+
+func (r *outboxRepository) ClaimDue(_ context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.OutboxEvent
+	for _, e := range s.outbox {
+		if e.Status == models.OutboxPending && !e.NextAttemptAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	sortByID(due, func(e models.OutboxEvent) uint { return e.ID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].NextAttemptAt = now.Add(lease)
+		s.outbox[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+func (r *outboxRepository) Update(_ context.Context, event *models.OutboxEvent) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.outbox[event.ID]; !ok {
+		return repositories.ErrNotFound
+	}
+	s.outbox[event.ID] = *event
+	return nil
+}
+
+type jobRepository store
+
+func (r *jobRepository) Enqueue(_ context.Context, job *models.Job) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	job.ID, job.CreatedAt, job.UpdatedAt = s.id("jobs"), now, now
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *jobRepository) Claim(_ context.Context, queue, workerID string, now time.Time, limit int) ([]models.Job, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.Job
+	for _, j := range s.jobs {
+		if j.Queue == queue && j.Status == models.JobQueued && !j.RunAt.After(now) {
+			due = append(due, j)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].RunAt.Equal(due[j].RunAt) {
+			return due[i].RunAt.Before(due[j].RunAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		lockedAt := now
+		due[i].Status, due[i].LockedBy, due[i].LockedAt = models.JobRunning, workerID, &lockedAt
+		due[i].Attempts++
+		due[i].UpdatedAt = now
+		s.jobs[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+func (r *jobRepository) RequeueStale(_ context.Context, lockedBefore time.Time) (requeued, dead int64, err error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, j := range s.jobs {
+		if j.Status != models.JobRunning || j.LockedAt == nil || !j.LockedAt.Before(lockedBefore) {
+			continue
+		}
+		j.LockedBy, j.LockedAt, j.UpdatedAt = "", nil, now
+		if j.Attempts >= j.MaxAttempts {
+			j.Status, j.FinishedAt, j.LastError = models.JobDead, &now, repositories.StaleJobError
+			dead++
+		} else {
+			j.Status, j.RunAt = models.JobQueued, now
+			requeued++
+		}
+		s.jobs[id] = j
+	}
+	return requeued, dead, nil
+}
+
+func (r *jobRepository) Transition(_ context.Context, job *models.Job, from ...string) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.jobs[job.ID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	if !slices.Contains(from, current.Status) {
+		return repositories.ErrConflict
+	}
+	job.UpdatedAt = time.Now()
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *jobRepository) FindByID(_ context.Context, id uint) (*models.Job, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return &j, nil
+}
+
+func (r *jobRepository) List(_ context.Context, filter repositories.JobFilter) ([]models.Job, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []models.Job
+	for _, j := range s.jobs {
+		if (filter.Queue == "" || j.Queue == filter.Queue) &&
+			(filter.Status == "" || j.Status == filter.Status) &&
+			(filter.Type == "" || j.Type == filter.Type) {
+			list = append(list, j)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if filter.Offset >= len(list) {
+		return nil, nil
+	}
+	list = list[filter.Offset:]
+	if filter.Limit > 0 && len(list) > filter.Limit {
+		list = list[:filter.Limit]
+	}
+	return list, nil
+}
+
+type scheduledRunRepository store
+
+func (r *scheduledRunRepository) Create(_ context.Context, run *models.ScheduledRun) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.ID = s.id("scheduled_runs")
+	s.runs[run.ID] = *run
+	return nil
+}
+
+func (r *scheduledRunRepository) Update(_ context.Context, run *models.ScheduledRun) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.runs[run.ID]; !ok {
+		return repositories.ErrNotFound
+	}
+	s.runs[run.ID] = *run
+	return nil
+}
+
+func (r *scheduledRunRepository) List(_ context.Context, task string, limit int) ([]models.ScheduledRun, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []models.ScheduledRun
+	for _, run := range s.runs {
+		if task == "" || run.Task == task {
+			list = append(list, run)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+type lessonWord struct{ lessonID, wordID uint }
+
+type wordRepository store
+
+func (r *wordRepository) Create(_ context.Context, word *models.Word) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.headwordTaken(word) {
+		return repositories.ErrDuplicate
+	}
+	now := time.Now()
+	word.ID, word.CreatedAt, word.UpdatedAt = s.id("words"), now, now
+	if word.Difficulty == "" {
+		word.Difficulty = models.DefaultWordDifficulty
+	}
+	for i := range word.Translations {
+		word.Translations[i].ID, word.Translations[i].WordID = s.id("word_translations"), word.ID
+	}
+	s.words[word.ID] = cloneWord(*word)
+	return nil
+}
+
+func (r *wordRepository) Update(_ context.Context, word *models.Word) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.words[word.ID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	if s.headwordTaken(word) {
+		return repositories.ErrDuplicate
+	}
+	word.CreatedAt, word.UpdatedAt = existing.CreatedAt, time.Now()
+	for i := range word.Translations {
+		word.Translations[i].ID, word.Translations[i].WordID = s.id("word_translations"), word.ID
+	}
+	s.words[word.ID] = cloneWord(*word)
+	return nil
+}
+
+// headwordTaken — слово с тем же написанием и частью речи уже есть в словаре;
+// вызывается под s.mu
+func (s *store) headwordTaken(word *models.Word) bool {
+	for _, w := range s.words {
+		if w.ID != word.ID && w.Headword == word.Headword && w.PartOfSpeech == word.PartOfSpeech {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *wordRepository) Delete(_ context.Context, id uint) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.words[id]; !ok {
+		return repositories.ErrNotFound
+	}
+	delete(s.words, id)
+	for link := range s.lessons {
+		if link.wordID == id {
+			delete(s.lessons, link)
+		}
+	}
+	for reviewID, review := range s.reviews {
+		if review.WordID == id {
+			delete(s.reviews, reviewID)
+		}
+	}
+	return nil
+}
+
+func (r *wordRepository) FindByID(_ context.Context, id uint) (*models.Word, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.words[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	w = cloneWord(w)
+	return &w, nil
+}
+
+func (r *wordRepository) List(_ context.Context, filter repositories.WordFilter) ([]models.Word, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	words := []models.Word{}
+	for _, w := range s.words {
+		switch {
+		case filter.Query != "" && !strings.HasPrefix(strings.ToLower(w.Headword), strings.ToLower(filter.Query)),
+			filter.Tag != "" && !slices.Contains(w.Tags, filter.Tag),
+			filter.PartOfSpeech != "" && w.PartOfSpeech != filter.PartOfSpeech,
+			filter.Difficulty != "" && w.Difficulty != filter.Difficulty:
+			continue
+		}
+		words = append(words, cloneWord(w))
+	}
+	sort.Slice(words, func(i, j int) bool { return words[i].Headword < words[j].Headword })
+	if filter.Offset > 0 {
+		words = words[min(filter.Offset, len(words)):]
+	}
+	if filter.Limit > 0 && len(words) > filter.Limit {
+		words = words[:filter.Limit]
+	}
+	return words, nil
+}
+
+func (r *wordRepository) LinkLesson(_ context.Context, lessonID, wordID uint) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.words[wordID]; !ok {
+		return repositories.ErrNotFound
+	}
+	s.lessons[lessonWord{lessonID, wordID}] = true
+	return nil
+}
+
+func (r *wordRepository) UnlinkLesson(_ context.Context, lessonID, wordID uint) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	link := lessonWord{lessonID, wordID}
+	if !s.lessons[link] {
+		return repositories.ErrNotFound
+	}
+	delete(s.lessons, link)
+	return nil
+}
+
+func (r *wordRepository) ListByLesson(_ context.Context, lessonID uint) ([]models.Word, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var words []models.Word
+	for link := range s.lessons {
+		if link.lessonID == lessonID {
+			words = append(words, cloneWord(s.words[link.wordID]))
+		}
+	}
+	sortByID(words, func(w models.Word) uint { return w.ID })
+	return words, nil
+}
+
+// cloneWord копирует слово вместе со срезами, чтобы вызывающий код не менял хранилище
+func cloneWord(w models.Word) models.Word {
+	w.Tags, w.Examples = slices.Clone(w.Tags), slices.Clone(w.Examples)
+	w.Translations = slices.Clone(w.Translations)
+	return w
+}
+
+type gameSessionRepository store
+
+func (r *gameSessionRepository) Create(_ context.Context, session *models.GameSession) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[session.Code]; ok {
+		return repositories.ErrDuplicate
+	}
+	now := time.Now()
+	session.CreatedAt, session.UpdatedAt = now, now
+	s.sessions[session.Code] = *session
+	return nil
+}
+
+func (r *gameSessionRepository) FindByCode(_ context.Context, code string) (*models.GameSession, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[code]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return &session, nil
+}
+
+func (r *gameSessionRepository) Update(_ context.Context, session *models.GameSession) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.sessions[session.Code]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	if current.Version != session.Version {
+		return repositories.ErrConflict
+	}
+	session.Version++
+	session.CreatedAt, session.UpdatedAt = current.CreatedAt, time.Now()
+	s.sessions[session.Code] = *session
+	return nil
+}
+
+func (r *gameSessionRepository) Delete(_ context.Context, session *models.GameSession) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.sessions[session.Code]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	if current.Version != session.Version {
+		return repositories.ErrConflict
+	}
+	delete(s.sessions, session.Code)
+	return nil
+}
+
+func (r *gameSessionRepository) ListDue(_ context.Context, now time.Time, limit int) ([]models.GameSession, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.GameSession
+	for _, session := range s.sessions {
+		if session.Deadline != nil && !session.Deadline.After(now) {
+			due = append(due, session)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Deadline.Before(*due[j].Deadline) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *gameSessionRepository) Count(_ context.Context) (int64, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.sessions)), nil
+}
+
+type gameResultRepository store
+
+func (r *gameResultRepository) Create(_ context.Context, result *models.GameResult) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result.ID, result.CreatedAt = s.id("game_results"), time.Now()
+	for i := range result.Players {
+		result.Players[i].ID, result.Players[i].GameID = s.id("game_players"), result.ID
+	}
+	stored := *result
+	stored.Players = slices.Clone(result.Players)
+	s.games[result.ID] = stored
+	return nil
+}
+
+func (r *gameResultRepository) ListByChild(_ context.Context, childID uint, limit int) ([]models.GameResult, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []models.GameResult
+	for _, g := range s.games {
+		if slices.ContainsFunc(g.Players, func(p models.GamePlayer) bool { return p.ChildID == childID }) {
+			g.Players = slices.Clone(g.Players)
+			list = append(list, g)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+type userEventRepository store
+
+func (r *userEventRepository) Append(_ context.Context, event *models.UserEvent, keep int) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.ID, event.CreatedAt = s.id("user_events"), time.Now()
+	s.events[event.ID] = *event
+
+	var ids []uint
+	for id, e := range s.events {
+		if e.UserID == event.UserID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	for _, id := range ids[:max(len(ids)-keep, 0)] {
+		delete(s.events, id)
+	}
+	return nil
+}
+
+func (r *userEventRepository) ListAfter(_ context.Context, userID, afterID uint) ([]models.UserEvent, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []models.UserEvent
+	for _, e := range s.events {
+		if e.UserID == userID && e.ID > afterID {
+			list = append(list, e)
+		}
+	}
+	sortByID(list, func(e models.UserEvent) uint { return e.ID })
+	return list, nil
+}
+
+func (r *userEventRepository) Exists(_ context.Context, userID, id uint) (bool, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.events[id]
+	return ok && e.UserID == userID, nil
+}
+
+type reviewRepository store
+
+func (r *reviewRepository) Save(_ context.Context, review *models.WordReview) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.reviews {
+		if existing.ID != review.ID && existing.ChildID == review.ChildID && existing.WordID == review.WordID {
+			return repositories.ErrDuplicate
+		}
+	}
+	now := time.Now()
+	if review.ID == 0 {
+		review.ID, review.CreatedAt = s.id("word_reviews"), now
+	}
+	review.UpdatedAt = now
+	s.reviews[review.ID] = *review
+	return nil
+}
+
+func (r *reviewRepository) Find(_ context.Context, childID, wordID uint) (*models.WordReview, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, review := range s.reviews {
+		if review.ChildID == childID && review.WordID == wordID {
+			return &review, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *reviewRepository) ListByWords(_ context.Context, childID uint, wordIDs []uint) ([]models.WordReview, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []models.WordReview{}
+	for _, review := range s.reviews {
+		if review.ChildID == childID && slices.Contains(wordIDs, review.WordID) {
+			list = append(list, review)
+		}
+	}
+	sortByID(list, func(r models.WordReview) uint { return r.WordID })
+	return list, nil
+}
+
+func (r *reviewRepository) ListChanged(_ context.Context, childIDs []uint, since time.Time) ([]models.WordReview, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return changed(s.reviews, childIDs, since, func(r models.WordReview) (uint, uint, time.Time) {
+		return r.ID, r.ChildID, r.UpdatedAt
+	}), nil
+}
+
+type settingRepository store
+
+func (r *settingRepository) Save(_ context.Context, setting *models.ChildSetting) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.settings {
+		if existing.ID != setting.ID && existing.ChildID == setting.ChildID && existing.Key == setting.Key {
+			return repositories.ErrDuplicate
+		}
+	}
+	now := time.Now()
+	if setting.ID == 0 {
+		setting.ID, setting.CreatedAt = s.id("child_settings"), now
+	}
+	setting.UpdatedAt = now
+	s.settings[setting.ID] = *setting
+	return nil
+}
+
+func (r *settingRepository) Find(_ context.Context, childID uint, key string) (*models.ChildSetting, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, setting := range s.settings {
+		if setting.ChildID == childID && setting.Key == key {
+			return &setting, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *settingRepository) ListChanged(_ context.Context, childIDs []uint, since time.Time) ([]models.ChildSetting, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return changed(s.settings, childIDs, since, func(st models.ChildSetting) (uint, uint, time.Time) {
+		return st.ID, st.ChildID, st.UpdatedAt
+	}), nil
+}
+
+type syncRepository store
+
+func (r *syncRepository) Record(_ context.Context, op *models.SyncOperation) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.syncOps {
+		if existing.UserID == op.UserID && existing.ClientID == op.ClientID {
+			return repositories.ErrDuplicate
+		}
+	}
+	op.ID, op.CreatedAt = s.id("sync_operations"), time.Now()
+	s.syncOps[op.ID] = *op
+	return nil
+}
+
+type idempotencyRepository store
+
+func (r *idempotencyRepository) Reserve(_ context.Context, record *models.IdempotencyKey, now time.Time) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, k := range s.idemKeys {
+		if k.UserID != record.UserID || k.Key != record.Key {
+			continue
+		}
+		if k.ExpiresAt.After(now) {
+			return repositories.ErrDuplicate
+		}
+		delete(s.idemKeys, id)
+	}
+	record.ID, record.CreatedAt = s.id("idempotency_keys"), time.Now()
+	s.idemKeys[record.ID] = *record
+	return nil
+}
+
+func (r *idempotencyRepository) Find(_ context.Context, userID uint, key string) (*models.IdempotencyKey, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.idemKeys {
+		if k.UserID == userID && k.Key == key {
+			k.Body = slices.Clone(k.Body)
+			return &k, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *idempotencyRepository) Complete(_ context.Context, record *models.IdempotencyKey) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.idemKeys[record.ID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	k.Status, k.ContentType, k.Body, k.ExpiresAt = record.Status, record.ContentType, slices.Clone(record.Body), record.ExpiresAt
+	s.idemKeys[k.ID] = k
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(_ context.Context, id uint) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.idemKeys, id)
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, k := range s.idemKeys {
+		if k.ExpiresAt.Before(before) {
+			delete(s.idemKeys, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+type mediaRepository store
+
+func (r *mediaRepository) Create(_ context.Context, asset *models.MediaAsset) error {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.media {
+		if existing.Hash == asset.Hash {
+			return repositories.ErrDuplicate
+		}
+	}
+	asset.ID, asset.CreatedAt = s.id("media_assets"), time.Now()
+	s.media[asset.ID] = *asset
+	return nil
+}
+
+func (r *mediaRepository) FindByID(_ context.Context, id uint) (*models.MediaAsset, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	asset, ok := s.media[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return &asset, nil
+}
+
+func (r *mediaRepository) FindByIDs(_ context.Context, ids []uint) ([]models.MediaAsset, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var assets []models.MediaAsset
+	for _, id := range ids {
+		if asset, ok := s.media[id]; ok && !slices.ContainsFunc(assets, func(a models.MediaAsset) bool { return a.ID == id }) {
+			assets = append(assets, asset)
+		}
+	}
+	sortByID(assets, func(a models.MediaAsset) uint { return a.ID })
+	return assets, nil
+}
+
+func (r *mediaRepository) FindByHash(_ context.Context, hash string) (*models.MediaAsset, error) {
+	s := (*store)(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, asset := range s.media {
+		if asset.Hash == hash {
+			return &asset, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, event *models.OutboxEvent) error {
+	if event.Status == "" {
+		event.Status = models.OutboxPending
+	}
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = time.Now()
+	}
+	return translate(r.db.WithContext(ctx).Create(event).Error)
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE outbox_events
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease),
+		models.OutboxPending, now, limit,
+	).Scan(&events).Error
+	// RETURNING не сохраняет порядок подзапроса, а подписчики ждут события по порядку
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, translate(err)
+}
+
+func (r *outboxRepository) Update(ctx context.Context, event *models.OutboxEvent) error {
+	err := r.db.WithContext(ctx).Model(event).
+		Select("status", "attempts", "next_attempt_at", "last_error", "processed_at").
+		Updates(event).Error
+	return translate(err)
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type progressRepository struct {
+	db *gorm.DB
+}
+
+func NewProgressRepository(db *gorm.DB) ProgressRepository {
+	return &progressRepository{db: db}
+}
+
+func (r *progressRepository) Save(ctx context.Context, progress *models.Progress) error {
+	return translate(r.db.WithContext(ctx).Save(progress).Error)
+}
+
+func (r *progressRepository) FindByChildAndLesson(ctx context.Context, childID, lessonID uint) (*models.Progress, error) {
+	var p models.Progress
+	err := r.db.WithContext(ctx).Where("child_id = ? AND lesson_id = ?", childID, lessonID).First(&p).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &p, nil
+}
+
+func (r *progressRepository) ListByChild(ctx context.Context, childID uint) ([]models.Progress, error) {
+	var list []models.Progress
+	err := r.db.WithContext(ctx).Where("child_id = ?", childID).Order("lesson_id").Find(&list).Error
+	return list, translate(err)
+}
+
+func (r *progressRepository) ListChanged(ctx context.Context, childIDs []uint, since time.Time) ([]models.Progress, error) {
+	list := []models.Progress{}
+	if len(childIDs) == 0 {
+		return list, nil
+	}
+	err := r.db.WithContext(ctx).Where("child_id IN ? AND updated_at > ?", childIDs, since).Order("id").Find(&list).Error
+	return list, translate(err)
+}
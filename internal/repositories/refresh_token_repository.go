@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Save(ctx context.Context, token *models.RefreshToken) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			UpdateAll: true,
+		}).Create(token).Error
+	return translate(err)
+}
+
+func (r *refreshTokenRepository) FindByToken(ctx context.Context, token string) (*models.RefreshToken, error) {
+	var rt models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&rt).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &rt, nil
+}
+
+func (r *refreshTokenRepository) DeleteByToken(ctx context.Context, token string) error {
+	result := r.db.WithContext(ctx).Where("token = ?", token).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	return result.RowsAffected, translate(result.Error)
+}
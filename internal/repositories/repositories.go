@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"engkids/internal/models"
+)
+
+// Ошибки хранилища, не зависящие от реализации. Сервисы сравнивают с ними
+// через errors.Is и не знают о GORM
+var (
+	ErrNotFound  = errors.New("record not found")
+	ErrDuplicate = errors.New("duplicate record")
+	ErrConflict  = errors.New("record changed concurrently")
+)
+
+// StaleJobError — last_error задачи, которую RequeueStale перевёл в dead
+const StaleJobError = "worker stopped before the job finished"
+
+// UserRepository — пользователи (родители и администраторы)
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	// PurgeDeleted окончательно удаляет пользователей, мягко удалённых раньше before
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RefreshTokenRepository — refresh-токены. У пользователя один действующий токен
+type RefreshTokenRepository interface {
+	// Save сохраняет токен, заменяя предыдущий токен того же пользователя
+	Save(ctx context.Context, token *models.RefreshToken) error
+	FindByToken(ctx context.Context, token string) (*models.RefreshToken, error)
+	// DeleteByToken возвращает ErrNotFound, если токена не было
+	DeleteByToken(ctx context.Context, token string) error
+	// DeleteExpired удаляет токены, истёкшие раньше before
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ChildRepository — профили детей
+type ChildRepository interface {
+	Create(ctx context.Context, child *models.Child) error
+	FindByID(ctx context.Context, id uint) (*models.Child, error)
+	ListByParent(ctx context.Context, parentID uint) ([]models.Child, error)
+	CountByParent(ctx context.Context, parentID uint) (int64, error)
+	Update(ctx context.Context, child *models.Child) error
+	Delete(ctx context.Context, id uint) error
+	// PurgeDeleted окончательно удаляет профили, мягко удалённые раньше before
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// ResetStreaks обнуляет серии детей, которые не занимались с activeSince
+	// (дата в часовом поясе планировщика)
+	ResetStreaks(ctx context.Context, activeSince time.Time) (int64, error)
+	// ListStreaksAtRisk возвращает детей с непрерванной серией, которые не
+	// занимались в день today
+	ListStreaksAtRisk(ctx context.Context, today time.Time) ([]models.Child, error)
+	// UpdateStreak сохраняет серию ребёнка и день последнего занятия
+	UpdateStreak(ctx context.Context, child *models.Child) error
+}
+
+// ProgressRepository — прогресс детей по урокам
+type ProgressRepository interface {
+	// Save создаёт запись или обновляет существующую по ID
+	Save(ctx context.Context, progress *models.Progress) error
+	FindByChildAndLesson(ctx context.Context, childID, lessonID uint) (*models.Progress, error)
+	ListByChild(ctx context.Context, childID uint) ([]models.Progress, error)
+	// ListChanged возвращает записи детей childIDs, изменённые позже since
+	ListChanged(ctx context.Context, childIDs []uint, since time.Time) ([]models.Progress, error)
+}
+
+// OutboxRepository — доменные события, ожидающие доставки подписчикам
+type OutboxRepository interface {
+	Add(ctx context.Context, event *models.OutboxEvent) error
+	// ClaimDue выбирает до limit событий, которые пора доставить, и одним
+	// запросом откладывает их next_attempt_at на lease: другие экземпляры
+	// приложения их пропустят, а после падения доставившего событие снова станет доступно
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error)
+	Update(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// JobFilter — условия выборки задач для админки
+type JobFilter struct {
+	Queue  string
+	Status string
+	Type   string
+	Limit  int
+	Offset int
+}
+
+// JobRepository — очередь фоновых задач
+type JobRepository interface {
+	Enqueue(ctx context.Context, job *models.Job) error
+	// Claim атомарно переводит до limit готовых задач очереди в running и
+	// увеличивает им счётчик попыток. Задачи, захваченные другими воркерами, пропускаются
+	Claim(ctx context.Context, queue, workerID string, now time.Time, limit int) ([]models.Job, error)
+	// RequeueStale разбирает задачи, которые висят в running с lockedBefore (воркер
+	// упал, не завершив их): исчерпавшие max_attempts переводит в dead, остальные
+	// возвращает в очередь
+	RequeueStale(ctx context.Context, lockedBefore time.Time) (requeued, dead int64, err error)
+	// Transition сохраняет состояние задачи, только если её текущий статус — один
+	// из from; иначе возвращает ErrConflict (или ErrNotFound, если задачи нет)
+	Transition(ctx context.Context, job *models.Job, from ...string) error
+	FindByID(ctx context.Context, id uint) (*models.Job, error)
+	List(ctx context.Context, filter JobFilter) ([]models.Job, error)
+}
+
+// ScheduledRunRepository — история запусков задач планировщика
+type ScheduledRunRepository interface {
+	Create(ctx context.Context, run *models.ScheduledRun) error
+	Update(ctx context.Context, run *models.ScheduledRun) error
+	// List возвращает последние запуски, новые первыми; пустой task — все задачи
+	List(ctx context.Context, task string, limit int) ([]models.ScheduledRun, error)
+}
+
+// WordFilter — условия выборки слов словаря; пустые поля не ограничивают выборку
+type WordFilter struct {
+	Query        string // начало слова без учёта регистра
+	Tag          string
+	PartOfSpeech string
+	Difficulty   string
+	Limit        int
+	Offset       int
+}
+
+// WordRepository — словарь и слова уроков
+type WordRepository interface {
+	// Create сохраняет слово вместе с переводами; ErrDuplicate — слово уже есть
+	Create(ctx context.Context, word *models.Word) error
+	// Update сохраняет слово и заменяет его переводы
+	Update(ctx context.Context, word *models.Word) error
+	// Delete удаляет слово с переводами, привязками к урокам и повторениями
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Word, error)
+	// List возвращает слова с переводами по алфавиту
+	List(ctx context.Context, filter WordFilter) ([]models.Word, error)
+	// LinkLesson добавляет слово в урок; повторная привязка ничего не меняет
+	LinkLesson(ctx context.Context, lessonID, wordID uint) error
+	// UnlinkLesson убирает слово из урока; ErrNotFound — слова в уроке нет
+	UnlinkLesson(ctx context.Context, lessonID, wordID uint) error
+	// ListByLesson возвращает слова урока с переводами
+	ListByLesson(ctx context.Context, lessonID uint) ([]models.Word, error)
+}
+
+// GameSessionRepository — идущие викторины
+type GameSessionRepository interface {
+	// Create сохраняет новую игру; ErrDuplicate — код уже занят
+	Create(ctx context.Context, session *models.GameSession) error
+	FindByCode(ctx context.Context, code string) (*models.GameSession, error)
+	// Update сохраняет игру и увеличивает Version, только если в базе та же
+	// Version, что в session; иначе ErrConflict (или ErrNotFound, если игры нет)
+	Update(ctx context.Context, session *models.GameSession) error
+	// Delete удаляет игру при той же Version, что в session; иначе ErrConflict
+	// (или ErrNotFound)
+	Delete(ctx context.Context, session *models.GameSession) error
+	// ListDue возвращает до limit игр, у которых к now наступил дедлайн
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.GameSession, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+// GameResultRepository — итоги сыгранных викторин
+type GameResultRepository interface {
+	// Create сохраняет итоги вместе с таблицей игроков
+	Create(ctx context.Context, result *models.GameResult) error
+	// ListByChild возвращает игры, в которых участвовал ребёнок, новые первыми
+	ListByChild(ctx context.Context, childID uint, limit int) ([]models.GameResult, error)
+}
+
+// UserEventRepository — журнал событий, отправленных пользователю в реальном времени
+type UserEventRepository interface {
+	// Append добавляет событие и оставляет в журнале пользователя только keep последних
+	Append(ctx context.Context, event *models.UserEvent, keep int) error
+	// ListAfter возвращает события пользователя с ID больше afterID по порядку
+	ListAfter(ctx context.Context, userID, afterID uint) ([]models.UserEvent, error)
+	// Exists сообщает, осталось ли событие id в журнале пользователя
+	Exists(ctx context.Context, userID, id uint) (bool, error)
+}
+
+// ReviewRepository — повторения слов детьми
+type ReviewRepository interface {
+	// Save создаёт запись или обновляет существующую по ID
+	Save(ctx context.Context, review *models.WordReview) error
+	Find(ctx context.Context, childID, wordID uint) (*models.WordReview, error)
+	// ListByWords возвращает повторения ребёнком слов wordIDs
+	ListByWords(ctx context.Context, childID uint, wordIDs []uint) ([]models.WordReview, error)
+	// ListChanged возвращает повторения детей childIDs, изменённые позже since
+	ListChanged(ctx context.Context, childIDs []uint, since time.Time) ([]models.WordReview, error)
+}
+
+// SettingRepository — настройки приложения детей
+type SettingRepository interface {
+	// Save создаёт запись или обновляет существующую по ID
+	Save(ctx context.Context, setting *models.ChildSetting) error
+	Find(ctx context.Context, childID uint, key string) (*models.ChildSetting, error)
+	// ListChanged возвращает настройки детей childIDs, изменённые позже since
+	ListChanged(ctx context.Context, childIDs []uint, since time.Time) ([]models.ChildSetting, error)
+}
+
+// SyncRepository — принятые операции офлайн-синхронизации
+type SyncRepository interface {
+	// Record запоминает операцию; ErrDuplicate — пользователь уже присылал
+	// операцию с таким ClientID
+	Record(ctx context.Context, op *models.SyncOperation) error
+}
+
+// IdempotencyRepository — ответы на запросы с Idempotency-Key
+type IdempotencyRepository interface {
+	// Reserve занимает ключ на время выполнения запроса. Запись с тем же ключом,
+	// истёкшая к now, заменяется; ErrDuplicate — ключ занят действующей записью
+	Reserve(ctx context.Context, record *models.IdempotencyKey, now time.Time) error
+	Find(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error)
+	// Complete сохраняет ответ на запрос и продлевает запись до ExpiresAt
+	Complete(ctx context.Context, record *models.IdempotencyKey) error
+	Delete(ctx context.Context, id uint) error
+	// DeleteExpired удаляет записи, истёкшие раньше before
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MediaRepository — загруженные медиафайлы
+type MediaRepository interface {
+	// Create сохраняет запись; ErrDuplicate — файл с таким хешем уже загружен
+	Create(ctx context.Context, asset *models.MediaAsset) error
+	FindByID(ctx context.Context, id uint) (*models.MediaAsset, error)
+	// FindByIDs возвращает найденные файлы из ids; отсутствующие пропускаются
+	FindByIDs(ctx context.Context, ids []uint) ([]models.MediaAsset, error)
+	FindByHash(ctx context.Context, hash string) (*models.MediaAsset, error)
+}
+
+// Transactor выполняет fn в одной транзакции. Хранилища, переданные в fn,
+// работают внутри неё; ошибка из fn откатывает транзакцию
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(repos *Repositories) error) error
+}
+
+// Repositories объединяет хранилища, чтобы передавать их в сервисы одним значением
+type Repositories struct {
+	Users         UserRepository
+	RefreshTokens RefreshTokenRepository
+	Children      ChildRepository
+	Progress      ProgressRepository
+	Outbox        OutboxRepository
+	Jobs          JobRepository
+	ScheduledRuns ScheduledRunRepository
+	Words         WordRepository
+	GameSessions  GameSessionRepository
+	GameResults   GameResultRepository
+	UserEvents    UserEventRepository
+	Reviews       ReviewRepository
+	Settings      SettingRepository
+	Sync          SyncRepository
+	Idempotency   IdempotencyRepository
+	Media         MediaRepository
+	Tx            Transactor
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type scheduledRunRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledRunRepository(db *gorm.DB) ScheduledRunRepository {
+	return &scheduledRunRepository{db: db}
+}
+
+func (r *scheduledRunRepository) Create(ctx context.Context, run *models.ScheduledRun) error {
+	return translate(r.db.WithContext(ctx).Create(run).Error)
+}
+
+func (r *scheduledRunRepository) Update(ctx context.Context, run *models.ScheduledRun) error {
+	result := r.db.WithContext(ctx).Save(run)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *scheduledRunRepository) List(ctx context.Context, task string, limit int) ([]models.ScheduledRun, error) {
+	q := r.db.WithContext(ctx).Order("id DESC").Limit(limit)
+	if task != "" {
+		q = q.Where("task = ?", task)
+	}
+	var runs []models.ScheduledRun
+	return runs, translate(q.Find(&runs).Error)
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) Save(ctx context.Context, review *models.WordReview) error {
+	return translate(r.db.WithContext(ctx).Save(review).Error)
+}
+
+func (r *reviewRepository) Find(ctx context.Context, childID, wordID uint) (*models.WordReview, error) {
+	var review models.WordReview
+	err := r.db.WithContext(ctx).Where("child_id = ? AND word_id = ?", childID, wordID).First(&review).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) ListByWords(ctx context.Context, childID uint, wordIDs []uint) ([]models.WordReview, error) {
+	list := []models.WordReview{}
+	if len(wordIDs) == 0 {
+		return list, nil
+	}
+	err := r.db.WithContext(ctx).Where("child_id = ? AND word_id IN ?", childID, wordIDs).Order("word_id").Find(&list).Error
+	return list, translate(err)
+}
+
+func (r *reviewRepository) ListChanged(ctx context.Context, childIDs []uint, since time.Time) ([]models.WordReview, error) {
+	list := []models.WordReview{}
+	if len(childIDs) == 0 {
+		return list, nil
+	}
+	err := r.db.WithContext(ctx).Where("child_id IN ? AND updated_at > ?", childIDs, since).Order("id").Find(&list).Error
+	return list, translate(err)
+}
+
+type settingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) SettingRepository {
+	return &settingRepository{db: db}
+}
+
+func (r *settingRepository) Save(ctx context.Context, setting *models.ChildSetting) error {
+	return translate(r.db.WithContext(ctx).Save(setting).Error)
+}
+
+func (r *settingRepository) Find(ctx context.Context, childID uint, key string) (*models.ChildSetting, error) {
+	var setting models.ChildSetting
+	err := r.db.WithContext(ctx).Where("child_id = ? AND key = ?", childID, key).First(&setting).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &setting, nil
+}
+
+func (r *settingRepository) ListChanged(ctx context.Context, childIDs []uint, since time.Time) ([]models.ChildSetting, error) {
+	list := []models.ChildSetting{}
+	if len(childIDs) == 0 {
+		return list, nil
+	}
+	err := r.db.WithContext(ctx).Where("child_id IN ? AND updated_at > ?", childIDs, since).Order("id").Find(&list).Error
+	return list, translate(err)
+}
+
+type syncRepository struct {
+	db *gorm.DB
+}
+
+func NewSyncRepository(db *gorm.DB) SyncRepository {
+	return &syncRepository{db: db}
+}
+
+func (r *syncRepository) Record(ctx context.Context, op *models.SyncOperation) error {
+	return translate(r.db.WithContext(ctx).Create(op).Error)
+}
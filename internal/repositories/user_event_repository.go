@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type userEventRepository struct {
+	db *gorm.DB
+}
+
+func NewUserEventRepository(db *gorm.DB) UserEventRepository {
+	return &userEventRepository{db: db}
+}
+
+func (r *userEventRepository) Append(ctx context.Context, event *models.UserEvent, keep int) error {
+	return translate(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		newest := tx.Model(&models.UserEvent{}).Select("id").
+			Where("user_id = ?", event.UserID).Order("id DESC").Limit(keep)
+		return tx.Where("user_id = ? AND id NOT IN (?)", event.UserID, newest).Delete(&models.UserEvent{}).Error
+	}))
+}
+
+func (r *userEventRepository) ListAfter(ctx context.Context, userID, afterID uint) ([]models.UserEvent, error) {
+	var list []models.UserEvent
+	err := r.db.WithContext(ctx).Where("user_id = ? AND id > ?", userID, afterID).Order("id").Find(&list).Error
+	return list, translate(err)
+}
+
+func (r *userEventRepository) Exists(ctx context.Context, userID, id uint) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&models.UserEvent{}).Where("user_id = ? AND id = ?", userID, id).Count(&n).Error
+	return n > 0, translate(err)
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) List(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).Order("id").Find(&users).Error
+	return users, translate(err)
+}
+
+// PurgeDeleted удаляет вместе с пользователями их детей со всеми данными,
+// refresh-токены, журнал событий, принятые операции синхронизации и
+// сохранённые ответы на запросы с Idempotency-Key
+func (r *userRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := tx.Unscoped().Model(&models.User{}).Select("id").Where("deleted_at < ?", before)
+		children := tx.Unscoped().Model(&models.Child{}).Select("id").Where("parent_id IN (?)", users)
+
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.Progress{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.WordReview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.ChildSetting{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("child_id IN (?)", children).Delete(&models.GamePlayer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("parent_id IN (?)", users).Delete(&models.Child{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", users).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", users).Delete(&models.UserEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", users).Delete(&models.SyncOperation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", users).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.User{})
+		n = result.RowsAffected
+		return result.Error
+	})
+	return n, translate(err)
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"engkids/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type wordRepository struct {
+	db *gorm.DB
+}
+
+func NewWordRepository(db *gorm.DB) WordRepository {
+	return &wordRepository{db: db}
+}
+
+func (r *wordRepository) Create(ctx context.Context, word *models.Word) error {
+	return translate(r.db.WithContext(ctx).Create(word).Error)
+}
+
+func (r *wordRepository) Update(ctx context.Context, word *models.Word) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(word).Omit(clause.Associations).
+			Select("headword", "part_of_speech", "difficulty", "tags", "examples", "image_media_id", "audio_uk_media_id", "audio_us_media_id", "updated_at").
+			Updates(word)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Where("word_id = ?", word.ID).Delete(&models.WordTranslation{}).Error; err != nil {
+			return err
+		}
+		if len(word.Translations) == 0 {
+			return nil
+		}
+		for i := range word.Translations {
+			word.Translations[i].ID, word.Translations[i].WordID = 0, word.ID
+		}
+		return tx.Create(&word.Translations).Error
+	})
+	return translate(err)
+}
+
+func (r *wordRepository) Delete(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("word_id = ?", id).Delete(&models.WordReview{}).Error; err != nil {
+			return err
+		}
+		// переводы и привязки к урокам удаляются каскадно
+		result := tx.Delete(&models.Word{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return translate(err)
+}
+
+func (r *wordRepository) FindByID(ctx context.Context, id uint) (*models.Word, error) {
+	var word models.Word
+	if err := r.db.WithContext(ctx).Preload("Translations").First(&word, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &word, nil
+}
+
+func (r *wordRepository) List(ctx context.Context, filter WordFilter) ([]models.Word, error) {
+	q := r.db.WithContext(ctx).Preload("Translations").Order("headword")
+	if filter.Query != "" {
+		q = q.Where("LOWER(headword) LIKE ?", likePrefix(strings.ToLower(filter.Query)))
+	}
+	if filter.Tag != "" {
+		q = q.Where("tags @> ?::jsonb", models.StringList{filter.Tag})
+	}
+	if filter.PartOfSpeech != "" {
+		q = q.Where("part_of_speech = ?", filter.PartOfSpeech)
+	}
+	if filter.Difficulty != "" {
+		q = q.Where("difficulty = ?", filter.Difficulty)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	words := []models.Word{}
+	err := q.Find(&words).Error
+	return words, translate(err)
+}
+
+func (r *wordRepository) LinkLesson(ctx context.Context, lessonID, wordID uint) error {
+	link := models.LessonWord{LessonID: lessonID, WordID: wordID}
+	return translate(r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&link).Error)
+}
+
+func (r *wordRepository) UnlinkLesson(ctx context.Context, lessonID, wordID uint) error {
+	result := r.db.WithContext(ctx).Where("lesson_id = ? AND word_id = ?", lessonID, wordID).Delete(&models.LessonWord{})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *wordRepository) ListByLesson(ctx context.Context, lessonID uint) ([]models.Word, error) {
+	var words []models.Word
+	err := r.db.WithContext(ctx).Preload("Translations").
+		Joins("JOIN lesson_words ON lesson_words.word_id = words.id").
+		Where("lesson_words.lesson_id = ?", lessonID).
+		Order("words.id").Find(&words).Error
+	return words, translate(err)
+}
+
+// likePrefix — шаблон LIKE для строк, начинающихся с prefix; спецсимволы
+// шаблона экранируются
+func likePrefix(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(prefix) + "%"
+}
@@ -0,0 +1,132 @@
+package routes_test
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"engkids/internal/apptest"
+	"engkids/internal/events"
+	"engkids/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type sseEvent struct {
+	id   uint
+	data string
+}
+
+// openStream подключается к /api/events/stream и возвращает функцию чтения событий
+func openStream(t *testing.T, addr, token, lastID string) func() sseEvent {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://"+addr+"/api/events/stream?token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return func() sseEvent {
+		t.Helper()
+		var e sseEvent
+		timeout := time.After(3 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatal("stream closed")
+				}
+				switch {
+				case line == "" && e.data != "":
+					return e
+				case strings.HasPrefix(line, "id: "):
+					id, _ := strconv.Atoi(strings.TrimPrefix(line, "id: "))
+					e.id = uint(id)
+				case strings.HasPrefix(line, "data: "):
+					e.data = strings.TrimPrefix(line, "data: ")
+				}
+			case <-timeout:
+				t.Fatal("no event")
+			}
+		}
+	}
+}
+
+func TestEventStreamResume(t *testing.T) {
+	h := apptest.New(t)
+	addr := h.Listen()
+	parent := h.Register("parent@example.com")
+	child := h.Child(parent.User.ID, "Маша", 7)
+
+	// пока клиент был отключён, в журнал попали события 1 и 2
+	for streak := 1; streak <= 2; streak++ {
+		err := h.App.Realtime.PublishToUser(t.Context(), parent.User.ID, realtime.TypeStreakAtRisk,
+			events.StreakAtRisk{ChildID: child.ID, Streak: streak, Date: "2024-03-15"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := openStream(t, addr, parent.AccessToken, "1")
+	if e := next(); e.id != 0 || !strings.Contains(e.data, `"type":"ready"`) {
+		t.Fatalf("first event: %+v", e)
+	}
+	if e := next(); e.id != 2 || !strings.Contains(e.data, `"streak":2`) {
+		t.Fatalf("missed event: %+v", e)
+	}
+
+	// новое событие приходит вживую с ID из журнала
+	if err := events.Record(t.Context(), h.Repos.Outbox, events.LessonCompleted{ChildID: child.ID, LessonID: 3, Score: 90}); err != nil {
+		t.Fatal(err)
+	}
+	h.App.Dispatcher.Notify()
+	if e := next(); e.id != 3 || !strings.Contains(e.data, `"type":"lesson.completed"`) || !strings.Contains(e.data, `"seq":`) {
+		t.Fatalf("live event: %+v", e)
+	}
+
+	// события после ID 999 в журнале нет — клиент должен перезагрузить данные
+	next = openStream(t, addr, parent.AccessToken, "999")
+	next()
+	if e := next(); !strings.Contains(e.data, `"type":"resync"`) {
+		t.Fatalf("expected resync, got %+v", e)
+	}
+}
+
+func TestEventStreamErrors(t *testing.T) {
+	h := apptest.New(t)
+	parent := h.Register("parent@example.com")
+
+	r := h.Get("/api/events/stream", "")
+	h.ExpectStatus(r, fiber.StatusUnauthorized)
+	apptest.Golden(t, "events_token_missing", r.Body)
+
+	r = h.Do(apptest.Request{
+		Method:  fiber.MethodGet,
+		Path:    "/api/events/stream",
+		Token:   parent.AccessToken,
+		Headers: map[string]string{"Last-Event-ID": "abc"},
+	})
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "events_invalid_last_id", r.Body)
+}
@@ -0,0 +1,137 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"engkids/internal/apptest"
+	"engkids/internal/game"
+	"engkids/internal/models"
+	"engkids/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func decodeState(t *testing.T, r *apptest.Response) game.State {
+	t.Helper()
+	var s game.State
+	if err := json.Unmarshal(r.Body, &s); err != nil {
+		t.Fatalf("decode state: %v: %s", err, r.Body)
+	}
+	return s
+}
+
+func TestGameQuiz(t *testing.T) {
+	h := apptest.New(t)
+	addr := h.Listen()
+	host := h.Register("host@example.com")
+	guest := h.Register("guest@example.com")
+	anya := h.Child(host.User.ID, "Аня", 8)
+	borya := h.Child(guest.User.ID, "Боря", 9)
+	h.LessonWords(1, "cat", "кошка", "dog", "собака", "fox", "лиса")
+	translations := map[string]string{"cat": "кошка", "dog": "собака", "fox": "лиса"}
+
+	r := h.Post("/api/games", map[string]any{"lesson_id": 2}, host.AccessToken)
+	h.ExpectStatus(r, fiber.StatusUnprocessableEntity)
+	apptest.Golden(t, "game_not_enough_words", r.Body)
+
+	r = h.Post("/api/games", map[string]any{"lesson_id": 1, "rounds": 2}, host.AccessToken)
+	h.ExpectStatus(r, fiber.StatusCreated)
+	created := decodeState(t, r)
+	if !game.ValidCode(created.Code) || created.Phase != game.PhaseLobby || created.Rounds != 2 {
+		t.Fatalf("created: %+v", created)
+	}
+	path := "/api/games/" + created.Code
+
+	// гость следит за игрой по WebSocket
+	conn := dialWS(t, addr, "?token="+guest.AccessToken)
+	readEnvelope(t, conn)
+	sub := map[string]any{"v": 1, "type": realtime.TypeGameSubscribe, "payload": map[string]string{"code": created.Code}}
+	if err := conn.WriteJSON(sub); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(3 * time.Second); h.App.Hub.Count(realtime.GameRoom(created.Code)) != 1; {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription to the game room timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	h.ExpectStatus(h.Post(path+"/players", map[string]any{"child_id": anya.ID}, host.AccessToken), fiber.StatusOK)
+	h.ExpectStatus(h.Post(path+"/players", map[string]any{"child_id": anya.ID}, guest.AccessToken), fiber.StatusNotFound)
+	h.ExpectStatus(h.Post(path+"/players", map[string]any{"child_id": borya.ID}, guest.AccessToken), fiber.StatusOK)
+
+	r = h.Post(path+"/start", nil, guest.AccessToken)
+	h.ExpectStatus(r, fiber.StatusForbidden)
+	apptest.Golden(t, "game_not_host", r.Body)
+
+	r = h.Post(path+"/start", nil, host.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	question := decodeState(t, r)
+	if question.Phase != game.PhaseQuestion || question.Round != 1 || question.Question.Answer != nil || question.Deadline == nil {
+		t.Fatalf("question: %+v", question)
+	}
+	correct := slices.Index(question.Question.Options, translations[question.Question.Prompt])
+	wrong := (correct + 1) % len(question.Question.Options)
+
+	answer := func(s *apptest.Session, child *models.Child, option int) *apptest.Response {
+		return h.Post(path+"/answers", map[string]any{"child_id": child.ID, "round": 1, "option": option}, s.AccessToken)
+	}
+	h.ExpectStatus(answer(guest, anya, correct), fiber.StatusForbidden)
+	h.ExpectStatus(answer(host, anya, correct), fiber.StatusOK)
+	r = answer(guest, borya, wrong)
+	h.ExpectStatus(r, fiber.StatusOK)
+	if s := decodeState(t, r); s.Phase != game.PhaseReveal || *s.Question.Answer != correct {
+		t.Fatalf("reveal: %+v", s)
+	}
+	r = answer(guest, borya, correct)
+	h.ExpectStatus(r, fiber.StatusConflict)
+	apptest.Golden(t, "game_round_over", r.Body)
+
+	// второй вопрос остаётся без ответов
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		if n := h.App.Services.Games.Tick(t.Context(), now.Add(time.Duration(i)*time.Hour)); n != 1 {
+			t.Fatalf("tick %d changed %d games", i, n)
+		}
+	}
+	h.ExpectStatus(h.Get(path, host.AccessToken), fiber.StatusNotFound)
+
+	var phases []string
+	for i := 1; i <= 8; i++ {
+		env := readEnvelope(t, conn)
+		var s game.State
+		if err := json.Unmarshal(env.Payload, &s); err != nil {
+			t.Fatal(err)
+		}
+		if env.Type != realtime.TypeGameState || env.Seq != uint64(i) {
+			t.Fatalf("message %d: %+v", i, env)
+		}
+		phases = append(phases, fmt.Sprintf("%s%d", s.Phase, s.Round))
+	}
+	// ответ Ани тоже рассылается: игроки видят, кто уже ответил
+	want := []string{"lobby0", "lobby0", "question1", "question1", "reveal1", "question2", "reveal2", "finished2"}
+	if !slices.Equal(phases, want) {
+		t.Fatalf("phases %v, want %v", phases, want)
+	}
+
+	r = h.Get(fmt.Sprintf("/api/games/history?child_id=%d", borya.ID), guest.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	var history []models.GameResult
+	if err := json.Unmarshal(r.Body, &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Code != created.Code || history[0].Rounds != 2 || len(history[0].Players) != 2 {
+		t.Fatalf("history: %s", r.Body)
+	}
+	first, second := history[0].Players[0], history[0].Players[1]
+	if first.ChildID != anya.ID || first.Rank != 1 || first.Score != 1000 || first.Correct != 1 ||
+		second.ChildID != borya.ID || second.Rank != 2 || second.Score != 0 {
+		t.Fatalf("leaderboard: %+v", history[0].Players)
+	}
+
+	h.ExpectStatus(h.Get(fmt.Sprintf("/api/games/history?child_id=%d", borya.ID), host.AccessToken), fiber.StatusNotFound)
+}
@@ -0,0 +1,119 @@
+package routes_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"engkids/internal/apptest"
+	"engkids/internal/dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// upload отправляет файл формой multipart, как её шлёт админка
+func upload(h *apptest.Harness, data []byte, token string) *apptest.Response {
+	h.T.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", "cat.png")
+	if err != nil {
+		h.T.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+	return h.Do(apptest.Request{
+		Method:  fiber.MethodPost,
+		Path:    "/api/media",
+		Body:    body.String(),
+		Token:   token,
+		Headers: map[string]string{fiber.HeaderContentType: w.FormDataContentType()},
+	})
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, x*height/width, color.NRGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMedia(t *testing.T) {
+	h := apptest.New(t)
+	editor := h.CreateUser("editor@example.com", "editor")
+	parent := h.Register("parent@example.com")
+	picture := testPNG(t, 640, 320)
+
+	h.ExpectStatus(upload(h, picture, parent.AccessToken), fiber.StatusForbidden)
+
+	r := upload(h, picture, editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusCreated)
+	apptest.Golden(t, "media_uploaded", r.Body)
+	var asset dto.MediaAsset
+	r.JSON(t, &asset)
+	if asset.ThumbnailURL == "" {
+		t.Fatal("no thumbnail for a PNG")
+	}
+
+	// тот же файл не сохраняется второй раз
+	r = upload(h, picture, editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	var again dto.MediaAsset
+	r.JSON(t, &again)
+	if again.ID != asset.ID {
+		t.Errorf("duplicate upload created media %d, want %d", again.ID, asset.ID)
+	}
+
+	r = upload(h, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusUnsupportedMediaType)
+	apptest.Golden(t, "media_unsupported_type", r.Body)
+
+	// PNG с правильной сигнатурой, но битым содержимым
+	h.ExpectStatus(upload(h, picture[:64], editor.AccessToken), fiber.StatusUnprocessableEntity)
+
+	huge := append(testPNG(t, 8, 8), make([]byte, 5<<20)...)
+	r = upload(h, huge, editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusRequestEntityTooLarge)
+	apptest.Golden(t, "media_too_large", r.Body)
+
+	r = h.Get("/api/media/"+strconv.Itoa(int(asset.ID)), parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	h.ExpectStatus(h.Get("/api/media/999", parent.AccessToken), fiber.StatusNotFound)
+
+	// ссылки открываются без токена
+	r = h.Get(asset.URL, "")
+	h.ExpectStatus(r, fiber.StatusOK)
+	if !bytes.Equal(r.Body, picture) || r.Header.Get(fiber.HeaderContentType) != "image/png" {
+		t.Errorf("download = %d bytes, %s", len(r.Body), r.Header.Get(fiber.HeaderContentType))
+	}
+	r = h.Get(asset.ThumbnailURL, "")
+	h.ExpectStatus(r, fiber.StatusOK)
+	thumb, err := png.DecodeConfig(bytes.NewReader(r.Body))
+	if err != nil || thumb.Width != 256 || thumb.Height != 128 {
+		t.Errorf("thumbnail = %+v, %v", thumb, err)
+	}
+
+	link, _ := url.Parse(asset.URL)
+	q := link.Query()
+	q.Set("expires", strconv.FormatInt(asset.ExpiresAt.Unix()+3600, 10))
+	link.RawQuery = q.Encode()
+	r = h.Get(link.String(), "")
+	h.ExpectStatus(r, fiber.StatusForbidden)
+	apptest.Golden(t, "media_link_invalid", r.Body)
+
+	// подпись одного файла не открывает другой
+	link, _ = url.Parse(asset.URL)
+	link.Path = "/media/media/00/missing.png"
+	h.ExpectStatus(h.Get(link.String(), ""), fiber.StatusForbidden)
+}
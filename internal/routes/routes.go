@@ -3,40 +3,101 @@ package routes
 import (
 	"engkids/internal/handlers"
 	"engkids/internal/middlewares"
-	"engkids/internal/services"
+	"engkids/pkg/logger"
 	"github.com/gofiber/fiber/v2"
-	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
-func SetupRoutes(app *fiber.App, db *gorm.DB, logger *logrus.Logger) {
+// Handlers — обработчики и middleware, из которых собираются маршруты
+type Handlers struct {
+	Auth      *handlers.AuthHandler
+	Logs      fiber.Handler
+	Jobs      *handlers.JobHandler
+	Scheduler *handlers.SchedulerHandler
+	WS        *handlers.WSHandler
+	Games     *handlers.GameHandler
+	Events    *handlers.EventsHandler
+	Sync      *handlers.SyncHandler
+	Words     *handlers.WordHandler
+	Media     *handlers.MediaHandler
+	// MediaPath — путь, по которому раздаются файлы локального хранилища
+	MediaPath string
+	AuthGate  *middlewares.Auth
+	// Idempotency повторяет сохранённые ответы на POST и PUT с Idempotency-Key;
+	// ставится после Protected, чтобы ключи принадлежали пользователю
+	Idempotency fiber.Handler
+}
+
+func SetupRoutes(app *fiber.App, h Handlers) {
 	app.Get("/", func(c *fiber.Ctx) error {
-		logger.Info("get hi from /")
+		logger.FromCtx(c).Info("get hi from /")
 		return c.SendString("another hi")
 	})
 
-	authService := services.NewAuthService(db)
-	authHandler := handlers.NewAuthHandler(authService)
-	middlewares.InjectAuthService(authService)
+	// WebSocket: токен проверяет сам обработчик, из query или первого сообщения
+	app.Get("/ws", h.WS.Connect)
 
 	api := app.Group("/api")
 
-	// Публичные маршруты
-	//api.Get("/logs", handlers.GetLogs(es, logger))
+	// SSE вместо WebSocket; токен, как и у /ws, проверяет сам обработчик
+	api.Get("/events/stream", h.Events.Stream)
+
+	// Маршруты администратора
+	api.Get("/logs", h.AuthGate.Protected(), middlewares.RequireRole("admin"), h.Logs)
+
+	admin := api.Group("/admin", h.AuthGate.Protected(), middlewares.RequireRole("admin"), h.Idempotency)
+	admin.Get("/jobs", h.Jobs.List)
+	admin.Post("/jobs/:id/retry", h.Jobs.Retry)
+	admin.Post("/jobs/:id/cancel", h.Jobs.Cancel)
+	admin.Get("/scheduler/runs", h.Scheduler.Runs)
 
 	auth := api.Group("/auth")
 
-	auth.Post("/register", authHandler.Register)
-	auth.Post("/login", authHandler.Login)
-	auth.Post("/refresh", authHandler.Refresh)
-	auth.Post("/logout", authHandler.Logout)
+	// без Idempotency: в ответе токены, хранить и повторять их нельзя
+	auth.Post("/register", h.Auth.Register)
+	auth.Post("/login", h.Auth.Login)
+	auth.Post("/refresh", h.Auth.Refresh)
+	auth.Post("/logout", h.Auth.Logout)
+
+	// Викторина: состояние игры приходит в WebSocket после game.subscribe
+	games := api.Group("/games", h.AuthGate.Protected(), h.Idempotency)
+	games.Post("/", h.Games.Create)
+	games.Get("/history", h.Games.History)
+	games.Get("/:code", h.Games.Get)
+	games.Post("/:code/players", h.Games.Join)
+	games.Delete("/:code/players/:childId", h.Games.Leave)
+	games.Post("/:code/start", h.Games.Start)
+	games.Post("/:code/answers", h.Games.Answer)
+
+	// Словарь: читают все пользователи, меняют редакторы контента
+	editor := middlewares.RequireRole("editor", "admin")
+	words := api.Group("/words", h.AuthGate.Protected(), h.Idempotency)
+	words.Get("/", h.Words.List)
+	words.Get("/:id", h.Words.Get)
+	words.Post("/", editor, h.Words.Create)
+	words.Put("/:id", editor, h.Words.Update)
+	words.Delete("/:id", editor, h.Words.Delete)
+
+	lessons := api.Group("/lessons/:lessonId", h.AuthGate.Protected(), h.Idempotency)
+	lessons.Get("/words", h.Words.LessonWords)
+	lessons.Put("/words/:wordId", editor, h.Words.Link)
+	lessons.Delete("/words/:wordId", editor, h.Words.Unlink)
+	lessons.Get("/progress", h.Words.Progress)
+
+	// Медиафайлы: загружают редакторы, скачивают по подписанным ссылкам без токена
+	media := api.Group("/media", h.AuthGate.Protected(), h.Idempotency)
+	media.Post("/", editor, h.Media.Upload)
+	media.Get("/:id", h.Media.Get)
+	app.Get(h.MediaPath+"/*", h.Media.Download)
+
+	// Офлайн-синхронизация мобильного приложения
+	api.Post("/sync", h.AuthGate.Protected(), h.Idempotency, h.Sync.Sync)
 
 	// Защищённые маршруты
-	protected := api.Group("/user", middlewares.Protected())
+	protected := api.Group("/user", h.AuthGate.Protected())
 
 	protected.Get("/profile", func(c *fiber.Ctx) error {
 		userID := c.Locals("userID")
-		logger.WithField("userID", userID).Info("accessed protected profile route")
+		logger.FromCtx(c).Info("accessed protected profile route")
 		return c.JSON(fiber.Map{
 			"message": "Защищённый маршрут",
 			"userID":  userID,
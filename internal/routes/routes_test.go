@@ -0,0 +1,280 @@
+package routes_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"engkids/config"
+	"engkids/internal/apptest"
+	"engkids/internal/jobs"
+	pkgjwt "engkids/pkg/jwt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRoot(t *testing.T) {
+	h := apptest.New(t)
+	r := h.Get("/", "")
+	h.ExpectStatus(r, fiber.StatusOK)
+	if string(r.Body) != "another hi" {
+		t.Fatalf("body = %q", r.Body)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	h := apptest.New(t)
+	creds := map[string]string{"email": "parent@example.com", "password": apptest.DefaultPassword}
+
+	r := h.Post("/api/auth/register", creds, "")
+	h.ExpectStatus(r, fiber.StatusCreated)
+	apptest.Golden(t, "register_created", r.Body)
+
+	r = h.Post("/api/auth/register", creds, "")
+	h.ExpectStatus(r, fiber.StatusConflict)
+	apptest.Golden(t, "register_conflict", r.Body)
+
+	r = h.Post("/api/auth/register", map[string]string{"email": "not-an-email", "password": "short"}, "")
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "register_invalid", r.Body)
+
+	r = h.Post("/api/auth/register", "{", "")
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "register_malformed", r.Body)
+}
+
+// Ответ регистрации содержит токены: Idempotency-Key на ней не действует,
+// и повтор с тем же ключом не получает чужие токены из хранилища ключей
+func TestRegisterIgnoresIdempotencyKey(t *testing.T) {
+	h := apptest.New(t)
+	creds := map[string]string{"email": "retry@example.com", "password": apptest.DefaultPassword}
+	register := func() *apptest.Response {
+		return h.Do(apptest.Request{
+			Method:  fiber.MethodPost,
+			Path:    "/api/auth/register",
+			Body:    creds,
+			Headers: map[string]string{"Idempotency-Key": "signup-1"},
+		})
+	}
+
+	h.ExpectStatus(register(), fiber.StatusCreated)
+	retry := register()
+	h.ExpectStatus(retry, fiber.StatusConflict)
+	if retry.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("registration response was replayed: %s", retry.Body)
+	}
+	apptest.Golden(t, "register_conflict", retry.Body)
+}
+
+func TestLogin(t *testing.T) {
+	h := apptest.New(t)
+	h.Register("parent@example.com")
+
+	r := h.Post("/api/auth/login", map[string]string{"email": "parent@example.com", "password": apptest.DefaultPassword}, "")
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "login_ok", r.Body)
+
+	r = h.Do(apptest.Request{
+		Method:  fiber.MethodPost,
+		Path:    "/api/auth/login",
+		Body:    map[string]string{"email": "parent@example.com", "password": "wrong-password1"},
+		Headers: map[string]string{fiber.HeaderAcceptLanguage: "en"},
+	})
+	h.ExpectStatus(r, fiber.StatusUnauthorized)
+	apptest.Golden(t, "login_invalid_en", r.Body)
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	h := apptest.New(t)
+	s := h.Register("parent@example.com")
+
+	r := h.Post("/api/auth/refresh", map[string]string{"refresh_token": s.RefreshToken}, "")
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "refresh_ok", r.Body)
+
+	// старый токен после ротации недействителен
+	r = h.Post("/api/auth/refresh", map[string]string{"refresh_token": s.RefreshToken}, "")
+	h.ExpectStatus(r, fiber.StatusUnauthorized)
+	apptest.Golden(t, "refresh_invalid", r.Body)
+
+	current := h.Login("parent@example.com")
+	r = h.Post("/api/auth/logout", map[string]string{"refresh_token": current.RefreshToken}, "")
+	h.ExpectStatus(r, fiber.StatusOK)
+
+	r = h.Post("/api/auth/logout", map[string]string{"refresh_token": current.RefreshToken}, "")
+	h.ExpectStatus(r, fiber.StatusUnauthorized)
+}
+
+func TestProfile(t *testing.T) {
+	h := apptest.New(t)
+	s := h.Register("parent@example.com")
+
+	r := h.Get("/api/user/profile", s.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "profile_ok", r.Body)
+
+	r = h.Get("/api/user/profile", "")
+	h.ExpectStatus(r, fiber.StatusUnauthorized)
+	apptest.Golden(t, "profile_token_missing", r.Body)
+
+	r = h.Do(apptest.Request{
+		Method:  fiber.MethodGet,
+		Path:    "/api/user/profile",
+		Headers: map[string]string{fiber.HeaderAuthorization: "Token abc"},
+	})
+	h.ExpectStatus(r, fiber.StatusUnauthorized)
+	apptest.Golden(t, "profile_token_malformed", r.Body)
+}
+
+func TestProfileRefreshesExpiredToken(t *testing.T) {
+	h := apptest.New(t)
+	s := h.Register("parent@example.com")
+	expired := expiredToken(t, s)
+
+	r := h.Get("/api/user/profile", expired)
+	h.ExpectStatus(r, fiber.StatusUnauthorized)
+	apptest.Golden(t, "profile_token_expired", r.Body)
+
+	r = h.Do(apptest.Request{
+		Method: fiber.MethodGet,
+		Path:   "/api/user/profile",
+		Token:  expired,
+		Headers: map[string]string{
+			"X-Refresh-Token": s.RefreshToken,
+		},
+	})
+	h.ExpectStatus(r, fiber.StatusOK)
+	if r.Header.Get("X-New-Access-Token") == "" || r.Header.Get("X-New-Refresh-Token") == "" {
+		t.Fatalf("new tokens not returned: %v", r.Header)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	h := apptest.New(t)
+	h.WriteLogs(
+		logrus.Fields{"time": "2026-01-02T10:00:00Z", "level": "info", "msg": "request completed", "request_id": "req-1", "path": "/api/auth/login"},
+		logrus.Fields{"time": "2026-01-02T10:00:01Z", "level": "error", "msg": "request failed", "request_id": "req-2", "path": "/api/auth/refresh"},
+	)
+	admin := h.CreateUser("admin@example.com", "admin")
+	parent := h.Register("parent@example.com")
+
+	r := h.Get("/api/logs?level=error", admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "logs_ok", r.Body)
+
+	r = h.Get("/api/logs?from=yesterday", admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "logs_invalid_query", r.Body)
+
+	r = h.Get("/api/logs", parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusForbidden)
+	apptest.Golden(t, "logs_forbidden", r.Body)
+}
+
+func TestUnknownRoutes(t *testing.T) {
+	h := apptest.New(t)
+
+	r := h.Get("/api/unknown", "")
+	h.ExpectStatus(r, fiber.StatusNotFound)
+	apptest.Golden(t, "not_found", r.Body)
+
+	r = h.Get("/api/auth/login", "")
+	h.ExpectStatus(r, fiber.StatusMethodNotAllowed)
+	apptest.Golden(t, "method_not_allowed", r.Body)
+}
+
+func TestMetrics(t *testing.T) {
+	h := apptest.New(t)
+	h.Get("/", "")
+
+	r := h.Get("/metrics", "")
+	h.ExpectStatus(r, fiber.StatusOK)
+	if !strings.Contains(string(r.Body), "engkids_http_requests_total") {
+		t.Fatalf("metrics missing:\n%s", r.Body)
+	}
+}
+
+// expiredToken подписывает access-токен сессии с истёкшим сроком
+func expiredToken(t *testing.T, s *apptest.Session) string {
+	t.Helper()
+	past := time.Now().Add(-time.Hour)
+	claims := &pkgjwt.Claims{
+		UserID: s.User.ID,
+		Email:  s.User.Email,
+		Role:   s.User.Role,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: past.Unix(),
+			IssuedAt:  past.Add(-time.Hour).Unix(),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
+		SignedString([]byte(config.GetEnv("JWT_SECRET_KEY", "your-secret-key")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAdminJobs(t *testing.T) {
+	h := apptest.New(t)
+	admin := h.CreateUser("admin@example.com", "admin")
+	parent := h.Register("parent@example.com")
+
+	job, err := h.App.Jobs.Enqueue(t.Context(), "email.welcome", map[string]uint{"user_id": parent.User.ID}, jobs.Delay(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/admin/jobs/" + strconv.Itoa(int(job.ID))
+
+	r := h.Get("/api/admin/jobs?status=queued", admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "jobs_list", r.Body)
+
+	r = h.Get("/api/admin/jobs?status=unknown", admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "jobs_invalid_query", r.Body)
+
+	r = h.Post(path+"/retry", nil, admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusConflict)
+	apptest.Golden(t, "jobs_retry_conflict", r.Body)
+
+	r = h.Post(path+"/cancel", nil, admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "jobs_cancel", r.Body)
+
+	r = h.Post(path+"/retry", nil, admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+
+	r = h.Post("/api/admin/jobs/999/cancel", nil, admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusNotFound)
+	apptest.Golden(t, "jobs_not_found", r.Body)
+
+	r = h.Get("/api/admin/jobs", parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusForbidden)
+}
+
+func TestAdminSchedulerRuns(t *testing.T) {
+	h := apptest.New(t)
+	admin := h.CreateUser("admin@example.com", "admin")
+	parent := h.Register("parent@example.com")
+
+	// первый вызов планирует задачи, второй — в начале часа — запускает очистку токенов
+	start := time.Date(2024, 3, 15, 10, 59, 0, 0, time.UTC)
+	h.App.Scheduler.RunDue(t.Context(), start)
+	if n := h.App.Scheduler.RunDue(t.Context(), start.Add(time.Minute)); n != 1 {
+		t.Fatalf("ran %d tasks, want 1", n)
+	}
+
+	r := h.Get("/api/admin/scheduler/runs?task=purge_refresh_tokens", admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "scheduler_runs", r.Body)
+
+	r = h.Get("/api/admin/scheduler/runs?limit=0", admin.AccessToken)
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+
+	r = h.Get("/api/admin/scheduler/runs", parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusForbidden)
+}
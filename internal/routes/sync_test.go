@@ -0,0 +1,78 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"engkids/internal/apptest"
+	"engkids/internal/dto"
+	"engkids/internal/events"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSync(t *testing.T) {
+	h := apptest.New(t)
+	parent := h.Register("parent@example.com")
+	other := h.Register("other@example.com")
+	child := h.Child(parent.User.ID, "Маша", 7)
+	stranger := h.Child(other.User.ID, "Петя", 8)
+	words := h.LessonWords(3, "cat", "кошка")
+
+	ops := []map[string]any{
+		{"id": "op-1", "type": "attempt", "child_id": child.ID, "client_time": "2024-03-15T09:00:00Z", "lesson_id": 3, "score": 70, "completed": true},
+		{"id": "op-2", "type": "attempt", "child_id": child.ID, "client_time": "2024-03-15T09:30:00Z", "lesson_id": 3, "score": 95, "completed": true},
+		{"id": "op-3", "type": "review", "child_id": child.ID, "client_time": "2024-03-15T09:40:00Z", "word_id": words[0].ID, "grade": 5},
+		{"id": "op-4", "type": "setting", "child_id": child.ID, "client_time": "2024-03-15T09:50:00Z", "key": "daily_goal", "value": 3},
+		{"id": "op-5", "type": "setting", "child_id": child.ID, "client_time": "2024-03-15T09:45:00Z", "key": "daily_goal", "value": 5},
+		{"id": "op-6", "type": "setting", "child_id": stranger.ID, "client_time": "2024-03-15T09:50:00Z", "key": "daily_goal", "value": 1},
+		{"id": "op-7", "type": "setting", "child_id": child.ID, "client_time": "2024-03-15T09:50:00Z", "key": "Bad Key", "value": 1},
+	}
+	r := h.Post("/api/sync", map[string]any{"operations": ops}, parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "sync_batch", r.Body)
+
+	// два прохождения урока — два события LessonCompleted для родителей
+	due, err := h.Repos.Outbox.ClaimDue(t.Context(), time.Now(), time.Minute, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var completed int
+	for _, e := range due {
+		if e.Type == events.TypeLessonCompleted {
+			completed++
+		}
+	}
+	if completed != 2 {
+		t.Errorf("got %d lesson.completed events, want 2", completed)
+	}
+
+	// приложение не получило ответ и отправило пакет ещё раз
+	var first dto.SyncResponse
+	if err := json.Unmarshal(r.Body, &first); err != nil {
+		t.Fatal(err)
+	}
+	r = h.Post("/api/sync", map[string]any{"operations": ops[:4], "cursor": first.Cursor}, parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	var resent dto.SyncResponse
+	if err := json.Unmarshal(r.Body, &resent); err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range resent.Results {
+		if res.Status != "duplicate" {
+			t.Errorf("resent %s: %s", res.ID, res.Status)
+		}
+	}
+	if resent.Cursor == "" || len(resent.Changes.Progress) != 1 {
+		t.Errorf("resent changes: %+v", resent.Changes)
+	}
+
+	r = h.Post("/api/sync", map[string]any{"cursor": "abc"}, parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "sync_invalid_cursor", r.Body)
+
+	r = h.Post("/api/sync", map[string]any{"operations": []map[string]any{{"id": "x", "type": "purchase", "child_id": child.ID}}}, parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "sync_validation", r.Body)
+}
@@ -0,0 +1,181 @@
+package routes_test
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"engkids/internal/apptest"
+	"engkids/internal/dto"
+	"engkids/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestWords(t *testing.T) {
+	h := apptest.New(t)
+	editor := h.CreateUser("editor@example.com", "editor")
+	parent := h.Register("parent@example.com")
+
+	var picture, sound dto.MediaAsset
+	upload(h, testPNG(t, 64, 64), editor.AccessToken).JSON(t, &picture)
+	// кадр MP3 без тега ID3
+	upload(h, append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 128)...), editor.AccessToken).JSON(t, &sound)
+
+	cat := map[string]any{
+		"headword":          " cat ",
+		"part_of_speech":    "noun",
+		"difficulty":        "pre-a1",
+		"tags":              []string{"Animals", "pets", "animals"},
+		"examples":          []string{"The cat is black."},
+		"image_media_id":    picture.ID,
+		"audio_uk_media_id": sound.ID,
+		"translations":      []map[string]string{{"language": "ru", "text": "кошка"}, {"language": "de", "text": "Katze"}},
+	}
+	r := h.Post("/api/words", cat, parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusForbidden)
+
+	r = h.Post("/api/words", cat, editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusCreated)
+	apptest.Golden(t, "words_created", r.Body)
+	var created dto.Word
+	r.JSON(t, &created)
+	if created.ImageURL == "" || created.AudioUKURL == "" || created.AudioUSURL != "" || created.MediaExpiresAt == nil {
+		t.Fatalf("media links = %+v", created)
+	}
+	// ссылки подписываются при каждом чтении и открывают файл
+	r = h.Get("/api/words/"+strconv.Itoa(int(created.ID)), parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	var read dto.Word
+	r.JSON(t, &read)
+	h.ExpectStatus(h.Get(read.ImageURL, ""), fiber.StatusOK)
+
+	r = h.Post("/api/words", cat, editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusConflict)
+	apptest.Golden(t, "words_exists", r.Body)
+
+	// файл другого вида или не загруженный файл
+	r = h.Post("/api/words", map[string]any{
+		"headword":          "dog",
+		"image_media_id":    sound.ID,
+		"audio_us_media_id": 999,
+		"translations":      []map[string]string{{"language": "ru", "text": "собака"}},
+	}, editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "words_invalid_media", r.Body)
+	r = h.Post("/api/words", map[string]any{
+		"headword":     "dog",
+		"translations": []map[string]string{{"language": "ru", "text": "собака"}, {"language": "ru", "text": "пёс"}},
+	}, editor.AccessToken)
+	h.ExpectStatus(r, fiber.StatusBadRequest)
+	apptest.Golden(t, "words_duplicate_translation", r.Body)
+
+	for _, w := range []map[string]any{
+		{"headword": "dog", "part_of_speech": "noun", "tags": []string{"animals"}, "translations": []map[string]string{{"language": "ru", "text": "собака"}}},
+		{"headword": "run", "part_of_speech": "verb", "difficulty": "A2", "translations": []map[string]string{{"language": "ru", "text": "бежать"}}},
+	} {
+		h.ExpectStatus(h.Post("/api/words", w, editor.AccessToken), fiber.StatusCreated)
+	}
+
+	// родители и приложение читают словарь
+	for query, want := range map[string][]string{
+		"":                     {"cat", "dog", "run"},
+		"?q=CA":                {"cat"},
+		"?tag=animals":         {"cat", "dog"},
+		"?part_of_speech=verb": {"run"},
+		"?difficulty=a2":       {"run"},
+		"?limit=1&offset=1":    {"dog"},
+	} {
+		r = h.Get("/api/words"+query, parent.AccessToken)
+		h.ExpectStatus(r, fiber.StatusOK)
+		var list []dto.Word
+		r.JSON(t, &list)
+		var got []string
+		for _, w := range list {
+			got = append(got, w.Headword)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("GET /api/words%s = %v, want %v", query, got, want)
+		}
+	}
+	h.ExpectStatus(h.Get("/api/words?limit=1000", parent.AccessToken), fiber.StatusBadRequest)
+
+	path := "/api/words/" + strconv.Itoa(int(created.ID))
+	r = h.Do(apptest.Request{Method: fiber.MethodPut, Path: path, Token: editor.AccessToken, Body: map[string]any{
+		"headword":     "cat",
+		"difficulty":   "A1",
+		"translations": []map[string]string{{"language": "ru", "text": "кот"}},
+	}})
+	h.ExpectStatus(r, fiber.StatusOK)
+	var updated dto.Word
+	r.JSON(t, &updated)
+	if len(updated.Translations) != 1 || updated.Translation("ru") != "кот" || len(updated.Tags) != 0 ||
+		updated.ImageMediaID != nil || updated.ImageURL != "" {
+		t.Errorf("updated word = %+v", updated)
+	}
+	r = h.Do(apptest.Request{Method: fiber.MethodPut, Path: "/api/words/999", Token: editor.AccessToken, Body: map[string]any{
+		"headword":     "ghost",
+		"translations": []map[string]string{{"language": "ru", "text": "призрак"}},
+	}})
+	h.ExpectStatus(r, fiber.StatusNotFound)
+
+	// состав урока
+	link := "/api/lessons/7/words/" + strconv.Itoa(int(created.ID))
+	h.ExpectStatus(h.Do(apptest.Request{Method: fiber.MethodPut, Path: link, Token: parent.AccessToken}), fiber.StatusForbidden)
+	for range 2 {
+		h.ExpectStatus(h.Do(apptest.Request{Method: fiber.MethodPut, Path: link, Token: editor.AccessToken}), fiber.StatusNoContent)
+	}
+	h.ExpectStatus(h.Do(apptest.Request{Method: fiber.MethodPut, Path: "/api/lessons/7/words/999", Token: editor.AccessToken}), fiber.StatusNotFound)
+	r = h.Get("/api/lessons/7/words", parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	var lesson []dto.Word
+	r.JSON(t, &lesson)
+	if len(lesson) != 1 || lesson[0].ID != created.ID {
+		t.Fatalf("lesson words = %+v", lesson)
+	}
+
+	h.ExpectStatus(h.Do(apptest.Request{Method: fiber.MethodDelete, Path: link, Token: editor.AccessToken}), fiber.StatusNoContent)
+	r = h.Do(apptest.Request{Method: fiber.MethodDelete, Path: link, Token: editor.AccessToken})
+	h.ExpectStatus(r, fiber.StatusNotFound)
+	apptest.Golden(t, "words_not_in_lesson", r.Body)
+
+	h.ExpectStatus(h.Do(apptest.Request{Method: fiber.MethodDelete, Path: path, Token: editor.AccessToken}), fiber.StatusNoContent)
+	h.ExpectStatus(h.Get(path, parent.AccessToken), fiber.StatusNotFound)
+
+	// омографы различаются частью речи
+	run := map[string]any{"headword": "run", "part_of_speech": "noun", "translations": []map[string]string{{"language": "ru", "text": "пробежка"}}}
+	h.ExpectStatus(h.Post("/api/words", run, editor.AccessToken), fiber.StatusCreated)
+	h.ExpectStatus(h.Post("/api/words", run, editor.AccessToken), fiber.StatusConflict)
+}
+
+func TestLessonWordsProgress(t *testing.T) {
+	h := apptest.New(t)
+	parent := h.Register("parent@example.com")
+	other := h.Register("other@example.com")
+	child := h.Child(parent.User.ID, "Маша", 7)
+	words := h.LessonWords(5, "cat", "кошка", "dog", "собака", "bird", "птица")
+
+	reviewedAt := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	for _, review := range []models.WordReview{
+		{ChildID: child.ID, WordID: words[0].ID, Box: 5, Grade: 5, Reviews: 6, ReviewedAt: reviewedAt, DueAt: reviewedAt.AddDate(0, 0, 16)},
+		{ChildID: child.ID, WordID: words[1].ID, Box: 1, Grade: 2, Reviews: 1, ReviewedAt: reviewedAt, DueAt: reviewedAt.AddDate(0, 0, 1)},
+	} {
+		if err := h.Repos.Reviews.Save(t.Context(), &review); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := "/api/lessons/5/progress?child_id=" + strconv.Itoa(int(child.ID))
+	r := h.Get(path, parent.AccessToken)
+	h.ExpectStatus(r, fiber.StatusOK)
+	apptest.Golden(t, "lesson_words_progress", r.Body)
+	var progress dto.LessonWordsProgress
+	r.JSON(t, &progress)
+	if progress.Total != 3 || progress.Learned != 1 || progress.Words[2].Box != 0 || progress.Words[2].ReviewedAt != nil {
+		t.Errorf("progress = %+v", progress)
+	}
+
+	h.ExpectStatus(h.Get(path, other.AccessToken), fiber.StatusNotFound)
+	h.ExpectStatus(h.Get("/api/lessons/5/progress", parent.AccessToken), fiber.StatusBadRequest)
+}
@@ -0,0 +1,133 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"engkids/internal/apptest"
+	"engkids/internal/events"
+	"engkids/internal/realtime"
+
+	ws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+func dialWS(t *testing.T, addr, query string) *ws.Conn {
+	t.Helper()
+	conn, resp, err := ws.DefaultDialer.Dial("ws://"+addr+"/ws"+query, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial: %v (status %d)", err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readEnvelope(t *testing.T, conn *ws.Conn) realtime.Envelope {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var env realtime.Envelope
+	if err := conn.ReadJSON(&env); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if env.V != realtime.Version {
+		t.Fatalf("envelope version %d", env.V)
+	}
+	return env
+}
+
+func TestWebSocketFamilyEvents(t *testing.T) {
+	h := apptest.New(t)
+	addr := h.Listen()
+	parent := h.Register("parent@example.com")
+	other := h.Register("other@example.com")
+	child := h.Child(parent.User.ID, "Маша", 7)
+
+	// токен в query
+	conn := dialWS(t, addr, "?token="+parent.AccessToken)
+	ready := readEnvelope(t, conn)
+	var payload realtime.ReadyPayload
+	if err := json.Unmarshal(ready.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if ready.Type != realtime.TypeReady || payload.UserID != parent.User.ID || payload.Rooms[0] != realtime.FamilyRoom(parent.User.ID) {
+		t.Fatalf("unexpected ready message: %+v %+v", ready, payload)
+	}
+
+	// токен первым сообщением
+	otherConn := dialWS(t, addr, "")
+	if err := otherConn.WriteJSON(map[string]any{"v": 1, "type": "auth", "payload": map[string]string{"token": other.AccessToken}}); err != nil {
+		t.Fatal(err)
+	}
+	if env := readEnvelope(t, otherConn); env.Type != realtime.TypeReady {
+		t.Fatalf("auth by message: %+v", env)
+	}
+
+	for _, e := range []events.Event{
+		events.LessonCompleted{ChildID: child.ID, LessonID: 3, Score: 90, CompletedAt: time.Now()},
+		events.StreakAtRisk{ChildID: child.ID, Streak: 4, Date: "2024-03-15"},
+	} {
+		if err := events.Record(t.Context(), h.Repos.Outbox, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.App.Dispatcher.Notify()
+
+	lesson := readEnvelope(t, conn)
+	var completed events.LessonCompleted
+	if err := json.Unmarshal(lesson.Payload, &completed); err != nil {
+		t.Fatal(err)
+	}
+	if lesson.Type != realtime.TypeLessonCompleted || lesson.Seq != 1 || completed.ChildID != child.ID || completed.Score != 90 {
+		t.Fatalf("unexpected lesson message: %+v", lesson)
+	}
+	if streak := readEnvelope(t, conn); streak.Type != realtime.TypeStreakAtRisk || streak.Seq != 2 {
+		t.Fatalf("unexpected streak message: %+v", streak)
+	}
+
+	// события чужой семьи не приходят
+	otherConn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, msg, err := otherConn.ReadMessage(); err == nil {
+		t.Fatalf("other family received %s", msg)
+	}
+}
+
+func TestWebSocketRejectsInvalidToken(t *testing.T) {
+	h := apptest.New(t)
+	addr := h.Listen()
+	parent := h.Register("parent@example.com")
+
+	_, resp, err := ws.DefaultDialer.Dial("ws://"+addr+"/ws?token=garbage", nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 before upgrade, got err=%v resp=%v", err, resp)
+	}
+
+	conn := dialWS(t, addr, "")
+	if err := conn.WriteJSON(map[string]any{"v": 1, "type": "auth", "payload": map[string]string{"token": expiredToken(t, parent)}}); err != nil {
+		t.Fatal(err)
+	}
+	env := readEnvelope(t, conn)
+	var e realtime.ErrorPayload
+	if err := json.Unmarshal(env.Payload, &e); err != nil {
+		t.Fatal(err)
+	}
+	if env.Type != realtime.TypeError || e.Code != "AUTH_TOKEN_EXPIRED" || e.Message == "" {
+		t.Fatalf("unexpected error message: %+v %+v", env, e)
+	}
+	_, _, err = conn.ReadMessage()
+	if !ws.IsCloseError(err, ws.ClosePolicyViolation) {
+		t.Fatalf("expected policy violation close, got %v", err)
+	}
+}
+
+func TestWebSocketRequiresUpgrade(t *testing.T) {
+	h := apptest.New(t)
+	r := h.Get("/ws", "")
+	h.ExpectStatus(r, fiber.StatusUpgradeRequired)
+	apptest.Golden(t, "ws_upgrade_required", r.Body)
+}
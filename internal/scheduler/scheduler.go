@@ -0,0 +1,277 @@
+// Package scheduler запускает периодические задачи по cron-расписанию. Задачи
+// выполняет только лидер — экземпляр, удерживающий блокировку в Postgres, —
+// поэтому приложение можно масштабировать без повторных запусков
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/cron"
+	"engkids/pkg/logger"
+	"engkids/pkg/metrics"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Leader — выбор единственного экземпляра, который выполняет задачи
+type Leader interface {
+	// Lead захватывает лидерство или подтверждает, что оно ещё удерживается
+	Lead(ctx context.Context) (bool, error)
+	// Resign отдаёт лидерство другим экземплярам
+	Resign(ctx context.Context) error
+}
+
+// LocalLeader — лидерство без координации, для одного экземпляра и тестов
+type LocalLeader struct{}
+
+func (LocalLeader) Lead(context.Context) (bool, error) { return true, nil }
+func (LocalLeader) Resign(context.Context) error       { return nil }
+
+// Options — настройки планировщика
+type Options struct {
+	Location    *time.Location    // часовой пояс расписаний; по умолчанию UTC
+	LeaderCheck time.Duration     // как часто проверять лидерство
+	TaskTimeout time.Duration     // дедлайн одного запуска
+	Metrics     *metrics.Registry // nil — метрики не публикуются
+}
+
+func (o *Options) setDefaults() {
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	if o.LeaderCheck <= 0 {
+		o.LeaderCheck = 15 * time.Second
+	}
+	if o.TaskTimeout <= 0 {
+		o.TaskTimeout = 30 * time.Minute
+	}
+	if o.Metrics == nil {
+		o.Metrics = metrics.NewRegistry()
+	}
+}
+
+// Task — периодическая задача
+type Task func(ctx context.Context) error
+
+type entry struct {
+	name     string
+	schedule *cron.Schedule
+	run      Task
+	next     time.Time
+}
+
+// Scheduler выполняет зарегистрированные задачи по расписанию и записывает
+// каждый запуск в ScheduledRunRepository
+type Scheduler struct {
+	runs     repositories.ScheduledRunRepository
+	leader   Leader
+	opts     Options
+	instance string
+
+	mu      sync.Mutex
+	entries []*entry
+	leading bool
+	checked time.Time
+
+	runsTotal   *metrics.CounterVec
+	runDuration *metrics.HistogramVec
+}
+
+func New(runs repositories.ScheduledRunRepository, leader Leader, opts Options) *Scheduler {
+	opts.setDefaults()
+	if leader == nil {
+		leader = LocalLeader{}
+	}
+	host, _ := os.Hostname()
+	return &Scheduler{
+		runs:     runs,
+		leader:   leader,
+		opts:     opts,
+		instance: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8]),
+		runsTotal: opts.Metrics.NewCounterVec(
+			"engkids_scheduler_runs_total",
+			"Количество запусков задач планировщика.",
+			"task", "status",
+		),
+		runDuration: opts.Metrics.NewHistogramVec(
+			"engkids_scheduler_run_duration_seconds",
+			"Время выполнения задачи планировщика в секундах.",
+			nil,
+			"task",
+		),
+	}
+}
+
+// Add регистрирует задачу name с расписанием expr, например "0 3 * * *"
+func (s *Scheduler) Add(name, expr string, run Task) error {
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, &entry{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Run выполняет задачи, пока не отменён ctx. Запуски, пропущенные пока
+// экземпляр не был лидером или был остановлен, не догоняются
+func (s *Scheduler) Run(ctx context.Context) {
+	defer func() {
+		if err := s.leader.Resign(context.WithoutCancel(ctx)); err != nil {
+			logger.FromContext(ctx).WithError(err).Warn("Failed to resign scheduler leadership")
+		}
+	}()
+
+	for ctx.Err() == nil {
+		s.RunDue(ctx, time.Now())
+
+		wait := time.Until(s.wakeAt())
+		select {
+		case <-ctx.Done():
+		case <-time.After(max(wait, 0)):
+		}
+	}
+}
+
+// wakeAt — ближайшее из времени следующего запуска и следующей проверки лидерства
+func (s *Scheduler) wakeAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at := s.checked.Add(s.opts.LeaderCheck)
+	for _, e := range s.entries {
+		if !e.next.IsZero() && e.next.Before(at) {
+			at = e.next
+		}
+	}
+	return at
+}
+
+// RunDue выполняет задачи, время которых наступило к now, если экземпляр —
+// лидер, и возвращает число запусков. Первый вызов только планирует задачи
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) int {
+	leading := s.lead(ctx, now)
+
+	s.mu.Lock()
+	local := now.In(s.opts.Location)
+	var due []*entry
+	var scheduled []time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			e.next = e.schedule.Next(local)
+			continue
+		}
+		if e.next.After(now) {
+			continue
+		}
+		if leading {
+			due = append(due, e)
+			scheduled = append(scheduled, e.next)
+		}
+		e.next = e.schedule.Next(local)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i, e := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.execute(ctx, e, scheduled[i])
+		}()
+	}
+	wg.Wait()
+	return len(due)
+}
+
+// lead проверяет лидерство не чаще LeaderCheck; при ошибке экземпляр считает
+// себя ведомым, чтобы не выполнять задачи параллельно с другим лидером
+func (s *Scheduler) lead(ctx context.Context, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.checked.IsZero() && now.Sub(s.checked) < s.opts.LeaderCheck {
+		return s.leading
+	}
+	s.checked = now
+
+	leading, err := s.leader.Lead(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.FromContext(ctx).WithError(err).Error("Failed to check scheduler leadership")
+		}
+		leading = false
+	}
+	if leading != s.leading {
+		logger.FromContext(ctx).WithFields(logrus.Fields{
+			"instance": s.instance,
+			"leader":   leading,
+		}).Info("Scheduler leadership changed")
+	}
+	s.leading = leading
+	return leading
+}
+
+func (s *Scheduler) execute(ctx context.Context, e *entry, scheduledAt time.Time) {
+	log := logger.FromContext(ctx).WithFields(logrus.Fields{
+		"task":         e.name,
+		"scheduled_at": scheduledAt,
+	})
+	// запись о запуске сохраняем, даже если планировщик останавливается
+	store := context.WithoutCancel(ctx)
+
+	run := &models.ScheduledRun{
+		Task:        e.name,
+		Instance:    s.instance,
+		Status:      models.ScheduledRunRunning,
+		ScheduledAt: scheduledAt,
+		StartedAt:   time.Now(),
+	}
+	if err := s.runs.Create(store, run); err != nil {
+		log.WithError(err).Error("Failed to record scheduled run")
+	}
+
+	err := s.call(ctx, e.run)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	if err != nil {
+		run.Status, run.Error = models.ScheduledRunFailed, err.Error()
+		log.WithError(err).WithField("duration_ms", run.DurationMs).Error("Scheduled task failed")
+	} else {
+		run.Status = models.ScheduledRunSucceeded
+		log.WithField("duration_ms", run.DurationMs).Info("Scheduled task finished")
+	}
+	s.runsTotal.WithLabelValues(e.name, run.Status).Inc()
+	s.runDuration.WithLabelValues(e.name).Observe(finished.Sub(run.StartedAt).Seconds())
+
+	if run.ID != 0 {
+		if err := s.runs.Update(store, run); err != nil {
+			log.WithError(err).Error("Failed to record scheduled run")
+		}
+	}
+}
+
+// call выполняет задачу с дедлайном и превращает панику в ошибку
+func (s *Scheduler) call(ctx context.Context, run Task) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.TaskTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// History возвращает последние запуски задачи task (пустая — всех задач)
+func (s *Scheduler) History(ctx context.Context, task string, limit int) ([]models.ScheduledRun, error) {
+	return s.runs.List(ctx, task, limit)
+}
@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"engkids/internal/models"
+	"engkids/internal/repositories/memory"
+)
+
+type fakeLeader struct {
+	leading bool
+	calls   int
+}
+
+func (l *fakeLeader) Lead(context.Context) (bool, error) {
+	l.calls++
+	return l.leading, nil
+}
+
+func (l *fakeLeader) Resign(context.Context) error {
+	l.leading = false
+	return nil
+}
+
+func TestRunDueRecordsRuns(t *testing.T) {
+	ctx := t.Context()
+	repos := memory.New()
+	s := New(repos.ScheduledRuns, LocalLeader{}, Options{})
+
+	var ok, failed int
+	if err := s.Add("ok", "*/5 * * * *", func(context.Context) error { ok++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("broken", "@hourly", func(context.Context) error { failed++; return errors.New("boom") }); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 3, 15, 9, 58, 0, 0, time.UTC)
+	if n := s.RunDue(ctx, start); n != 0 {
+		t.Fatalf("first call ran %d tasks, want only planning", n)
+	}
+	if n := s.RunDue(ctx, start.Add(time.Minute)); n != 0 {
+		t.Fatalf("ran %d tasks before they were due", n)
+	}
+	if n := s.RunDue(ctx, start.Add(2*time.Minute)); n != 2 {
+		t.Fatalf("ran %d tasks at 10:00, want 2", n)
+	}
+	if ok != 1 || failed != 1 {
+		t.Fatalf("calls: ok=%d broken=%d", ok, failed)
+	}
+
+	runs, err := s.History(ctx, "broken", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("got %d runs of broken task", len(runs))
+	}
+	run := runs[0]
+	if run.Status != models.ScheduledRunFailed || run.Error != "boom" || run.FinishedAt == nil {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if !run.ScheduledAt.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("scheduled_at = %s", run.ScheduledAt)
+	}
+
+	runs, _ = s.History(ctx, "ok", 10)
+	if len(runs) != 1 || runs[0].Status != models.ScheduledRunSucceeded {
+		t.Errorf("unexpected ok runs: %+v", runs)
+	}
+}
+
+func TestRunDueOnlyOnLeader(t *testing.T) {
+	ctx := t.Context()
+	repos := memory.New()
+	leader := &fakeLeader{}
+	s := New(repos.ScheduledRuns, leader, Options{LeaderCheck: time.Minute})
+
+	calls := 0
+	if err := s.Add("task", "* * * * *", func(context.Context) error { calls++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 3, 15, 10, 0, 30, 0, time.UTC)
+	s.RunDue(ctx, start)
+	// пропущенный ведомым запуск не догоняется, когда экземпляр становится лидером
+	if n := s.RunDue(ctx, start.Add(time.Minute)); n != 0 || calls != 0 {
+		t.Fatalf("follower ran task: n=%d calls=%d", n, calls)
+	}
+
+	leader.leading = true
+	if n := s.RunDue(ctx, start.Add(2*time.Minute)); n != 1 || calls != 1 {
+		t.Fatalf("leader: n=%d calls=%d", n, calls)
+	}
+	// лидерство проверяется не чаще LeaderCheck
+	if leader.calls != 3 {
+		t.Errorf("Lead called %d times, want 3", leader.calls)
+	}
+	s.RunDue(ctx, start.Add(2*time.Minute+10*time.Second))
+	if leader.calls != 3 {
+		t.Errorf("Lead called %d times within LeaderCheck", leader.calls)
+	}
+}
+
+func TestRunDueInLocation(t *testing.T) {
+	ctx := t.Context()
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	s := New(memory.New().ScheduledRuns, nil, Options{Location: loc})
+
+	calls := 0
+	if err := s.Add("midnight", "@daily", func(context.Context) error { calls++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	s.RunDue(ctx, time.Date(2024, 3, 15, 20, 0, 0, 0, time.UTC))
+	if s.RunDue(ctx, time.Date(2024, 3, 15, 21, 0, 0, 0, time.UTC)); calls != 1 {
+		t.Fatalf("task did not run at local midnight, calls=%d", calls)
+	}
+}
+
+func TestPanicIsRecordedAsFailure(t *testing.T) {
+	ctx := t.Context()
+	repos := memory.New()
+	s := New(repos.ScheduledRuns, nil, Options{})
+	if err := s.Add("panics", "* * * * *", func(context.Context) error { panic("oops") }); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 3, 15, 10, 0, 30, 0, time.UTC)
+	s.RunDue(ctx, start)
+	s.RunDue(ctx, start.Add(time.Minute))
+
+	runs, _ := s.History(ctx, "", 10)
+	if len(runs) != 1 || runs[0].Status != models.ScheduledRunFailed || runs[0].Error != "panic: oops" {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+}
+
+func TestAddRejectsInvalidExpression(t *testing.T) {
+	s := New(memory.New().ScheduledRuns, nil, Options{})
+	if err := s.Add("bad", "every minute", func(context.Context) error { return nil }); err == nil {
+		t.Fatal("expected error")
+	}
+}
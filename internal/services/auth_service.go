@@ -1,39 +1,53 @@
 package services
 
 import (
+	"context"
 	"engkids/internal/dto"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/events"
 	"engkids/internal/models"
+	"engkids/internal/repositories"
 	"engkids/pkg/jwt"
+	"engkids/pkg/logger"
+	"engkids/pkg/metrics"
+	"engkids/pkg/tracing"
 	"errors"
-	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
-	"log"
 	"time"
 )
 
 type AuthService struct {
-	DB *gorm.DB
+	repos    *repositories.Repositories
+	users    repositories.UserRepository
+	tokens   repositories.RefreshTokenRepository
+	notifier events.Notifier
+	metrics  *metrics.Business
 }
 
-func NewAuthService(db *gorm.DB) *AuthService {
-	return &AuthService{DB: db}
+// NewAuthService создаёт сервис; notifier может быть nil, тогда события
+// доставляются при следующем опросе outbox. m может быть nil — метрики не публикуются
+func NewAuthService(repos *repositories.Repositories, notifier events.Notifier, m *metrics.Business) *AuthService {
+	if m == nil {
+		m = metrics.NewBusiness(metrics.NewRegistry())
+	}
+	return &AuthService{repos: repos, users: repos.Users, tokens: repos.RefreshTokens, notifier: notifier, metrics: m}
 }
 
-func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.FullAuthResponse, error) {
-	var existing models.User
-	if err := s.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
-		return nil, fiber.NewError(fiber.StatusConflict, "Пользователь уже существует")
-	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		log.Println("DB error:", err)
-		return nil, fiber.ErrInternalServerError
+func (s *AuthService) Register(ctx context.Context, req *dto.RegisterRequest) (resp *dto.FullAuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer func() { span.RecordError(err); span.End() }()
+
+	if _, err := s.users.FindByEmail(ctx, req.Email); err == nil {
+		return nil, apperrors.New(apperrors.CodeAuthUserExists)
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		logger.FromContext(ctx).WithError(err).Error("Failed to look up user by email")
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "Хеширование пароля не удалось")
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
 	}
 
 	user := models.User{
@@ -42,51 +56,73 @@ func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.FullAuthResponse,
 		Role:     "user",
 	}
 
-	if err := s.DB.Create(&user).Error; err != nil {
-		log.Println("DB create error:", err)
-		return nil, fiber.ErrInternalServerError
+	err = s.repos.Tx.Transaction(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Users.Create(ctx, &user); err != nil {
+			return err
+		}
+		return events.Record(ctx, repos.Outbox, events.UserRegistered{UserID: user.ID, Role: user.Role})
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, apperrors.New(apperrors.CodeAuthUserExists)
+		}
+		logger.FromContext(ctx).WithError(err).Error("Failed to create user")
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	s.metrics.Registrations.Inc()
+	if s.notifier != nil {
+		s.notifier.Notify()
 	}
 
-	return s.buildFullAuthResponse(&user)
+	return s.buildFullAuthResponse(ctx, &user)
 }
 
-func (s *AuthService) Login(req *dto.LoginRequest) (*dto.FullAuthResponse, error) {
-	var user models.User
-	if err := s.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fiber.NewError(fiber.StatusUnauthorized, "Неверный email или пароль")
+func (s *AuthService) Login(ctx context.Context, req *dto.LoginRequest) (resp *dto.FullAuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer func() { span.RecordError(err); span.End() }()
+
+	user, err := s.users.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			s.metrics.FailedLogins.WithLabelValues("unknown_email").Inc()
+			return nil, apperrors.New(apperrors.CodeAuthInvalidCredentials)
 		}
-		log.Println("DB error:", err)
-		return nil, fiber.ErrInternalServerError
+		logger.FromContext(ctx).WithError(err).Error("Failed to look up user by email")
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, fiber.NewError(fiber.StatusUnauthorized, "Неверный email или пароль")
+		s.metrics.FailedLogins.WithLabelValues("wrong_password").Inc()
+		return nil, apperrors.New(apperrors.CodeAuthInvalidCredentials)
 	}
+	s.metrics.Logins.Inc()
 
-	return s.buildFullAuthResponse(&user)
+	return s.buildFullAuthResponse(ctx, user)
 }
 
-func (s *AuthService) Refresh(oldRefresh string) (*dto.FullAuthResponse, error) {
-	var rt models.RefreshToken
-	err := s.DB.Where("token = ?", oldRefresh).First(&rt).Error
+func (s *AuthService) Refresh(ctx context.Context, oldRefresh string) (resp *dto.FullAuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Refresh")
+	defer func() { span.RecordError(err); span.End() }()
+
+	rt, err := s.tokens.FindByToken(ctx, oldRefresh)
 	if err != nil || rt.ExpiresAt.Before(time.Now()) {
-		return nil, fiber.NewError(fiber.StatusUnauthorized, "Неверный или просроченный refresh токен")
+		return nil, apperrors.New(apperrors.CodeAuthRefreshInvalid)
 	}
 
-	var user models.User
-	if err := s.DB.First(&user, rt.UserID).Error; err != nil {
-		return nil, fiber.ErrInternalServerError
+	user, err := s.users.FindByID(ctx, rt.UserID)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField(logger.FieldUserID, rt.UserID).Error("Failed to load refresh token owner")
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
 	}
-	log.Println("refresh: ", &rt)
-	s.DB.Delete(&rt)
-	return s.buildFullAuthResponse(&user)
+	logger.FromContext(ctx).WithField(logger.FieldUserID, rt.UserID).Info("Refresh token rotated")
+	// новый токен заменяет старый в buildFullAuthResponse: у пользователя он один
+	return s.buildFullAuthResponse(ctx, user)
 }
 
-func (s *AuthService) buildFullAuthResponse(user *models.User) (*dto.FullAuthResponse, error) {
+func (s *AuthService) buildFullAuthResponse(ctx context.Context, user *models.User) (*dto.FullAuthResponse, error) {
 	accessToken, err := jwt.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
-		return nil, fiber.ErrInternalServerError
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
 	}
 
 	refreshToken := uuid.NewString()
@@ -96,13 +132,9 @@ func (s *AuthService) buildFullAuthResponse(user *models.User) (*dto.FullAuthRes
 		Token:     refreshToken,
 		ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
 	}
-	err = s.DB.
-		Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "user_id"}},
-			UpdateAll: true,
-		}).Create(&rt).Error
-	if err != nil {
-		return nil, fiber.ErrInternalServerError
+	if err := s.tokens.Save(ctx, &rt); err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Failed to store refresh token")
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
 	}
 
 	user.Password = ""
@@ -113,13 +145,16 @@ func (s *AuthService) buildFullAuthResponse(user *models.User) (*dto.FullAuthRes
 	}, nil
 }
 
-func (s *AuthService) Logout(refreshToken string) error {
-	result := s.DB.Where("token = ?", refreshToken).Delete(&models.RefreshToken{})
-	if result.Error != nil {
-		return fiber.ErrInternalServerError
-	}
-	if result.RowsAffected == 0 {
-		return fiber.NewError(fiber.StatusUnauthorized, "invalid refresh token")
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer func() { span.RecordError(err); span.End() }()
+
+	if err := s.tokens.DeleteByToken(ctx, refreshToken); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return apperrors.New(apperrors.CodeAuthRefreshInvalid)
+		}
+		logger.FromContext(ctx).WithError(err).Error("Failed to delete refresh token")
+		return apperrors.Wrap(apperrors.CodeInternal, err)
 	}
 	return nil
 }
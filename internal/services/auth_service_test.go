@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"engkids/internal/dto"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/repositories/memory"
+)
+
+func newTestAuthService() *AuthService {
+	return NewAuthService(memory.New(), nil, nil)
+}
+
+func assertCode(t *testing.T, err error, code apperrors.Code) {
+	t.Helper()
+	if !errors.Is(err, apperrors.New(code)) {
+		t.Fatalf("expected %s, got %v", code, err)
+	}
+}
+
+func TestAuthServiceRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	s := newTestAuthService()
+
+	reg, err := s.Register(ctx, &dto.RegisterRequest{Email: "parent@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.AccessToken == "" || reg.RefreshToken == "" || reg.User.ID == 0 || reg.User.Password != "" {
+		t.Fatalf("unexpected response: %+v", reg)
+	}
+
+	_, err = s.Register(ctx, &dto.RegisterRequest{Email: "parent@example.com", Password: "secret123"})
+	assertCode(t, err, apperrors.CodeAuthUserExists)
+
+	_, err = s.Login(ctx, &dto.LoginRequest{Email: "parent@example.com", Password: "wrong"})
+	assertCode(t, err, apperrors.CodeAuthInvalidCredentials)
+
+	_, err = s.Login(ctx, &dto.LoginRequest{Email: "nobody@example.com", Password: "secret123"})
+	assertCode(t, err, apperrors.CodeAuthInvalidCredentials)
+
+	login, err := s.Login(ctx, &dto.LoginRequest{Email: "parent@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.User.ID != reg.User.ID {
+		t.Fatalf("logged in as %d, want %d", login.User.ID, reg.User.ID)
+	}
+
+	// у пользователя один refresh-токен: вход заменяет выданный при регистрации
+	_, err = s.Refresh(ctx, reg.RefreshToken)
+	assertCode(t, err, apperrors.CodeAuthRefreshInvalid)
+}
+
+func TestAuthServiceRefreshRotatesToken(t *testing.T) {
+	ctx := context.Background()
+	s := newTestAuthService()
+
+	reg, err := s.Register(ctx, &dto.RegisterRequest{Email: "parent@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed, err := s.Refresh(ctx, reg.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.RefreshToken == reg.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	_, err = s.Refresh(ctx, reg.RefreshToken)
+	assertCode(t, err, apperrors.CodeAuthRefreshInvalid)
+
+	if err := s.Logout(ctx, refreshed.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	assertCode(t, s.Logout(ctx, refreshed.RefreshToken), apperrors.CodeAuthRefreshInvalid)
+}
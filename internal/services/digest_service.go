@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"engkids/internal/jobs"
+	"engkids/internal/repositories"
+	"engkids/pkg/mailer"
+)
+
+// JobWeeklyDigest — задача отправки итогов недели одному родителю
+const JobWeeklyDigest = "digest.weekly"
+
+// DigestPayload — параметры задачи JobWeeklyDigest
+type DigestPayload struct {
+	UserID uint      `json:"user_id"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// DigestService собирает и отправляет родителям итоги занятий детей
+type DigestService struct {
+	repos  *repositories.Repositories
+	mailer mailer.Mailer
+}
+
+func NewDigestService(repos *repositories.Repositories, m mailer.Mailer) *DigestService {
+	return &DigestService{repos: repos, mailer: m}
+}
+
+// Send отправляет итоги за период; родителям без детей письмо не отправляется
+func (s *DigestService) Send(ctx context.Context, p DigestPayload) error {
+	user, err := s.repos.Users.FindByID(ctx, p.UserID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return jobs.Permanent(fmt.Errorf("user %d not found", p.UserID))
+	}
+	if err != nil {
+		return err
+	}
+	children, err := s.repos.Children.ListByParent(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if len(children) == 0 {
+		return nil
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Итоги занятий с %s по %s:\n\n", p.From.Format("02.01"), p.To.Format("02.01"))
+	for _, child := range children {
+		progress, err := s.repos.Progress.ListByChild(ctx, child.ID)
+		if err != nil {
+			return err
+		}
+		var lessons, score int
+		for _, pr := range progress {
+			if pr.Completed && pr.CompletedAt != nil && !pr.CompletedAt.Before(p.From) && pr.CompletedAt.Before(p.To) {
+				lessons++
+				score += pr.Score
+			}
+		}
+		if lessons == 0 {
+			fmt.Fprintf(&body, "%s: на этой неделе занятий не было\n", child.Name)
+			continue
+		}
+		fmt.Fprintf(&body, "%s: уроков пройдено — %d, средний балл — %d, дней подряд — %d\n",
+			child.Name, lessons, score/lessons, child.Streak)
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Итоги недели в EngKids",
+		Body:    body.String(),
+	})
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"engkids/config"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/game"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/logger"
+	"engkids/pkg/metrics"
+)
+
+const (
+	// gameTickInterval — как часто проверяются дедлайны раундов
+	gameTickInterval = 250 * time.Millisecond
+	// gameGaugeInterval — как часто обновляется число активных игр: это
+	// подсчёт по всей таблице, и частый тик ему не нужен
+	gameGaugeInterval = 30 * time.Second
+	// gameOptions — вариантов ответа в вопросе
+	gameOptions = 4
+	// gameTickBatch — сколько игр с наступившим дедлайном Tick забирает за раз
+	gameTickBatch = 100
+	// gameUpdateAttempts — сколько раз действие повторяется, если игру
+	// одновременно изменил другой запрос
+	gameUpdateAttempts = 5
+)
+
+// GamePublisher рассылает состояние игры подписчикам её комнаты
+type GamePublisher interface {
+	PublishGame(ctx context.Context, state game.State) error
+}
+
+// GameService ведёт викторины для нескольких игроков. Состояние игры хранится
+// в базе, поэтому запрос по коду может попасть на любой экземпляр приложения;
+// одновременные изменения разводит Version. Состояние рассылается через хаб и
+// доходит до сокетов на любом экземпляре
+type GameService struct {
+	repos     *repositories.Repositories
+	publisher GamePublisher
+	cfg       config.Game
+	metrics   *metrics.Business
+}
+
+// NewGameService создаёт сервис. Нулевые параметры cfg заменяются значениями
+// по умолчанию; m может быть nil — метрики не публикуются
+func NewGameService(repos *repositories.Repositories, publisher GamePublisher, cfg config.Game, m *metrics.Business) *GameService {
+	if cfg.QuestionTime <= 0 {
+		cfg.QuestionTime = 15 * time.Second
+	}
+	if cfg.RevealTime <= 0 {
+		cfg.RevealTime = 5 * time.Second
+	}
+	if cfg.Rounds <= 0 {
+		cfg.Rounds = 10
+	}
+	if cfg.MaxPlayers <= 0 {
+		cfg.MaxPlayers = 8
+	}
+	if cfg.LobbyTTL <= 0 {
+		cfg.LobbyTTL = 30 * time.Minute
+	}
+	if m == nil {
+		m = metrics.NewBusiness(metrics.NewRegistry())
+	}
+	return &GameService{repos: repos, publisher: publisher, cfg: cfg, metrics: m}
+}
+
+// Create создаёт игру в лобби с вопросами по словам урока. lang — язык
+// переводов в вариантах ответа, rounds — число вопросов (0 — из настроек)
+func (s *GameService) Create(ctx context.Context, userID, lessonID uint, lang string, rounds int) (*game.State, error) {
+	words, err := s.repos.Words.ListByLesson(ctx, lessonID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	pool := make([]game.Word, 0, len(words))
+	for _, w := range words {
+		pool = append(pool, game.Word{Text: w.Headword, Translation: w.Translation(lang)})
+	}
+	if rounds <= 0 {
+		rounds = s.cfg.Rounds
+	}
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	questions, err := game.BuildQuestions(pool, rounds, gameOptions, rng)
+	if errors.Is(err, game.ErrNotEnoughWords) {
+		return nil, apperrors.New(apperrors.CodeGameNotEnoughWords).WithDetails(map[string]any{"min": game.MinWords})
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+
+	cfg := game.Config{QuestionTime: s.cfg.QuestionTime, RevealTime: s.cfg.RevealTime, MaxPlayers: s.cfg.MaxPlayers}
+	var g *game.Game
+	for {
+		// код занят — пробуем другой
+		g = game.New(game.NewCode(), userID, lessonID, cfg, questions)
+		session := &models.GameSession{}
+		if err := s.store(session, g, time.Now()); err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+		err := s.repos.GameSessions.Create(ctx, session)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, repositories.ErrDuplicate) {
+			return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+	}
+
+	logger.FromContext(ctx).WithField("game", g.Code).WithField("lesson_id", lessonID).Info("Game created")
+	state := g.State()
+	return &state, nil
+}
+
+// State возвращает текущее состояние игры
+func (s *GameService) State(ctx context.Context, code string) (*game.State, error) {
+	_, g, err := s.load(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	state := g.State()
+	return &state, nil
+}
+
+// Join добавляет в лобби ребёнка пользователя
+func (s *GameService) Join(ctx context.Context, userID uint, code string, childID uint) (*game.State, error) {
+	child, err := s.repos.Children.FindByID(ctx, childID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && child.ParentID != userID) {
+		return nil, apperrors.New(apperrors.CodeChildNotFound)
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return s.update(ctx, code, func(g *game.Game) error {
+		return g.Join(game.Player{ChildID: child.ID, ParentID: userID, Name: child.Name})
+	})
+}
+
+// Leave убирает ребёнка пользователя из лобби
+func (s *GameService) Leave(ctx context.Context, userID uint, code string, childID uint) (*game.State, error) {
+	return s.update(ctx, code, func(g *game.Game) error {
+		if err := checkPlayer(g, userID, childID); err != nil {
+			return err
+		}
+		return g.Leave(childID)
+	})
+}
+
+// Start начинает игру; доступно только её создателю
+func (s *GameService) Start(ctx context.Context, userID uint, code string) (*game.State, error) {
+	return s.update(ctx, code, func(g *game.Game) error {
+		return g.Start(userID, time.Now())
+	})
+}
+
+// Answer принимает ответ ребёнка пользователя на вопрос round
+func (s *GameService) Answer(ctx context.Context, userID uint, code string, childID uint, round, option int) (*game.State, error) {
+	return s.update(ctx, code, func(g *game.Game) error {
+		if err := checkPlayer(g, userID, childID); err != nil {
+			return err
+		}
+		return g.Answer(childID, round, option, time.Now())
+	})
+}
+
+// checkPlayer проверяет, что ребёнок играет и за него отвечает userID
+func checkPlayer(g *game.Game, userID, childID uint) error {
+	if p := g.Player(childID); p == nil || p.ParentID != userID {
+		return game.ErrNotPlayer
+	}
+	return nil
+}
+
+// update применяет действие к игре, сохраняет её и рассылает новое состояние.
+// Если игру успел изменить другой запрос, действие повторяется на свежем состоянии
+func (s *GameService) update(ctx context.Context, code string, action func(g *game.Game) error) (*game.State, error) {
+	for attempt := 1; ; attempt++ {
+		session, g, err := s.load(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if err := action(g); err != nil {
+			return nil, s.gameError(err, g)
+		}
+		if err := s.store(session, g, time.Now()); err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+		err = s.repos.GameSessions.Update(ctx, session)
+		if errors.Is(err, repositories.ErrConflict) && attempt < gameUpdateAttempts {
+			continue
+		}
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, apperrors.New(apperrors.CodeGameNotFound)
+		}
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+		state := g.State()
+		s.publish(ctx, state)
+		return &state, nil
+	}
+}
+
+// load читает игру из базы
+func (s *GameService) load(ctx context.Context, code string) (*models.GameSession, *game.Game, error) {
+	session, err := s.repos.GameSessions.FindByCode(ctx, code)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil, apperrors.New(apperrors.CodeGameNotFound)
+	}
+	if err != nil {
+		return nil, nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	var g game.Game
+	if err := json.Unmarshal(session.State, &g); err != nil {
+		return nil, nil, apperrors.Wrap(apperrors.CodeInternal, fmt.Errorf("decode game %s: %w", code, err))
+	}
+	return session, &g, nil
+}
+
+// store записывает игру в session. Лобби получает дедлайн через LobbyTTL после
+// создания, чтобы Tick удалил брошенную игру
+func (s *GameService) store(session *models.GameSession, g *game.Game, now time.Time) error {
+	state, err := json.Marshal(g)
+	if err != nil {
+		return fmt.Errorf("encode game %s: %w", g.Code, err)
+	}
+	session.Code, session.State, session.Phase = g.Code, state, string(g.Phase())
+	switch deadline := g.Deadline(); {
+	case g.Phase() == game.PhaseLobby:
+		created := session.CreatedAt
+		if created.IsZero() {
+			created = now
+		}
+		lobbyDeadline := created.Add(s.cfg.LobbyTTL)
+		session.Deadline = &lobbyDeadline
+	case deadline.IsZero():
+		session.Deadline = nil
+	default:
+		session.Deadline = &deadline
+	}
+	return nil
+}
+
+// Tick переводит игры, у которых наступил дедлайн, на следующий этап,
+// сохраняет итоги законченных и удаляет брошенные в лобби. Tick можно вызывать
+// на всех экземплярах: игру, которую успел изменить другой, он пропускает.
+// Возвращает число изменившихся игр
+func (s *GameService) Tick(ctx context.Context, now time.Time) int {
+	log := logger.FromContext(ctx)
+	due, err := s.repos.GameSessions.ListDue(ctx, now, gameTickBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.WithError(err).Error("Failed to list due games")
+		}
+		return 0
+	}
+
+	changed := 0
+	for i := range due {
+		ok, err := s.tick(ctx, &due[i], now)
+		if err != nil && !errors.Is(err, repositories.ErrConflict) && !errors.Is(err, repositories.ErrNotFound) {
+			log.WithError(err).WithField("game", due[i].Code).Error("Failed to advance game")
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed
+}
+
+func (s *GameService) tick(ctx context.Context, session *models.GameSession, now time.Time) (bool, error) {
+	var g game.Game
+	if err := json.Unmarshal(session.State, &g); err != nil {
+		return false, fmt.Errorf("decode game: %w", err)
+	}
+	log := logger.FromContext(ctx).WithField("game", g.Code)
+	if g.Phase() == game.PhaseLobby {
+		if err := s.repos.GameSessions.Delete(ctx, session); err != nil {
+			return false, err
+		}
+		log.Info("Abandoned game removed from lobby")
+		return false, nil
+	}
+	if !g.Tick(now) {
+		return false, nil
+	}
+
+	if g.Phase() == game.PhaseFinished {
+		// итоги сохраняет тот экземпляр, который удалил игру
+		err := s.repos.Tx.Transaction(ctx, func(repos *repositories.Repositories) error {
+			if err := repos.GameSessions.Delete(ctx, session); err != nil {
+				return err
+			}
+			return s.saveResult(ctx, repos, &g)
+		})
+		if err != nil {
+			return false, err
+		}
+		s.publish(ctx, g.State())
+		s.metrics.GamesFinished.Inc()
+		log.Info("Game finished")
+		return true, nil
+	}
+
+	if err := s.store(session, &g, now); err != nil {
+		return false, err
+	}
+	if err := s.repos.GameSessions.Update(ctx, session); err != nil {
+		return false, err
+	}
+	s.publish(ctx, g.State())
+	return true, nil
+}
+
+func (s *GameService) saveResult(ctx context.Context, repos *repositories.Repositories, g *game.Game) error {
+	board := g.Leaderboard()
+	ranks := game.Ranks(board)
+	result := &models.GameResult{
+		Code:       g.Code,
+		HostID:     g.HostID,
+		LessonID:   g.LessonID,
+		Rounds:     g.State().Rounds,
+		StartedAt:  g.StartedAt,
+		FinishedAt: g.FinishedAt,
+	}
+	for i, p := range board {
+		result.Players = append(result.Players, models.GamePlayer{
+			ChildID: p.ChildID,
+			Name:    p.Name,
+			Rank:    ranks[i],
+			Score:   p.Score,
+			Correct: p.Correct,
+		})
+	}
+	return repos.GameResults.Create(ctx, result)
+}
+
+func (s *GameService) publish(ctx context.Context, state game.State) {
+	if err := s.publisher.PublishGame(ctx, state); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("game", state.Code).Warn("Failed to publish game state")
+	}
+}
+
+// Run продвигает игры по таймеру и обновляет число активных игр, пока не отменён ctx
+func (s *GameService) Run(ctx context.Context) {
+	ticker := time.NewTicker(gameTickInterval)
+	defer ticker.Stop()
+	gauge := time.NewTicker(gameGaugeInterval)
+	defer gauge.Stop()
+	s.updateGauge(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Tick(ctx, now)
+		case <-gauge.C:
+			s.updateGauge(ctx)
+		}
+	}
+}
+
+// updateGauge записывает в GamesActive число игр во всех экземплярах
+func (s *GameService) updateGauge(ctx context.Context) {
+	n, err := s.repos.GameSessions.Count(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.FromContext(ctx).WithError(err).Warn("Failed to count active games")
+		}
+		return
+	}
+	s.metrics.GamesActive.Set(float64(n))
+}
+
+// History возвращает последние игры ребёнка пользователя
+func (s *GameService) History(ctx context.Context, userID, childID uint, limit int) ([]models.GameResult, error) {
+	child, err := s.repos.Children.FindByID(ctx, childID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && child.ParentID != userID) {
+		return nil, apperrors.New(apperrors.CodeChildNotFound)
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	results, err := s.repos.GameResults.ListByChild(ctx, childID, limit)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return results, nil
+}
+
+// gameError переводит ошибки правил игры в ошибки API
+func (s *GameService) gameError(err error, g *game.Game) error {
+	switch {
+	case errors.Is(err, game.ErrWrongPhase):
+		return apperrors.New(apperrors.CodeGameInvalidState).WithDetails(map[string]any{"phase": g.Phase()})
+	case errors.Is(err, game.ErrFull):
+		return apperrors.New(apperrors.CodeGameFull).WithDetails(map[string]any{"limit": s.cfg.MaxPlayers})
+	case errors.Is(err, game.ErrAlreadyJoined):
+		return apperrors.New(apperrors.CodeGamePlayerExists)
+	case errors.Is(err, game.ErrNotPlayer):
+		return apperrors.New(apperrors.CodeGameNotPlayer)
+	case errors.Is(err, game.ErrNotHost):
+		return apperrors.New(apperrors.CodeGameNotHost)
+	case errors.Is(err, game.ErrNoPlayers):
+		return apperrors.New(apperrors.CodeGameNoPlayers)
+	case errors.Is(err, game.ErrRoundOver):
+		return apperrors.New(apperrors.CodeGameRoundOver)
+	case errors.Is(err, game.ErrAlreadyAnswered):
+		return apperrors.New(apperrors.CodeGameAlreadyAnswered)
+	case errors.Is(err, game.ErrInvalidOption):
+		return apperrors.New(apperrors.CodeBadRequest).WithDetails(map[string]any{"param": "option"})
+	default:
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"engkids/config"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/game"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/internal/repositories/memory"
+)
+
+// recordingPublisher запоминает разосланные состояния
+type recordingPublisher struct {
+	mu     sync.Mutex
+	states []game.State
+}
+
+func (p *recordingPublisher) PublishGame(_ context.Context, state game.State) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.states = append(p.states, state)
+	return nil
+}
+
+func newTestGames(t *testing.T, repos *repositories.Repositories) *GameService {
+	t.Helper()
+	cfg := config.Game{QuestionTime: time.Minute, RevealTime: time.Minute, LobbyTTL: time.Hour}
+	return NewGameService(repos, &recordingPublisher{}, cfg, nil)
+}
+
+func gameLesson(t *testing.T, repos *repositories.Repositories, lessonID uint, pairs ...string) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		w := models.Word{Headword: pairs[i], Translations: []models.WordTranslation{{Language: "ru", Text: pairs[i+1]}}}
+		if err := repos.Words.Create(ctx, &w); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Words.LinkLesson(ctx, lessonID, w.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Игра не привязана к экземпляру: её создаёт один, игроки входят через
+// другой, а раунды по таймеру двигает любой, причём ровно один раз
+func TestGameSharedBetweenInstances(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	gameLesson(t, repos, 1, "cat", "кошка", "dog", "собака")
+	child := &models.Child{Name: "Аня", ParentID: 1}
+	if err := repos.Children.Create(ctx, child); err != nil {
+		t.Fatal(err)
+	}
+	first, second := newTestGames(t, repos), newTestGames(t, repos)
+
+	created, err := first.Create(ctx, 1, 1, "ru", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Join(ctx, 1, created.Code, child.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Start(ctx, 1, created.Code); err != nil {
+		t.Fatal(err)
+	}
+	state, err := second.State(ctx, created.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Phase != game.PhaseQuestion || len(state.Players) != 1 {
+		t.Fatalf("state on the second instance: %+v", state)
+	}
+
+	// оба экземпляра тикают одновременно, но раунд сменяется один раз
+	later := time.Now().Add(2 * time.Minute)
+	if n := first.Tick(ctx, later) + second.Tick(ctx, later); n != 1 {
+		t.Fatalf("reveal applied %d times", n)
+	}
+	if n := second.Tick(ctx, later.Add(2*time.Minute)) + first.Tick(ctx, later.Add(2*time.Minute)); n != 1 {
+		t.Fatalf("finish applied %d times", n)
+	}
+	if _, err := first.State(ctx, created.Code); apperrors.From(err).Code != apperrors.CodeGameNotFound {
+		t.Fatalf("finished game is still stored: %v", err)
+	}
+	results, err := repos.GameResults.ListByChild(ctx, child.ID, 10)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("results: %+v %v", results, err)
+	}
+}
+
+func TestGameAbandonedLobbyRemoved(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	gameLesson(t, repos, 1, "cat", "кошка", "dog", "собака")
+	games := newTestGames(t, repos)
+
+	created, err := games.Create(ctx, 1, 1, "ru", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	games.Tick(ctx, time.Now().Add(30*time.Minute))
+	if _, err := games.State(ctx, created.Code); err != nil {
+		t.Fatalf("lobby removed before LobbyTTL: %v", err)
+	}
+	games.Tick(ctx, time.Now().Add(2*time.Hour))
+	if _, err := games.State(ctx, created.Code); apperrors.From(err).Code != apperrors.CodeGameNotFound {
+		t.Fatalf("abandoned lobby is still stored: %v", err)
+	}
+}
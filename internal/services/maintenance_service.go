@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"engkids/internal/events"
+	"engkids/internal/jobs"
+	"engkids/internal/repositories"
+	"engkids/pkg/logger"
+)
+
+// MaintenanceService — периодическое обслуживание данных; методы — задачи планировщика
+type MaintenanceService struct {
+	repos     *repositories.Repositories
+	retention time.Duration
+	loc       *time.Location
+	now       func() time.Time
+}
+
+// NewMaintenanceService создаёт сервис. retention — сколько хранить мягко
+// удалённые записи, loc — часовой пояс, в котором считаются дни серий
+func NewMaintenanceService(repos *repositories.Repositories, retention time.Duration, loc *time.Location) *MaintenanceService {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &MaintenanceService{repos: repos, retention: retention, loc: loc, now: time.Now}
+}
+
+// PurgeExpiredTokens удаляет истёкшие refresh-токены
+func (s *MaintenanceService) PurgeExpiredTokens(ctx context.Context) error {
+	n, err := s.repos.RefreshTokens.DeleteExpired(ctx, s.now())
+	if err != nil {
+		return fmt.Errorf("delete expired refresh tokens: %w", err)
+	}
+	logger.FromContext(ctx).WithField("count", n).Info("Purged expired refresh tokens")
+	return nil
+}
+
+// PurgeIdempotencyKeys удаляет истёкшие ответы на запросы с Idempotency-Key
+func (s *MaintenanceService) PurgeIdempotencyKeys(ctx context.Context) error {
+	n, err := s.repos.Idempotency.DeleteExpired(ctx, s.now())
+	if err != nil {
+		return fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+	logger.FromContext(ctx).WithField("count", n).Info("Purged expired idempotency keys")
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет пользователей и детей, мягко удалённых
+// раньше, чем retention назад
+func (s *MaintenanceService) PurgeDeleted(ctx context.Context) error {
+	before := s.now().Add(-s.retention)
+	children, err := s.repos.Children.PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("purge deleted children: %w", err)
+	}
+	users, err := s.repos.Users.PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("purge deleted users: %w", err)
+	}
+	logger.FromContext(ctx).WithField("children", children).WithField("users", users).
+		Info("Purged soft-deleted records")
+	return nil
+}
+
+// ResetStreaks обнуляет серии детей, которые не занимались вчера. Запускается
+// в полночь по часовому поясу сервиса
+func (s *MaintenanceService) ResetStreaks(ctx context.Context) error {
+	n, err := s.repos.Children.ResetStreaks(ctx, s.yesterday())
+	if err != nil {
+		return fmt.Errorf("reset streaks: %w", err)
+	}
+	logger.FromContext(ctx).WithField("count", n).Info("Reset broken streaks")
+	return nil
+}
+
+// WarnStreaksAtRisk публикует StreakAtRisk для детей, которые сегодня ещё не
+// занимались, чтобы родители успели напомнить до полуночи
+func (s *MaintenanceService) WarnStreaksAtRisk(ctx context.Context) error {
+	today := s.today()
+	children, err := s.repos.Children.ListStreaksAtRisk(ctx, today)
+	if err != nil {
+		return fmt.Errorf("list streaks at risk: %w", err)
+	}
+	err = s.repos.Tx.Transaction(ctx, func(repos *repositories.Repositories) error {
+		for _, c := range children {
+			e := events.StreakAtRisk{ChildID: c.ID, Streak: c.Streak, Date: today.Format(time.DateOnly)}
+			if err := events.Record(ctx, repos.Outbox, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("record streak warnings: %w", err)
+	}
+	logger.FromContext(ctx).WithField("count", len(children)).Info("Warned about streaks at risk")
+	return nil
+}
+
+func (s *MaintenanceService) today() time.Time {
+	y, m, d := s.now().In(s.loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, s.loc)
+}
+
+func (s *MaintenanceService) yesterday() time.Time {
+	return s.today().AddDate(0, 0, -1)
+}
+
+// EnqueueWeeklyDigests ставит в очередь письма с итогами недели всем родителям
+func (s *MaintenanceService) EnqueueWeeklyDigests(ctx context.Context) error {
+	users, err := s.repos.Users.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	to := s.now()
+	from := to.AddDate(0, 0, -7)
+
+	var n int
+	for _, u := range users {
+		if u.Role != "user" {
+			continue
+		}
+		payload := DigestPayload{UserID: u.ID, From: from, To: to}
+		if _, err := jobs.Enqueue(ctx, s.repos.Jobs, JobWeeklyDigest, payload); err != nil {
+			return fmt.Errorf("enqueue digest for user %d: %w", u.ID, err)
+		}
+		n++
+	}
+	logger.FromContext(ctx).WithField("count", n).Info("Enqueued weekly digests")
+	return nil
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"engkids/internal/events"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/internal/repositories/memory"
+	"engkids/pkg/mailer"
+
+	"gorm.io/gorm"
+)
+
+var maintenanceNow = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+func newTestMaintenance(repos *repositories.Repositories) *MaintenanceService {
+	s := NewMaintenanceService(repos, 30*24*time.Hour, time.UTC)
+	s.now = func() time.Time { return maintenanceNow }
+	return s
+}
+
+func TestPurgeExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	tokens := []models.RefreshToken{
+		{UserID: 1, Token: "t1", ExpiresAt: maintenanceNow.Add(-time.Minute)},
+		{UserID: 2, Token: "t2", ExpiresAt: maintenanceNow.Add(time.Hour)},
+	}
+	for i := range tokens {
+		if err := repos.RefreshTokens.Save(ctx, &tokens[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := newTestMaintenance(repos).PurgeExpiredTokens(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.RefreshTokens.FindByToken(ctx, "t1"); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("expired token kept: %v", err)
+	}
+	if _, err := repos.RefreshTokens.FindByToken(ctx, "t2"); err != nil {
+		t.Errorf("valid token purged: %v", err)
+	}
+}
+
+func TestPurgeDeletedAfterRetention(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	deleted := func(ago time.Duration) gorm.DeletedAt {
+		return gorm.DeletedAt{Time: maintenanceNow.Add(-ago), Valid: true}
+	}
+
+	gone := &models.User{Email: "gone@example.com", DeletedAt: deleted(40 * 24 * time.Hour)}
+	recent := &models.User{Email: "recent@example.com", DeletedAt: deleted(24 * time.Hour)}
+	active := &models.User{Email: "active@example.com"}
+	for _, u := range []*models.User{gone, recent, active} {
+		if err := repos.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orphan := &models.Child{Name: "Маша", ParentID: gone.ID}
+	removed := &models.Child{Name: "Петя", ParentID: active.ID, DeletedAt: deleted(31 * 24 * time.Hour)}
+	kept := &models.Child{Name: "Ваня", ParentID: active.ID}
+	for _, c := range []*models.Child{orphan, removed, kept} {
+		if err := repos.Children.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Progress.Save(ctx, &models.Progress{ChildID: removed.ID, LessonID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := newTestMaintenance(repos).PurgeDeleted(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// мягко удалённые записи не видны через Find*, поэтому проверяем повторной очисткой
+	// с нулевым сроком хранения: пережить первую должен только recent
+	n, err := repos.Users.PurgeDeleted(ctx, maintenanceNow)
+	if err != nil || n != 1 {
+		t.Errorf("users left after purge: %d, %v", n, err)
+	}
+	if n, _ := repos.Children.PurgeDeleted(ctx, maintenanceNow); n != 0 {
+		t.Errorf("%d deleted children survived purge", n)
+	}
+	if _, err := repos.Children.FindByID(ctx, orphan.ID); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("child of purged user kept: %v", err)
+	}
+	if _, err := repos.Children.FindByID(ctx, kept.ID); err != nil {
+		t.Errorf("active child purged: %v", err)
+	}
+	if list, _ := repos.Progress.ListByChild(ctx, removed.ID); len(list) != 0 {
+		t.Errorf("progress of purged child kept: %+v", list)
+	}
+}
+
+func TestResetStreaks(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	day := func(offset int) *time.Time {
+		d := maintenanceNow.AddDate(0, 0, offset)
+		return &d
+	}
+
+	yesterday := &models.Child{Name: "Маша", Streak: 3, LastActiveOn: day(-1)}
+	stale := &models.Child{Name: "Петя", Streak: 5, BestStreak: 5, LastActiveOn: day(-2)}
+	for _, c := range []*models.Child{yesterday, stale} {
+		if err := repos.Children.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := newTestMaintenance(repos).ResetStreaks(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := repos.Children.FindByID(ctx, yesterday.ID); c.Streak != 3 {
+		t.Errorf("streak of child active yesterday = %d", c.Streak)
+	}
+	if c, _ := repos.Children.FindByID(ctx, stale.ID); c.Streak != 0 || c.BestStreak != 5 {
+		t.Errorf("stale child: streak=%d best=%d", c.Streak, c.BestStreak)
+	}
+}
+
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestWeeklyDigest(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+
+	parent := &models.User{Email: "parent@example.com", Role: "user"}
+	childless := &models.User{Email: "childless@example.com", Role: "user"}
+	admin := &models.User{Email: "admin@example.com", Role: "admin"}
+	for _, u := range []*models.User{parent, childless, admin} {
+		if err := repos.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	child := &models.Child{Name: "Маша", ParentID: parent.ID, Streak: 4}
+	if err := repos.Children.Create(ctx, child); err != nil {
+		t.Fatal(err)
+	}
+	for lesson, daysAgo := range map[uint]int{1: 2, 2: 3, 3: 10} {
+		completed := maintenanceNow.AddDate(0, 0, -daysAgo)
+		p := &models.Progress{ChildID: child.ID, LessonID: lesson, Completed: true, Score: 80 + int(lesson)*5, CompletedAt: &completed}
+		if err := repos.Progress.Save(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := newTestMaintenance(repos).EnqueueWeeklyDigests(ctx); err != nil {
+		t.Fatal(err)
+	}
+	queued, err := repos.Jobs.List(ctx, repositories.JobFilter{Type: JobWeeklyDigest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 2 {
+		t.Fatalf("queued %d digests, want one per parent", len(queued))
+	}
+
+	m := &recordingMailer{}
+	digests := NewDigestService(repos, m)
+	for _, job := range queued {
+		var p DigestPayload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			t.Fatal(err)
+		}
+		if err := digests.Send(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(m.sent) != 1 || m.sent[0].To != parent.Email {
+		t.Fatalf("unexpected emails: %+v", m.sent)
+	}
+	want := "Маша: уроков пройдено — 2, средний балл — 87, дней подряд — 4"
+	if !strings.Contains(m.sent[0].Body, want) {
+		t.Errorf("digest body %q does not contain %q", m.sent[0].Body, want)
+	}
+}
+
+func TestWarnStreaksAtRisk(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	today, yesterday := maintenanceNow, maintenanceNow.AddDate(0, 0, -1)
+
+	atRisk := &models.Child{Name: "Маша", Streak: 3, LastActiveOn: &yesterday}
+	safe := &models.Child{Name: "Петя", Streak: 5, LastActiveOn: &today}
+	none := &models.Child{Name: "Ваня"}
+	for _, c := range []*models.Child{atRisk, safe, none} {
+		if err := repos.Children.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := newTestMaintenance(repos).WarnStreaksAtRisk(ctx); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := repos.Outbox.ClaimDue(ctx, time.Now(), time.Minute, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 1 || recorded[0].Type != events.TypeStreakAtRisk {
+		t.Fatalf("unexpected events: %+v", recorded)
+	}
+	var e events.StreakAtRisk
+	if err := json.Unmarshal(recorded[0].Payload, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ChildID != atRisk.ID || e.Streak != 3 || e.Date != "2024-03-15" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
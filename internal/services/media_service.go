@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	_ "image/gif" // декодер GIF для image.Decode
+
+	"engkids/config"
+	"engkids/internal/dto"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/imaging"
+	"engkids/pkg/logger"
+	"engkids/pkg/storage"
+)
+
+const (
+	// thumbnailSize — длинная сторона миниатюры, px
+	thumbnailSize = 256
+	// maxImagePixels — картинки больше не декодируются: маленький сжатый файл
+	// может развернуться в гигабайты памяти
+	maxImagePixels = 40_000_000
+)
+
+// mediaFormat — поддерживаемый формат файла
+type mediaFormat struct {
+	kind        string
+	ext         string
+	contentType string
+	thumbnail   bool // стандартная библиотека умеет декодировать формат
+}
+
+// mediaFormats — форматы по типу, который определяет http.DetectContentType
+var mediaFormats = map[string]mediaFormat{
+	"image/png":       {models.MediaImage, ".png", "image/png", true},
+	"image/jpeg":      {models.MediaImage, ".jpg", "image/jpeg", true},
+	"image/gif":       {models.MediaImage, ".gif", "image/gif", true},
+	"image/webp":      {models.MediaImage, ".webp", "image/webp", false},
+	"audio/mpeg":      {models.MediaAudio, ".mp3", "audio/mpeg", false},
+	"audio/wave":      {models.MediaAudio, ".wav", "audio/wav", false},
+	"application/ogg": {models.MediaAudio, ".ogg", "audio/ogg", false},
+}
+
+// MediaService — картинки и аудио уроков: загрузка с проверкой формата и
+// размера, дедупликация по хешу содержимого и подписанные ссылки на скачивание
+type MediaService struct {
+	repos  *repositories.Repositories
+	store  storage.Storage
+	signer *storage.URLSigner
+	cfg    config.Storage
+	now    func() time.Time
+}
+
+func NewMediaService(repos *repositories.Repositories, store storage.Storage, signer *storage.URLSigner, cfg config.Storage) *MediaService {
+	if cfg.MaxImageSize <= 0 {
+		cfg.MaxImageSize = 5 << 20
+	}
+	if cfg.MaxAudioSize <= 0 {
+		cfg.MaxAudioSize = 10 << 20
+	}
+	if cfg.URLTTL <= 0 {
+		cfg.URLTTL = time.Hour
+	}
+	return &MediaService{repos: repos, store: store, signer: signer, cfg: cfg, now: time.Now}
+}
+
+// MaxUploadSize — самый большой файл, который может пройти проверку размера
+func (s *MediaService) MaxUploadSize() int64 {
+	return max(s.cfg.MaxImageSize, s.cfg.MaxAudioSize)
+}
+
+// Upload сохраняет файл. Формат определяется по содержимому, а не по имени
+// или заголовкам клиента. Если такой файл уже загружен, возвращается
+// существующая запись и created = false
+func (s *MediaService) Upload(ctx context.Context, userID uint, data []byte) (asset *dto.MediaAsset, created bool, err error) {
+	sniffed := sniffMedia(data)
+	format, ok := mediaFormats[sniffed]
+	if !ok {
+		return nil, false, apperrors.New(apperrors.CodeMediaUnsupportedType).WithDetails(map[string]any{"type": sniffed})
+	}
+	limit := s.cfg.MaxAudioSize
+	if format.kind == models.MediaImage {
+		limit = s.cfg.MaxImageSize
+	}
+	if int64(len(data)) > limit {
+		return nil, false, apperrors.New(apperrors.CodeMediaTooLarge).WithDetails(map[string]any{"limit": limit >> 20})
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	existing, err := s.repos.Media.FindByHash(ctx, hash)
+	if err == nil {
+		asset, err := s.signed(ctx, existing)
+		return asset, false, err
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, false, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+
+	record := &models.MediaAsset{
+		Hash:        hash,
+		Kind:        format.kind,
+		ContentType: format.contentType,
+		Size:        int64(len(data)),
+		Key:         "media/" + hash[:2] + "/" + hash + format.ext,
+		UploadedBy:  userID,
+	}
+	if format.thumbnail {
+		thumb, ext, err := makeThumbnail(record, data)
+		if err != nil {
+			return nil, false, err
+		}
+		record.ThumbnailKey = "thumbnails/" + hash[:2] + "/" + hash + ext
+		if err := s.put(ctx, record.ThumbnailKey, thumb); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := s.put(ctx, record.Key, data); err != nil {
+		return nil, false, err
+	}
+
+	// файлы лежат по ключам из хеша, поэтому параллельная загрузка того же
+	// файла перезаписывает их тем же содержимым; запись остаётся одна
+	err = s.repos.Media.Create(ctx, record)
+	if errors.Is(err, repositories.ErrDuplicate) {
+		existing, err := s.repos.Media.FindByHash(ctx, hash)
+		if err != nil {
+			return nil, false, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+		asset, err := s.signed(ctx, existing)
+		return asset, false, err
+	}
+	if err != nil {
+		return nil, false, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	logger.FromContext(ctx).WithField("media_id", record.ID).WithField("size", record.Size).Info("Media uploaded")
+	asset, err = s.signed(ctx, record)
+	return asset, true, err
+}
+
+// Get возвращает файл со свежими ссылками
+func (s *MediaService) Get(ctx context.Context, id uint) (*dto.MediaAsset, error) {
+	record, err := s.repos.Media.FindByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, apperrors.New(apperrors.CodeMediaNotFound)
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return s.signed(ctx, record)
+}
+
+// Links подписывает ссылки на файлы ids; у всех ссылок один срок действия.
+// Файлов, которых нет, в ответе нет
+func (s *MediaService) Links(ctx context.Context, ids []uint) (map[uint]string, time.Time, error) {
+	expiresAt := s.now().Add(s.cfg.URLTTL).Truncate(time.Second)
+	if len(ids) == 0 {
+		return map[uint]string{}, expiresAt, nil
+	}
+	records, err := s.repos.Media.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, time.Time{}, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	links := make(map[uint]string, len(records))
+	for _, record := range records {
+		if links[record.ID], err = s.store.SignedURL(ctx, record.Key, expiresAt); err != nil {
+			return nil, time.Time{}, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+	}
+	return links, expiresAt, nil
+}
+
+// Open проверяет подписанную ссылку локального хранилища и открывает файл
+func (s *MediaService) Open(ctx context.Context, key, expires, signature string) (io.ReadCloser, *storage.Object, error) {
+	if err := s.signer.Verify(key, expires, signature, s.now()); err != nil {
+		return nil, nil, apperrors.New(apperrors.CodeMediaLinkInvalid)
+	}
+	rc, obj, err := s.store.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return nil, nil, apperrors.New(apperrors.CodeMediaNotFound)
+	}
+	if err != nil {
+		return nil, nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return rc, obj, nil
+}
+
+func (s *MediaService) put(ctx context.Context, key string, data []byte) error {
+	err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "")
+	if err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return nil
+}
+
+func (s *MediaService) signed(ctx context.Context, record *models.MediaAsset) (*dto.MediaAsset, error) {
+	asset := &dto.MediaAsset{MediaAsset: *record, ExpiresAt: s.now().Add(s.cfg.URLTTL).Truncate(time.Second)}
+	var err error
+	if asset.URL, err = s.store.SignedURL(ctx, record.Key, asset.ExpiresAt); err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	if record.ThumbnailKey != "" {
+		if asset.ThumbnailURL, err = s.store.SignedURL(ctx, record.ThumbnailKey, asset.ExpiresAt); err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+	}
+	return asset, nil
+}
+
+// sniffMedia определяет тип по первым байтам файла. MP3 без тега ID3
+// начинается сразу с кадра, его http.DetectContentType не узнаёт
+func sniffMedia(data []byte) string {
+	if len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 {
+		return "audio/mpeg"
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	return contentType
+}
+
+// makeThumbnail заполняет размеры картинки и строит миниатюру: JPEG для
+// фотографий, PNG для остальных, чтобы не терять прозрачность
+func makeThumbnail(record *models.MediaAsset, data []byte) ([]byte, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, "", apperrors.New(apperrors.CodeMediaInvalidFile)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", apperrors.New(apperrors.CodeMediaInvalidFile)
+	}
+	record.Width, record.Height = cfg.Width, cfg.Height
+
+	thumb := imaging.Thumbnail(img, thumbnailSize)
+	var buf bytes.Buffer
+	ext := ".png"
+	if record.ContentType == "image/jpeg" {
+		ext, err = ".jpg", jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return nil, "", apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return buf.Bytes(), ext, nil
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"engkids/internal/dto"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/events"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/logger"
+	"engkids/pkg/metrics"
+)
+
+// Типы операций синхронизации
+const (
+	SyncAttempt = "attempt" // результат прохождения урока
+	SyncReview  = "review"  // оценка при повторении слова
+	SyncSetting = "setting" // изменение настройки приложения
+)
+
+// Итоги операций синхронизации, см. dto.SyncResult
+const (
+	SyncApplied   = "applied"
+	SyncIgnored   = "ignored"
+	SyncDuplicate = "duplicate"
+	SyncRejected  = "rejected"
+)
+
+const (
+	// syncCursorOverlap — насколько раньше курсора отдаются изменения: запись,
+	// закоммиченная уже после прошлой синхронизации, могла получить updated_at
+	// до курсора. Повторы безопасны — приложение заменяет записи по ID
+	syncCursorOverlap = 5 * time.Second
+	// maxSettingSize — предельный размер значения настройки в JSON
+	maxSettingSize = 1024
+)
+
+// reviewIntervals — через сколько дней повторять слово из коробки 1, 2, …
+var reviewIntervals = []int{1, 2, 4, 8, 16}
+
+var settingKey = regexp.MustCompile(`^[a-z][a-z0-9_.]{0,63}$`)
+
+// errSyncDuplicate — операция с этим ClientID уже принималась
+var errSyncDuplicate = errors.New("sync operation already applied")
+
+// SyncService принимает действия, накопленные приложением без сети, и отдаёт
+// изменения с прошлой синхронизации. Операции применяются в порядке времени
+// на устройстве, каждая в своей транзакции. Конфликты решаются так: у урока
+// остаются лучший балл и самое раннее прохождение, у повторения слова и
+// настройки — более позднее изменение
+type SyncService struct {
+	repos    *repositories.Repositories
+	notifier events.Notifier
+	loc      *time.Location
+	now      func() time.Time
+	metrics  *metrics.Business
+}
+
+// NewSyncService создаёт сервис. loc — часовой пояс, в котором считаются дни
+// серий; notifier и m могут быть nil
+func NewSyncService(repos *repositories.Repositories, notifier events.Notifier, loc *time.Location, m *metrics.Business) *SyncService {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if m == nil {
+		m = metrics.NewBusiness(metrics.NewRegistry())
+	}
+	return &SyncService{repos: repos, notifier: notifier, loc: loc, now: time.Now, metrics: m}
+}
+
+// syncBatch — состояние одного запроса синхронизации
+type syncBatch struct {
+	userID    uint
+	children  map[uint]*models.Child
+	now       time.Time
+	completed int // сколько прохождений уроков принято
+}
+
+// Sync применяет операции пользователя и возвращает изменения данных его
+// детей после курсора req.Cursor
+func (s *SyncService) Sync(ctx context.Context, userID uint, req *dto.SyncRequest) (*dto.SyncResponse, error) {
+	since, err := parseCursor(req.Cursor)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeSyncInvalidCursor, err)
+	}
+	list, err := s.repos.Children.ListByParent(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	b := &syncBatch{userID: userID, children: make(map[uint]*models.Child, len(list)), now: s.now()}
+	for i := range list {
+		b.children[list[i].ID] = &list[i]
+	}
+
+	ops := req.Operations
+	order := make([]int, len(ops))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int { return ops[i].ClientTime.Compare(ops[j].ClientTime) })
+
+	results := make([]dto.SyncResult, len(ops))
+	applied := 0
+	for _, i := range order {
+		if results[i], err = s.apply(ctx, b, ops[i]); err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+		}
+		if results[i].Status == SyncApplied {
+			applied++
+		}
+	}
+	if b.completed > 0 && s.notifier != nil {
+		s.notifier.Notify()
+	}
+	logger.FromContext(ctx).WithField("operations", len(ops)).WithField("applied", applied).Info("Sync operations processed")
+
+	cursor := s.now()
+	changes, err := s.changes(ctx, userID, since)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return &dto.SyncResponse{Results: results, Changes: changes, Cursor: formatCursor(cursor)}, nil
+}
+
+// apply применяет одну операцию. Ошибка — только внутренняя: некорректные
+// операции отклоняются в результате, не прерывая пакет
+func (s *SyncService) apply(ctx context.Context, b *syncBatch, op dto.SyncOperation) (dto.SyncResult, error) {
+	res := dto.SyncResult{ID: op.ID}
+	child, ok := b.children[op.ChildID]
+	if !ok {
+		return rejected(res, apperrors.New(apperrors.CodeChildNotFound)), nil
+	}
+	if err := checkOperation(op); err != nil {
+		return rejected(res, err), nil
+	}
+	// время в будущем — часы устройства спешат; иначе такая настройка
+	// перекрывала бы все следующие изменения
+	at := op.ClientTime
+	if at.After(b.now) {
+		at = b.now
+	}
+
+	updated := *child
+	err := s.repos.Tx.Transaction(ctx, func(repos *repositories.Repositories) error {
+		err := repos.Sync.Record(ctx, &models.SyncOperation{UserID: b.userID, ClientID: op.ID, Type: op.Type})
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return errSyncDuplicate
+		}
+		if err != nil {
+			return err
+		}
+		switch op.Type {
+		case SyncAttempt:
+			res.Status, err = s.applyAttempt(ctx, repos, &updated, op, at)
+		case SyncReview:
+			res.Status, err = applyReview(ctx, repos, op, at)
+		case SyncSetting:
+			res.Status, err = applySetting(ctx, repos, op, at)
+		}
+		return err
+	})
+
+	var appErr *apperrors.Error
+	switch {
+	case errors.Is(err, errSyncDuplicate):
+		res.Status = SyncDuplicate
+		return res, nil
+	case errors.As(err, &appErr):
+		logger.FromContext(ctx).WithField(logger.FieldChildID, child.ID).WithField("operation", op.ID).
+			WithField("code", appErr.Code).Info("Sync operation rejected")
+		return rejected(res, appErr), nil
+	case err != nil:
+		logger.FromContext(ctx).WithError(err).WithField(logger.FieldChildID, child.ID).WithField("operation", op.ID).
+			Error("Failed to apply sync operation")
+		return res, err
+	}
+	*child = updated
+	if op.Type == SyncAttempt && op.Completed {
+		b.completed++
+		s.metrics.LessonsCompleted.Inc()
+	}
+	return res, nil
+}
+
+func rejected(res dto.SyncResult, err *apperrors.Error) dto.SyncResult {
+	res.Status, res.Err = SyncRejected, err
+	return res
+}
+
+// checkOperation проверяет поля, обязательные для типа операции
+func checkOperation(op dto.SyncOperation) *apperrors.Error {
+	invalid := func(param string) *apperrors.Error {
+		return apperrors.New(apperrors.CodeSyncInvalidOperation).WithDetails(map[string]any{"param": param})
+	}
+	switch op.Type {
+	case SyncAttempt:
+		if op.LessonID == 0 {
+			return invalid("lesson_id")
+		}
+		if op.Score == nil {
+			return invalid("score")
+		}
+	case SyncReview:
+		if op.WordID == 0 {
+			return invalid("word_id")
+		}
+		if op.Grade == nil {
+			return invalid("grade")
+		}
+	case SyncSetting:
+		if !settingKey.MatchString(op.Key) {
+			return invalid("key")
+		}
+		if len(op.Value) == 0 || len(op.Value) > maxSettingSize {
+			return invalid("value")
+		}
+	}
+	return nil
+}
+
+// applyAttempt сохраняет результат урока. Прохождение продлевает серию
+// ребёнка и публикует LessonCompleted, даже если лучший балл не изменился
+func (s *SyncService) applyAttempt(ctx context.Context, repos *repositories.Repositories, child *models.Child, op dto.SyncOperation, at time.Time) (string, error) {
+	p, err := repos.Progress.FindByChildAndLesson(ctx, child.ID, op.LessonID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		p, err = &models.Progress{ChildID: child.ID, LessonID: op.LessonID}, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	changed := p.ID == 0
+	if *op.Score > p.Score {
+		p.Score, changed = *op.Score, true
+	}
+	if op.Completed && (p.CompletedAt == nil || at.Before(*p.CompletedAt)) {
+		completedAt := at
+		p.Completed, p.CompletedAt, changed = true, &completedAt, true
+	}
+	if changed {
+		if err := repos.Progress.Save(ctx, p); err != nil {
+			return "", err
+		}
+	}
+	if !op.Completed {
+		return syncStatus(changed), nil
+	}
+
+	if touchStreak(child, at, s.loc) {
+		if err := repos.Children.UpdateStreak(ctx, child); err != nil {
+			return "", err
+		}
+		changed = true
+	}
+	e := events.LessonCompleted{ChildID: child.ID, LessonID: op.LessonID, Score: *op.Score, CompletedAt: at}
+	if err := events.Record(ctx, repos.Outbox, e); err != nil {
+		return "", err
+	}
+	return syncStatus(changed), nil
+}
+
+// applyReview переносит слово между коробками по оценке. Оценка не новее
+// последнего повторения на сервере пришла с опозданием и не учитывается
+func applyReview(ctx context.Context, repos *repositories.Repositories, op dto.SyncOperation, at time.Time) (string, error) {
+	if _, err := repos.Words.FindByID(ctx, op.WordID); errors.Is(err, repositories.ErrNotFound) {
+		return "", apperrors.New(apperrors.CodeWordNotFound)
+	} else if err != nil {
+		return "", err
+	}
+	r, err := repos.Reviews.Find(ctx, op.ChildID, op.WordID)
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		r = &models.WordReview{ChildID: op.ChildID, WordID: op.WordID}
+	case err != nil:
+		return "", err
+	case !at.After(r.ReviewedAt):
+		return SyncIgnored, nil
+	}
+
+	grade := *op.Grade
+	if grade >= 3 {
+		r.Box = min(r.Box+1, len(reviewIntervals))
+	} else {
+		r.Box = 1
+	}
+	r.Grade, r.Reviews, r.ReviewedAt = grade, r.Reviews+1, at
+	r.DueAt = at.AddDate(0, 0, reviewIntervals[r.Box-1])
+	return SyncApplied, repos.Reviews.Save(ctx, r)
+}
+
+// applySetting сохраняет значение настройки, если оно изменено позже текущего
+func applySetting(ctx context.Context, repos *repositories.Repositories, op dto.SyncOperation, at time.Time) (string, error) {
+	st, err := repos.Settings.Find(ctx, op.ChildID, op.Key)
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		st = &models.ChildSetting{ChildID: op.ChildID, Key: op.Key}
+	case err != nil:
+		return "", err
+	case !at.After(st.ChangedAt):
+		return SyncIgnored, nil
+	}
+	st.Value, st.ChangedAt = op.Value, at
+	return SyncApplied, repos.Settings.Save(ctx, st)
+}
+
+func syncStatus(changed bool) string {
+	if changed {
+		return SyncApplied
+	}
+	return SyncIgnored
+}
+
+// touchStreak отмечает занятие ребёнка в момент at: серия продлевается, если
+// он занимался накануне, иначе начинается заново. Занятие задним числом, не
+// позже последнего дня занятий, серию не меняет
+func touchStreak(child *models.Child, at time.Time, loc *time.Location) bool {
+	day := civilDate(at.In(loc))
+	switch {
+	case child.LastActiveOn == nil:
+		child.Streak = 1
+	case !day.After(civilDate(*child.LastActiveOn)):
+		return false
+	case day.Equal(civilDate(*child.LastActiveOn).AddDate(0, 0, 1)):
+		child.Streak++
+	default:
+		child.Streak = 1
+	}
+	child.BestStreak = max(child.BestStreak, child.Streak)
+	child.LastActiveOn = &day
+	return true
+}
+
+// civilDate — календарная дата t как полночь UTC, в таком виде хранится тип date
+func civilDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// changes собирает данные детей пользователя, изменённые после since
+func (s *SyncService) changes(ctx context.Context, userID uint, since time.Time) (dto.SyncChanges, error) {
+	changes := dto.SyncChanges{Children: []models.Child{}}
+	children, err := s.repos.Children.ListByParent(ctx, userID)
+	if err != nil {
+		return changes, err
+	}
+	ids := make([]uint, 0, len(children))
+	for _, c := range children {
+		ids = append(ids, c.ID)
+		if c.UpdatedAt.After(since) {
+			changes.Children = append(changes.Children, c)
+		}
+	}
+	if changes.Progress, err = s.repos.Progress.ListChanged(ctx, ids, since); err != nil {
+		return changes, err
+	}
+	if changes.Reviews, err = s.repos.Reviews.ListChanged(ctx, ids, since); err != nil {
+		return changes, err
+	}
+	changes.Settings, err = s.repos.Settings.ListChanged(ctx, ids, since)
+	return changes, err
+}
+
+// parseCursor возвращает момент, с которого отдавать изменения; пустой курсор —
+// с самого начала
+func parseCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, nil
+	}
+	micros, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || micros <= 0 {
+		return time.Time{}, errors.New("malformed sync cursor")
+	}
+	return time.UnixMicro(micros).Add(-syncCursorOverlap), nil
+}
+
+func formatCursor(t time.Time) string {
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
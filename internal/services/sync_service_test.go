@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"engkids/internal/dto"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/internal/repositories/memory"
+)
+
+var syncNow = time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+func newTestSync(t *testing.T) (*SyncService, *repositories.Repositories, *models.Child) {
+	t.Helper()
+	repos := memory.New()
+	child := &models.Child{Name: "Маша", ParentID: 1}
+	if err := repos.Children.Create(context.Background(), child); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSyncService(repos, nil, time.UTC, nil)
+	s.now = func() time.Time { return syncNow }
+	return s, repos, child
+}
+
+func ptr(v int) *int { return &v }
+
+func statuses(results []dto.SyncResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Status
+		if r.Err != nil {
+			out[i] += ":" + string(r.Err.Code)
+		}
+	}
+	return out
+}
+
+func TestSyncConflicts(t *testing.T) {
+	ctx := context.Background()
+	s, repos, child := newTestSync(t)
+	word := &models.Word{Headword: "cat"}
+	if err := repos.Words.Create(ctx, word); err != nil {
+		t.Fatal(err)
+	}
+	at := func(h int) time.Time { return syncNow.Add(time.Duration(h-12) * time.Hour) }
+	op := func(id, typ string, hour int) dto.SyncOperation {
+		return dto.SyncOperation{ID: id, Type: typ, ChildID: child.ID, ClientTime: at(hour)}
+	}
+
+	attempt1 := op("a1", SyncAttempt, 10)
+	attempt1.LessonID, attempt1.Score, attempt1.Completed = 3, ptr(90), true
+	attempt2 := op("a2", SyncAttempt, 9) // раньше, но с худшим баллом
+	attempt2.LessonID, attempt2.Score, attempt2.Completed = 3, ptr(60), true
+	review1 := op("r1", SyncReview, 8)
+	review1.WordID, review1.Grade = word.ID, ptr(4)
+	review2 := op("r2", SyncReview, 7) // операции пакета применяются по времени на устройстве
+	review2.WordID, review2.Grade = word.ID, ptr(1)
+	theme := op("s1", SyncSetting, 11)
+	theme.Key, theme.Value = "theme", json.RawMessage(`"dark"`)
+	future := op("s2", SyncSetting, 30) // часы устройства спешат
+	future.Key, future.Value = "sound", json.RawMessage(`false`)
+	noScore := op("bad", SyncAttempt, 10)
+	noScore.LessonID = 3
+	unknownWord := op("r3", SyncReview, 8)
+	unknownWord.WordID, unknownWord.Grade = 99, ptr(5)
+	foreign := op("f1", SyncSetting, 10)
+	foreign.ChildID, foreign.Key, foreign.Value = 42, "theme", json.RawMessage(`"light"`)
+
+	req := &dto.SyncRequest{Operations: []dto.SyncOperation{attempt1, attempt2, review1, review2, theme, future, noScore, unknownWord, foreign}}
+	resp, err := s.Sync(ctx, 1, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"applied", "applied", "applied", "applied", "applied", "applied",
+		"rejected:SYNC_INVALID_OPERATION", "rejected:WORD_NOT_FOUND", "rejected:CHILD_NOT_FOUND"}
+	if got := statuses(resp.Results); !slices.Equal(got, want) {
+		t.Fatalf("statuses:\n got %v\nwant %v", got, want)
+	}
+	if resp.Results[6].Err.Details["param"] != "score" {
+		t.Errorf("invalid operation details: %v", resp.Results[6].Err.Details)
+	}
+
+	// лучший балл и самое раннее прохождение
+	p, err := repos.Progress.FindByChildAndLesson(ctx, child.ID, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Score != 90 || !p.Completed || !p.CompletedAt.Equal(at(9)) {
+		t.Errorf("progress: %+v", p)
+	}
+	r, err := repos.Reviews.Find(ctx, child.ID, word.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Box != 2 || r.Grade != 4 || r.Reviews != 2 || !r.DueAt.Equal(at(8).AddDate(0, 0, 2)) {
+		t.Errorf("review: %+v", r)
+	}
+	sound, err := repos.Settings.Find(ctx, child.ID, "sound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sound.ChangedAt.Equal(syncNow) {
+		t.Errorf("future change time was not clamped: %v", sound.ChangedAt)
+	}
+	if c, _ := repos.Children.FindByID(ctx, child.ID); c.Streak != 1 || c.LastActiveOn.Format(time.DateOnly) != "2024-03-15" {
+		t.Errorf("streak %d, last active %v", c.Streak, c.LastActiveOn)
+	}
+	if len(resp.Changes.Children) != 1 || len(resp.Changes.Progress) != 1 || len(resp.Changes.Reviews) != 1 || len(resp.Changes.Settings) != 2 {
+		t.Errorf("changes: %+v", resp.Changes)
+	}
+
+	// повторная отправка ничего не меняет; изменения старше сохранённых
+	// проигрывают, новее — применяются
+	late := op("r4", SyncReview, 6)
+	late.WordID, late.Grade = word.ID, ptr(0)
+	older := op("s3", SyncSetting, 10)
+	older.Key, older.Value = "theme", json.RawMessage(`"light"`)
+	newer := op("s4", SyncSetting, 12)
+	newer.Key, newer.Value = "theme", json.RawMessage(`"blue"`)
+	resp, err = s.Sync(ctx, 1, &dto.SyncRequest{Operations: []dto.SyncOperation{attempt1, review1, late, older, newer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := statuses(resp.Results); !slices.Equal(got, []string{"duplicate", "duplicate", "ignored", "ignored", "applied"}) {
+		t.Fatalf("resend statuses: %v", got)
+	}
+	if st, _ := repos.Settings.Find(ctx, child.ID, "theme"); string(st.Value) != `"blue"` {
+		t.Errorf("theme = %s", st.Value)
+	}
+}
+
+func TestSyncCursor(t *testing.T) {
+	ctx := context.Background()
+	s, repos, child := newTestSync(t)
+	if err := repos.Progress.Save(ctx, &models.Progress{ChildID: child.ID, LessonID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.Sync(ctx, 1, &dto.SyncRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Changes.Progress) != 1 || resp.Cursor != formatCursor(syncNow) {
+		t.Fatalf("full sync: %+v", resp)
+	}
+
+	s.now = time.Now
+	resp, err = s.Sync(ctx, 1, &dto.SyncRequest{Cursor: formatCursor(time.Now().Add(time.Minute))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Changes.Progress) != 0 || len(resp.Changes.Children) != 0 {
+		t.Fatalf("delta after cursor: %+v", resp.Changes)
+	}
+
+	if _, err := s.Sync(ctx, 1, &dto.SyncRequest{Cursor: "yesterday"}); !errors.Is(err, apperrors.New(apperrors.CodeSyncInvalidCursor)) {
+		t.Fatalf("invalid cursor: %v", err)
+	}
+}
+
+func TestTouchStreak(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	day := func(s string) *time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return &d
+	}
+	cases := []struct {
+		name       string
+		last       *time.Time
+		streak     int
+		at         time.Time
+		wantStreak int
+		wantLast   string
+	}{
+		{"first lesson", nil, 0, syncNow, 1, "2024-03-15"},
+		{"next day", day("2024-03-14"), 4, syncNow, 5, "2024-03-15"},
+		{"same day", day("2024-03-15"), 4, syncNow, 4, "2024-03-15"},
+		{"gap", day("2024-03-12"), 4, syncNow, 1, "2024-03-15"},
+		{"offline lesson before last activity", day("2024-03-15"), 4, syncNow.AddDate(0, 0, -2), 4, "2024-03-15"},
+		// 22:30 UTC 15 марта — уже 16 марта по Москве
+		{"service time zone", day("2024-03-15"), 4, time.Date(2024, 3, 15, 22, 30, 0, 0, time.UTC), 5, "2024-03-16"},
+	}
+	for _, tc := range cases {
+		child := &models.Child{LastActiveOn: tc.last, Streak: tc.streak, BestStreak: 4}
+		touchStreak(child, tc.at, moscow)
+		if child.Streak != tc.wantStreak || child.LastActiveOn.Format(time.DateOnly) != tc.wantLast || child.BestStreak != max(4, tc.wantStreak) {
+			t.Errorf("%s: streak %d (best %d), last %v", tc.name, child.Streak, child.BestStreak, child.LastActiveOn)
+		}
+	}
+}
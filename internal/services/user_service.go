@@ -1,12 +1,19 @@
 package services
 
 import (
+	"context"
 	"engkids/internal/models"
-	"gorm.io/gorm"
+	"engkids/internal/repositories"
 )
 
-func GetAllUsers(db *gorm.DB) ([]models.User, error) {
-	var users []models.User
-	err := db.Find(&users).Error
-	return users, err
+type UserService struct {
+	users repositories.UserRepository
+}
+
+func NewUserService(users repositories.UserRepository) *UserService {
+	return &UserService{users: users}
+}
+
+func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.users.List(ctx)
 }
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"engkids/internal/dto"
+	apperrors "engkids/internal/errors"
+	"engkids/internal/models"
+	"engkids/internal/repositories"
+	"engkids/pkg/logger"
+	"engkids/pkg/utils"
+)
+
+// WordService — словарь: редакторы ведут слова и составы уроков, приложение
+// читает их и показывает прогресс ребёнка по словам. Слова хранят ID
+// медиафайлов, а ссылки на файлы подписываются при каждом чтении
+type WordService struct {
+	repos *repositories.Repositories
+	media *MediaService
+}
+
+func NewWordService(repos *repositories.Repositories, media *MediaService) *WordService {
+	return &WordService{repos: repos, media: media}
+}
+
+// Create добавляет слово в словарь
+func (s *WordService) Create(ctx context.Context, req dto.WordRequest) (*dto.Word, error) {
+	word, err := newWord(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMedia(ctx, word); err != nil {
+		return nil, err
+	}
+	err = s.repos.Words.Create(ctx, word)
+	if errors.Is(err, repositories.ErrDuplicate) {
+		return nil, apperrors.New(apperrors.CodeWordExists).WithDetails(map[string]any{"headword": word.Headword, "part_of_speech": word.PartOfSpeech})
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	logger.FromContext(ctx).WithField("word_id", word.ID).Info("Word created")
+	return s.withLinks(ctx, word)
+}
+
+// Update заменяет слово и его переводы данными из запроса
+func (s *WordService) Update(ctx context.Context, id uint, req dto.WordRequest) (*dto.Word, error) {
+	word, err := newWord(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMedia(ctx, word); err != nil {
+		return nil, err
+	}
+	word.ID = id
+	err = s.repos.Words.Update(ctx, word)
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		return nil, apperrors.New(apperrors.CodeWordNotFound)
+	case errors.Is(err, repositories.ErrDuplicate):
+		return nil, apperrors.New(apperrors.CodeWordExists).WithDetails(map[string]any{"headword": word.Headword, "part_of_speech": word.PartOfSpeech})
+	case err != nil:
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	logger.FromContext(ctx).WithField("word_id", id).Info("Word updated")
+	return s.Get(ctx, id)
+}
+
+// Delete удаляет слово из словаря и из всех уроков
+func (s *WordService) Delete(ctx context.Context, id uint) error {
+	err := s.repos.Words.Delete(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperrors.New(apperrors.CodeWordNotFound)
+	}
+	if err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	logger.FromContext(ctx).WithField("word_id", id).Info("Word deleted")
+	return nil
+}
+
+func (s *WordService) Get(ctx context.Context, id uint) (*dto.Word, error) {
+	word, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withLinks(ctx, word)
+}
+
+func (s *WordService) find(ctx context.Context, id uint) (*models.Word, error) {
+	word, err := s.repos.Words.FindByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, apperrors.New(apperrors.CodeWordNotFound)
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return word, nil
+}
+
+func (s *WordService) List(ctx context.Context, filter repositories.WordFilter) ([]dto.Word, error) {
+	filter.Difficulty = cefrLevel(filter.Difficulty)
+	words, err := s.repos.Words.List(ctx, filter)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return s.listWithLinks(ctx, words)
+}
+
+func (s *WordService) LessonWords(ctx context.Context, lessonID uint) ([]dto.Word, error) {
+	words, err := s.repos.Words.ListByLesson(ctx, lessonID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return s.listWithLinks(ctx, words)
+}
+
+// LinkLesson добавляет слово в урок
+func (s *WordService) LinkLesson(ctx context.Context, lessonID, wordID uint) error {
+	if _, err := s.find(ctx, wordID); err != nil {
+		return err
+	}
+	if err := s.repos.Words.LinkLesson(ctx, lessonID, wordID); err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return nil
+}
+
+// UnlinkLesson убирает слово из урока; само слово остаётся в словаре
+func (s *WordService) UnlinkLesson(ctx context.Context, lessonID, wordID uint) error {
+	err := s.repos.Words.UnlinkLesson(ctx, lessonID, wordID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperrors.New(apperrors.CodeLessonWordNotFound)
+	}
+	if err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	return nil
+}
+
+// LessonProgress возвращает прогресс ребёнка пользователя по каждому слову урока
+func (s *WordService) LessonProgress(ctx context.Context, userID, childID, lessonID uint) (*dto.LessonWordsProgress, error) {
+	child, err := s.repos.Children.FindByID(ctx, childID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && child.ParentID != userID) {
+		return nil, apperrors.New(apperrors.CodeChildNotFound)
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+
+	words, err := s.repos.Words.ListByLesson(ctx, lessonID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	ids := make([]uint, len(words))
+	for i, w := range words {
+		ids[i] = w.ID
+	}
+	reviews, err := s.repos.Reviews.ListByWords(ctx, childID, ids)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	byWord := make(map[uint]models.WordReview, len(reviews))
+	for _, r := range reviews {
+		byWord[r.WordID] = r
+	}
+	linked, err := s.listWithLinks(ctx, words)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &dto.LessonWordsProgress{LessonID: lessonID, ChildID: childID, Total: len(words), Words: make([]dto.WordProgress, 0, len(words))}
+	for _, w := range linked {
+		p := dto.WordProgress{Word: w}
+		if r, ok := byWord[w.ID]; ok {
+			p.Box, p.Reviews = r.Box, r.Reviews
+			p.ReviewedAt, p.DueAt = &r.ReviewedAt, &r.DueAt
+			p.Learned = r.Box == len(reviewIntervals)
+		}
+		if p.Learned {
+			progress.Learned++
+		}
+		progress.Words = append(progress.Words, p)
+	}
+	return progress, nil
+}
+
+// newWord собирает слово из запроса: убирает лишние пробелы, приводит темы к
+// нижнему регистру без повторов и проверяет, что переводы не повторяют язык
+func newWord(req dto.WordRequest) (*models.Word, error) {
+	word := &models.Word{
+		Headword:       strings.TrimSpace(req.Headword),
+		PartOfSpeech:   req.PartOfSpeech,
+		Difficulty:     cefrLevel(req.Difficulty),
+		Tags:           models.StringList{},
+		Examples:       models.StringList{},
+		ImageMediaID:   req.ImageMediaID,
+		AudioUKMediaID: req.AudioUKMediaID,
+		AudioUSMediaID: req.AudioUSMediaID,
+	}
+	if word.Difficulty == "" {
+		word.Difficulty = models.DefaultWordDifficulty
+	}
+	for _, tag := range req.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(word.Tags, tag) {
+			word.Tags = append(word.Tags, tag)
+		}
+	}
+	for _, example := range req.Examples {
+		if example = strings.TrimSpace(example); example != "" {
+			word.Examples = append(word.Examples, example)
+		}
+	}
+
+	var fields []apperrors.FieldError
+	for i, t := range req.Translations {
+		if slices.ContainsFunc(word.Translations, func(wt models.WordTranslation) bool { return wt.Language == t.Language }) {
+			fields = append(fields, apperrors.FieldError{Field: "translations[" + strconv.Itoa(i) + "].language", Rule: "unique"})
+			continue
+		}
+		word.Translations = append(word.Translations, models.WordTranslation{Language: t.Language, Text: strings.TrimSpace(t.Text)})
+	}
+	if word.Headword == "" {
+		fields = append(fields, apperrors.FieldError{Field: "headword", Rule: "required"})
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.New(apperrors.CodeValidationFailed).WithFields(fields)
+	}
+	return word, nil
+}
+
+// wordMediaField — поле слова с медиафайлом: вид файла, который в нём
+// ожидается, и поле ответа для подписанной ссылки
+type wordMediaField struct {
+	field string
+	kind  string
+	id    *uint
+	url   *string
+}
+
+func wordMedia(w *dto.Word) []wordMediaField {
+	return []wordMediaField{
+		{"image_media_id", models.MediaImage, w.ImageMediaID, &w.ImageURL},
+		{"audio_uk_media_id", models.MediaAudio, w.AudioUKMediaID, &w.AudioUKURL},
+		{"audio_us_media_id", models.MediaAudio, w.AudioUSMediaID, &w.AudioUSURL},
+	}
+}
+
+// checkMedia проверяет, что медиафайлы слова загружены и подходят по виду
+func (s *WordService) checkMedia(ctx context.Context, word *models.Word) error {
+	media := wordMedia(&dto.Word{Word: *word})
+	var ids []uint
+	for _, m := range media {
+		if m.id != nil {
+			ids = append(ids, *m.id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	assets, err := s.repos.Media.FindByIDs(ctx, ids)
+	if err != nil {
+		return apperrors.Wrap(apperrors.CodeInternal, err)
+	}
+	kinds := make(map[uint]string, len(assets))
+	for _, a := range assets {
+		kinds[a.ID] = a.Kind
+	}
+	var fields []apperrors.FieldError
+	for _, m := range media {
+		if m.id != nil && kinds[*m.id] != m.kind {
+			fields = append(fields, apperrors.FieldError{Field: m.field, Rule: "media", Param: m.kind})
+		}
+	}
+	if len(fields) > 0 {
+		return apperrors.New(apperrors.CodeValidationFailed).WithFields(fields)
+	}
+	return nil
+}
+
+func (s *WordService) withLinks(ctx context.Context, word *models.Word) (*dto.Word, error) {
+	words, err := s.listWithLinks(ctx, []models.Word{*word})
+	if err != nil {
+		return nil, err
+	}
+	return &words[0], nil
+}
+
+// listWithLinks подписывает ссылки на медиафайлы слов; файлы читаются одним запросом
+func (s *WordService) listWithLinks(ctx context.Context, words []models.Word) ([]dto.Word, error) {
+	out := make([]dto.Word, len(words))
+	var ids []uint
+	for i, w := range words {
+		out[i] = dto.Word{Word: w}
+		for _, m := range wordMedia(&out[i]) {
+			if m.id != nil {
+				ids = append(ids, *m.id)
+			}
+		}
+	}
+	links, expiresAt, err := s.media.Links(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		for _, m := range wordMedia(&out[i]) {
+			if m.id != nil && links[*m.id] != "" {
+				*m.url = links[*m.id]
+				out[i].MediaExpiresAt = &expiresAt
+			}
+		}
+	}
+	return out, nil
+}
+
+// cefrLevel приводит уровень к написанию из utils.CEFRLevels: a1 -> A1.
+// Неизвестный уровень возвращается как есть
+func cefrLevel(level string) string {
+	for _, l := range utils.CEFRLevels {
+		if strings.EqualFold(level, l) {
+			return l
+		}
+	}
+	return level
+}
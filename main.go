@@ -1,54 +1,77 @@
 package main
 
 import (
+	"context"
+	"engkids/config"
 	_ "engkids/docs"
-	"engkids/internal/routes"
-	"engkids/pkg/database"
-	//"engkids/pkg/elasticsearch"
-	"engkids/pkg/logger"
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/requestid"
-	"github.com/gofiber/swagger"
+	"engkids/internal/app"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // часовые пояса планировщика в образе без tzdata
 )
 
+const usage = `Usage: engkids [command]
+
+Commands:
+  serve   HTTP API (default)
+  worker  only background jobs and scheduled tasks, without HTTP
+`
+
 func main() {
-	//es, err := elasticsearch.NewClient()
-	//if err != nil {
-	//	log.Fatal(err)
-	//}
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	if command != "serve" && command != "worker" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
-	appLogger, err := logger.NewLogger("engkids")
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to initialize logger: %v", err)
+		log.Fatalf("Invalid configuration: %v", err)
 	}
-	appLogger.Info("Logger initialized")
-
-	db := database.ConnectDB()
-
-	app := fiber.New()
 
-	app.Use(requestid.New())
-	app.Use(logger.LoggingMiddleware(appLogger))
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Content-Type, Authorization",
-	}))
-
-	app.Get("/swagger/*", swagger.HandlerDefault)
+	application, err := app.Bootstrap(cfg)
+	if err != nil {
+		log.Fatalf("Failed to start application: %v", err)
+	}
 
-	routes.SetupRoutes(app, db, appLogger)
+	// планировщик можно запускать в каждом процессе: задачи выполнит только лидер
+	if cfg.Scheduler.Enabled {
+		application.StartScheduler()
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	switch command {
+	case "serve":
+		application.StartBackground()
+		if cfg.Jobs.InProcess {
+			application.StartWorkers()
+		}
+		go func() {
+			application.Logger.WithField("port", cfg.Port).Info("Starting HTTP server")
+			if err := application.Listen(); err != nil {
+				application.Logger.Fatal("Failed to start server: ", err)
+			}
+		}()
+	case "worker":
+		application.Logger.WithField("queues", cfg.Jobs.Queues).Info("Starting job worker")
+		application.StartWorkers()
 	}
 
-	appLogger.WithField("port", port).Info("Starting HTTP server")
-	if err := app.Listen(":" + port); err != nil {
-		appLogger.Fatal("Failed to start server: ", err)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	application.Logger.Info("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := application.Shutdown(ctx); err != nil {
+		// логгер мог уже отключиться от Logstash, поэтому пишем в stderr
+		log.Printf("Shutdown finished with errors: %v", err)
 	}
 }
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// AdvisoryLock — лидерство через сессионную advisory-блокировку Postgres.
+// Блокировка держится, пока открыто выделенное соединение, поэтому при падении
+// экземпляра Postgres снимает её сам
+type AdvisoryLock struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewAdvisoryLock создаёт блокировку, ключ которой выводится из name
+func NewAdvisoryLock(db *sql.DB, name string) *AdvisoryLock {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &AdvisoryLock{db: db, key: int64(h.Sum64())}
+}
+
+// Lead захватывает блокировку или проверяет, что соединение с ней ещё живо
+func (l *AdvisoryLock) Lead(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// соединение разорвано или проверку прервал ctx. Во втором случае
+		// блокировка ещё держится, поэтому соединение закрываем, а не возвращаем
+		// в пул; другой экземпляр может захватить блокировку заново
+		discard(l.conn)
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("advisory lock: %w", err)
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&locked); err != nil {
+		// запрос мог выполниться до отмены ctx, и блокировка тогда уже захвачена
+		discard(conn)
+		return false, fmt.Errorf("advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Resign снимает блокировку и возвращает соединение в пул
+func (l *AdvisoryLock) Resign(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	if err != nil {
+		// блокировка не снята — её снимет Postgres, когда закроется соединение
+		discard(l.conn)
+		l.conn = nil
+		return fmt.Errorf("advisory unlock: %w", err)
+	}
+	l.conn.Close()
+	l.conn = nil
+	return nil
+}
+
+// discard закрывает соединение вместо возврата в пул: database/sql выбрасывает
+// соединение, если Raw вернул driver.ErrBadConn
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"engkids/config"
+)
+
+// testDB подключается к настоящему Postgres (TEST_DB_HOST и остальные
+// переменные, как у apptest); каждый вызов — отдельный пул соединений
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+	db, err := ConnectDB(config.DB{
+		Host:     host,
+		Port:     config.GetEnv("TEST_DB_PORT", "5432"),
+		User:     config.GetEnv("TEST_DB_USER", "postgres"),
+		Password: config.GetEnv("TEST_DB_PASSWORD", ""),
+		Name:     config.GetEnv("TEST_DB_NAME", "engkids_test"),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { CloseDB(db, nil) })
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+// Проверка лидерства, прерванная отменой ctx, не должна вернуть соединение с
+// блокировкой в пул: тогда её не снимет никто, пока жив процесс
+func TestAdvisoryLockReleasedWhenPingCancelled(t *testing.T) {
+	db := testDB(t)
+	lock := NewAdvisoryLock(db, "engkids_test:advisory")
+	t.Cleanup(func() { _ = lock.Resign(context.Background()) })
+
+	if ok, err := lock.Lead(t.Context()); !ok || err != nil {
+		t.Fatalf("Lead: %v %v", ok, err)
+	}
+	cancelled, cancel := context.WithCancel(t.Context())
+	cancel()
+	if ok, _ := lock.Lead(cancelled); ok {
+		t.Fatal("Lead with a cancelled context reported leadership")
+	}
+
+	// другой экземпляр (отдельный пул) должен получить блокировку
+	other := NewAdvisoryLock(testDB(t), "engkids_test:advisory")
+	t.Cleanup(func() { _ = other.Resign(context.Background()) })
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ok, err := other.Lead(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("lock is still held by a pooled connection")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -1,63 +1,70 @@
 package database
 
 import (
+	"engkids/config"
 	"engkids/internal/models"
+	"engkids/pkg/metrics"
+	"engkids/pkg/tracing"
+	"errors"
 	"fmt"
-	"log"
-	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// ConnectDB открывает подключение к базе данных
-func ConnectDB() *gorm.DB {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	if host == "" || port == "" || user == "" || dbname == "" {
-		log.Fatalf("Missing one or more required DB environment variables")
+// ConnectDB открывает подключение к базе данных и применяет миграции. Время
+// запросов и состояние пула публикуются в reg; nil — без метрик
+func ConnectDB(cfg config.DB, reg *metrics.Registry) (*gorm.DB, error) {
+	if cfg.Host == "" || cfg.Port == "" || cfg.User == "" || cfg.Name == "" {
+		return nil, errors.New("missing one or more required DB environment variables")
 	}
 
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
-	log.Println("Database connection established")
+	if reg != nil {
+		if err := db.Use(metrics.NewGormPlugin(reg)); err != nil {
+			return nil, fmt.Errorf("register metrics plugin: %w", err)
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			reg.RegisterDBStats(sqlDB)
+		}
+	}
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("register tracing plugin: %w", err)
+	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Child{}, &models.Progress{}, &models.RefreshToken{})
+	err = db.AutoMigrate(&models.User{}, &models.Child{}, &models.Progress{}, &models.RefreshToken{}, &models.OutboxEvent{}, &models.Job{}, &models.ScheduledRun{}, &models.RealtimeMessage{},
+		&models.Word{}, &models.WordTranslation{}, &models.LessonWord{}, &models.GameSession{}, &models.GameResult{}, &models.GamePlayer{}, &models.UserEvent{},
+		&models.WordReview{}, &models.ChildSetting{}, &models.SyncOperation{}, &models.IdempotencyKey{},
+		&models.MediaAsset{})
 	if err != nil {
-		log.Fatal("Error during migration: ", err)
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	// уникальным раньше было одно написание слова, и старый индекс не давал
+	// добавить омограф с другой частью речи
+	if m := db.Migrator(); m.HasIndex(&models.Word{}, "idx_words_headword") {
+		if err := m.DropIndex(&models.Word{}, "idx_words_headword"); err != nil {
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
 	}
-	fmt.Println("Migrations applied successfully")
-
-	return db
-}
-
-// DB глобальная переменная для хранения подключения к базе данных
-var DB *gorm.DB
 
-// InitDB инициализирует подключение и миграцию базы данных
-func InitDB() {
-	DB = ConnectDB()
+	return db, nil
 }
 
-// CloseDB закрывает подключение к базе данных
-func CloseDB() {
-	sqlDB, err := DB.DB()
+// CloseDB закрывает подключение к базе данных и убирает его пул из reg
+func CloseDB(db *gorm.DB, reg *metrics.Registry) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to close database connection: ", err)
+		return err
 	}
-	err = sqlDB.Close()
-	if err != nil {
-		log.Fatal("Failed to close database connection: ", err)
+	if reg != nil {
+		reg.UnregisterDBStats(sqlDB)
 	}
-	fmt.Println("Database connection closed")
+	return sqlDB.Close()
 }
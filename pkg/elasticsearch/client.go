@@ -1,105 +1,120 @@
 package elasticsearch
 
-/*
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"net/http"
 	"strings"
+	"time"
 
-	"github.com/elastic/go-elasticsearch/v8"
+	"engkids/pkg/tracing"
 )
 
-// Client обертка для клиента Elasticsearch
+// Client — минимальный клиент Elasticsearch поверх REST API
 type Client struct {
-	es *elasticsearch.Client
+	baseURL string
+	http    *http.Client
 }
 
-// NewClient создает новый клиент для работы с Elasticsearch
-func NewClient() (*Client, error) {
-	esURL := os.Getenv("ELASTICSEARCH_URL")
-	if esURL == "" {
-		esURL = "http://elasticsearch:9200"
-	}
-
-	cfg := elasticsearch.Config{
-		Addresses: []string{esURL},
-	}
+// NewClient создаёт клиент по адресу esURL и проверяет соединение
+func NewClient(esURL string) (*Client, error) {
+	c := New(esURL, nil)
 
-	es, err := elasticsearch.NewClient(cfg)
-	if err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Ping(ctx); err != nil {
 		return nil, err
 	}
 
-	// Проверяем соединение
-	res, err := es.Info()
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
+	return c, nil
+}
 
-	// Проверяем статус ответа
-	if res.IsError() {
-		return nil, fmt.Errorf("elasticsearch error: %s", res.String())
+// New создаёт клиент без проверки соединения; httpClient может быть nil
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: tracing.NewTransport(nil),
+		}
 	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), http: httpClient}
+}
 
-	return &Client{es: es}, nil
+// Ping проверяет доступность кластера
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/", nil, nil)
 }
 
-// Search выполняет поиск в Elasticsearch
-func (c *Client) Search(index string, query map[string]interface{}) (map[string]interface{}, error) {
-	var buf strings.Builder
+// Hit — найденный документ
+type Hit struct {
+	Index  string          `json:"_index"`
+	ID     string          `json:"_id"`
+	Source json.RawMessage `json:"_source"`
+	Sort   []any           `json:"sort,omitempty"`
+}
 
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		return nil, err
-	}
+// SearchResult — ответ на поисковый запрос
+type SearchResult struct {
+	Took int `json:"took"`
+	Hits struct {
+		Total struct {
+			Value    int    `json:"value"`
+			Relation string `json:"relation"`
+		} `json:"total"`
+		Hits []Hit `json:"hits"`
+	} `json:"hits"`
+}
 
-	res, err := c.es.Search(
-		c.es.Search.WithContext(context.Background()),
-		c.es.Search.WithIndex(index),
-		c.es.Search.WithBody(strings.NewReader(buf.String())),
-		c.es.Search.WithTrackTotalHits(true),
-	)
-	if err != nil {
+// Search выполняет поиск в индексе (допускаются шаблоны вида engkids-logs-*)
+func (c *Client) Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResult, error) {
+	var result SearchResult
+	path := "/" + index + "/_search?track_total_hits=true&ignore_unavailable=true&allow_no_indices=true"
+	if err := c.do(ctx, http.MethodPost, path, query, &result); err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
+	return &result, nil
+}
 
-	if res.IsError() {
-		return nil, fmt.Errorf("search error: %s", res.String())
-	}
+// IndexDocument индексирует документ в Elasticsearch
+func (c *Client) IndexDocument(ctx context.Context, index string, document map[string]interface{}) error {
+	return c.do(ctx, http.MethodPost, "/"+index+"/_doc?refresh=true", document, nil)
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		return nil, err
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(buf)
 	}
 
-	return result, nil
-}
-
-// IndexDocument индексирует документ в Elasticsearch
-func (c *Client) IndexDocument(index string, document map[string]interface{}) error {
-	var buf strings.Builder
-	if err := json.NewEncoder(&buf).Encode(document); err != nil {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
 		return err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	res, err := c.es.Index(
-		index,
-		strings.NewReader(buf.String()),
-		c.es.Index.WithContext(context.Background()),
-		c.es.Index.WithRefresh("true"),
-	)
+	res, err := c.http.Do(req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
-	if res.IsError() {
-		return fmt.Errorf("index error: %s", res.String())
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return fmt.Errorf("elasticsearch error: %s: %s", res.Status, strings.TrimSpace(string(msg)))
 	}
 
-	return nil
+	if out == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
 }
-*/
@@ -0,0 +1,45 @@
+// Package imaging уменьшает картинки для миниатюр без внешних зависимостей
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail уменьшает src так, чтобы большая сторона была не больше size, с
+// сохранением пропорций; картинки меньше size не увеличиваются. Пиксель
+// миниатюры — среднее пикселей исходника, которые в него попадают: мелкие
+// детали не теряются, как при выборе ближайшего пикселя
+func Thumbnail(src image.Image, size int) *image.NRGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, max(1, sh*size/sw)
+		} else {
+			dw, dh = max(1, sw*size/sh), size
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*sh/dh, b.Min.Y+max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*sw/dw, b.Min.X+max((x+1)*sw/dw, x*sw/dw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// RGBA возвращает цвет, умноженный на альфу: прозрачные
+					// пиксели не окрашивают соседей
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			avg := color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)}
+			dst.Set(x, y, avg)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,44 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnailKeepsAspectRatio(t *testing.T) {
+	cases := []struct {
+		w, h, size int
+		want       image.Point
+	}{
+		{1000, 500, 256, image.Pt(256, 128)},
+		{300, 900, 256, image.Pt(85, 256)},
+		{100, 80, 256, image.Pt(100, 80)},
+		{5000, 2, 256, image.Pt(256, 1)},
+	}
+	for _, c := range cases {
+		got := Thumbnail(image.NewRGBA(image.Rect(0, 0, c.w, c.h)), c.size).Bounds().Size()
+		if got != c.want {
+			t.Errorf("%dx%d -> %v, want %v", c.w, c.h, got, c.want)
+		}
+	}
+}
+
+func TestThumbnailAveragesPixels(t *testing.T) {
+	// слева красная полоса, справа в каждом блоке 2×2 — синий пиксель и три прозрачных
+	src := image.NewNRGBA(image.Rect(10, 10, 14, 12))
+	for y := 10; y < 12; y++ {
+		src.Set(10, y, color.NRGBA{R: 255, A: 255})
+		src.Set(11, y, color.NRGBA{R: 255, A: 255})
+	}
+	src.Set(12, 10, color.NRGBA{B: 255, A: 255})
+
+	dst := Thumbnail(src, 2)
+	if got := dst.NRGBAAt(0, 0); got != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("left = %v", got)
+	}
+	// прозрачные пиксели уменьшают непрозрачность, но не цвет
+	if got := dst.NRGBAAt(1, 0); got != (color.NRGBA{B: 255, A: 63}) {
+		t.Errorf("right = %v", got)
+	}
+}
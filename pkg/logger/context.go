@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// Поля, по которым связываются записи одного запроса
+const (
+	FieldRequestID = "request_id"
+	FieldUserID    = "user_id"
+	FieldChildID   = "child_id"
+	FieldRoute     = "route"
+)
+
+// localsKey — ключ c.Locals, под которым лежит логгер запроса
+const localsKey = "logger"
+
+type ctxKey struct{}
+
+func defaultEntry() *logrus.Entry {
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// requestLogger хранит запись с полями запроса. Поля дополняются по ходу
+// обработки (например, user_id после аутентификации), поэтому держим указатель
+type requestLogger struct {
+	mu    sync.RWMutex
+	entry *logrus.Entry
+}
+
+// NewContext кладёт логгер в контекст: логгер запроса или логгер приложения
+// для фоновой работы
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &requestLogger{entry: entry})
+}
+
+// FromContext возвращает логгер из контекста. Если его там нет, возвращается
+// стандартный логгер logrus
+func FromContext(ctx context.Context) *logrus.Entry {
+	if ctx == nil {
+		return defaultEntry()
+	}
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLogger); ok {
+		rl.mu.RLock()
+		defer rl.mu.RUnlock()
+		// WithContext, чтобы trace_id/span_id брались из текущего спана
+		return rl.entry.WithContext(ctx)
+	}
+	return defaultEntry().WithContext(ctx)
+}
+
+// AddFields дополняет логгер запроса полями, которые попадут во все последующие записи
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	if ctx == nil {
+		return
+	}
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLogger); ok {
+		rl.mu.Lock()
+		rl.entry = rl.entry.WithFields(fields)
+		rl.mu.Unlock()
+	}
+}
+
+// FromCtx возвращает логгер запроса для обработчика Fiber и фиксирует в нём
+// шаблон маршрута, чтобы он попал и в записи сервисов
+func FromCtx(c *fiber.Ctx) *logrus.Entry {
+	rl, ok := c.Locals(localsKey).(*requestLogger)
+	if !ok {
+		return FromContext(c.UserContext())
+	}
+	rl.mu.Lock()
+	rl.entry = rl.entry.WithField(FieldRoute, c.Route().Path)
+	entry := rl.entry
+	rl.mu.Unlock()
+	return entry.WithContext(c.UserContext())
+}
+
+// bind кладёт логгер запроса и в c.Locals, и в c.UserContext()
+func bind(c *fiber.Ctx, entry *logrus.Entry) *requestLogger {
+	rl := &requestLogger{entry: entry}
+	c.Locals(localsKey, rl)
+	c.SetUserContext(context.WithValue(c.UserContext(), ctxKey{}, rl))
+	return rl
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+func newJSONLogger() (*logrus.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	return l, &buf
+}
+
+// records разбирает JSON-строки лога
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("bad log line %q: %v", line, err)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	if got := FromContext(nil).Logger; got != logrus.StandardLogger() {
+		t.Errorf("nil context: got %p, want the standard logger", got)
+	}
+	if got := FromContext(context.Background()).Logger; got != logrus.StandardLogger() {
+		t.Errorf("empty context: got %p, want the standard logger", got)
+	}
+
+	// фоновая работа получает логгер приложения через контекст
+	l, buf := newJSONLogger()
+	FromContext(NewContext(context.Background(), logrus.NewEntry(l))).Info("background")
+	if recs := records(t, buf); len(recs) != 1 || recs[0]["msg"] != "background" {
+		t.Fatalf("app logger got %v", recs)
+	}
+}
+
+func TestAddFieldsVisibleToDerivedContexts(t *testing.T) {
+	l, buf := newJSONLogger()
+	ctx := NewContext(context.Background(), logrus.NewEntry(l).WithField(FieldRequestID, "req-1"))
+
+	// поля, добавленные через производный контекст, видны и в исходном
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	AddFields(child, logrus.Fields{FieldChildID: 7})
+	FromContext(ctx).Info("after")
+
+	recs := records(t, buf)
+	if len(recs) != 1 {
+		t.Fatalf("got %d records", len(recs))
+	}
+	if recs[0][FieldRequestID] != "req-1" || recs[0][FieldChildID] != float64(7) {
+		t.Errorf("record = %v", recs[0])
+	}
+}
+
+func TestAddFieldsWithoutRequestLogger(t *testing.T) {
+	AddFields(context.Background(), logrus.Fields{FieldChildID: 7})
+	AddFields(nil, logrus.Fields{FieldChildID: 7})
+
+	if data := FromContext(context.Background()).Data; data[FieldChildID] != nil {
+		t.Errorf("standard logger picked up request fields: %v", data)
+	}
+}
+
+func TestMiddlewareCarriesRequestFields(t *testing.T) {
+	l, buf := newJSONLogger()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("requestid", "req-42")
+		return c.Next()
+	})
+	app.Use(LoggingMiddleware(l))
+	app.Get("/games/:code", func(c *fiber.Ctx) error {
+		AddFields(c.UserContext(), logrus.Fields{FieldUserID: 3, FieldChildID: 7})
+		FromCtx(c).Info("handled")
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/games/ABCD", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	recs := records(t, buf)
+	if len(recs) != 2 {
+		t.Fatalf("got %d records: %v", len(recs), recs)
+	}
+	for _, rec := range recs {
+		if rec[FieldRequestID] != "req-42" || rec[FieldUserID] != float64(3) ||
+			rec[FieldChildID] != float64(7) || rec[FieldRoute] != "/games/:code" {
+			t.Errorf("record %q misses request fields: %v", rec["msg"], rec)
+		}
+	}
+	if recs[1]["msg"] != "request completed" || recs[1]["status"] != float64(fiber.StatusNoContent) {
+		t.Errorf("access record = %v", recs[1])
+	}
+}
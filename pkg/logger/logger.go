@@ -1,6 +1,10 @@
 package logger
 
 import (
+	"engkids/config"
+	"engkids/pkg/metrics"
+	"engkids/pkg/tracing"
+	"io"
 	"os"
 	"time"
 
@@ -13,34 +17,77 @@ func NewLogger(appName string) (*logrus.Logger, error) {
 
 	logger.SetOutput(os.Stdout)
 
+	// LOG_FILE дублирует логи в файл, откуда их читает файловое хранилище логов
+	if path := config.GetEnv("LOG_FILE", ""); path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		logger.SetOutput(io.MultiWriter(os.Stdout, file))
+	}
+
 	logger.SetFormatter(&logrus.JSONFormatter{
 		TimestampFormat: time.RFC3339Nano,
 	})
 	logger.SetLevel(logrus.InfoLevel)
 
+	// редактор должен идти первым: следующие хуки получают уже очищенную запись
+	redactor, err := NewRedactor(RedactionConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	logger.AddHook(redactor)
+	logger.AddHook(tracing.NewLogrusHook())
+
 	return logger, nil
 }
 
+// LoggingMiddleware создаёт логгер запроса с request_id (доступен через FromCtx
+// и FromContext) и пишет итоговую запись о запросе
 func LoggingMiddleware(log *logrus.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
+
+		rl := bind(c, log.WithFields(logrus.Fields{
+			FieldRequestID: c.Locals("requestid"),
+			"method":       c.Method(),
+			"path":         c.OriginalURL(),
+		}))
+
+		own := c.Route()
 		err := c.Next()
 		latency := time.Since(start)
 
-		entry := log.WithFields(logrus.Fields{
-			"method":     c.Method(),
-			"path":       c.OriginalURL(),
-			"status":     c.Response().StatusCode(),
+		rl.mu.RLock()
+		entry := rl.entry
+		rl.mu.RUnlock()
+
+		// ошибка ещё не превращена в ответ ErrorHandler'ом, поэтому статус берём из неё
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = metrics.StatusFromError(err)
+		}
+
+		fields := logrus.Fields{
+			"status":     status,
 			"latency_ms": latency.Milliseconds(),
 			"ip":         c.IP(),
-			"request_id": c.Locals("requestid"),
 			"user_agent": c.Get("User-Agent"),
-			"time":       time.Now().Format(time.RFC3339Nano),
-		})
-
+		}
+		if r := c.Route(); r != own {
+			fields[FieldRoute] = r.Path
+		}
+		entry = entry.WithContext(c.UserContext()).WithFields(fields)
 		if err != nil {
-			entry.WithField("error", err.Error()).Error("request failed")
-		} else {
+			entry = entry.WithField("error", err.Error())
+		}
+
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			entry.Error("request failed")
+		case err != nil:
+			entry.Warn("request failed")
+		default:
 			entry.Info("request completed")
 		}
 
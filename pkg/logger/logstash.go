@@ -0,0 +1,282 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"engkids/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// LogType — значение log.type, по которому пайплайн Logstash узнаёт логи приложения
+const LogType = "engkids-log"
+
+// LogstashOptions — настройки отправки логов в Logstash
+type LogstashOptions struct {
+	BufferSize   int               // сколько записей держим в памяти, пока Logstash недоступен
+	MaxLineBytes int               // записи длиннее отбрасываются
+	DialTimeout  time.Duration     // таймаут подключения
+	WriteTimeout time.Duration     // таймаут записи пачки
+	MinBackoff   time.Duration     // первая пауза перед переподключением
+	MaxBackoff   time.Duration     // максимальная пауза перед переподключением
+	Metrics      *metrics.Registry // nil — метрики не публикуются
+}
+
+func (o *LogstashOptions) setDefaults() {
+	if o.BufferSize <= 0 {
+		o.BufferSize = 10000
+	}
+	if o.MaxLineBytes <= 0 {
+		o.MaxLineBytes = 64 * 1024
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 5 * time.Second
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = 100 * time.Millisecond
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 30 * time.Second
+	}
+	if o.Metrics == nil {
+		o.Metrics = metrics.NewRegistry()
+	}
+}
+
+// LogstashHook асинхронно отправляет записи в TCP-вход Logstash с кодеком json_lines.
+// Запись лога никогда не блокируется: при переполнении буфера записи отбрасываются.
+// О недоступности Logstash хук сообщает ошибкой из Fire, которую logrus
+// печатает сам, — один раз за каждый разрыв
+type LogstashHook struct {
+	addr      string
+	opts      LogstashOptions
+	formatter *logrus.JSONFormatter
+	queue     chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	dropped   atomic.Uint64
+	failure   atomic.Pointer[error] // ошибка подключения, о которой ещё не сообщили
+
+	droppedTotal  *metrics.CounterVec
+	connectErrors *metrics.Counter
+}
+
+// NewLogstashHook создаёт хук и запускает фоновую отправку на addr (host:port)
+func NewLogstashHook(addr string, opts LogstashOptions) *LogstashHook {
+	opts.setDefaults()
+	h := &LogstashHook{
+		addr: addr,
+		opts: opts,
+		formatter: &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime: "@timestamp",
+				logrus.FieldKeyMsg:  "message",
+			},
+		},
+		queue: make(chan []byte, opts.BufferSize),
+		done:  make(chan struct{}),
+		droppedTotal: opts.Metrics.NewCounterVec(
+			"engkids_logstash_dropped_total",
+			"Количество записей лога, не отправленных в Logstash.",
+			"reason",
+		),
+		connectErrors: opts.Metrics.NewCounter(
+			"engkids_logstash_connect_errors_total",
+			"Количество неудачных подключений к Logstash.",
+		),
+	}
+	h.wg.Add(1)
+	go h.run()
+	return h
+}
+
+// Levels реализует logrus.Hook
+func (h *LogstashHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire реализует logrus.Hook
+func (h *LogstashHook) Fire(entry *logrus.Entry) error {
+	e := entry.Dup()
+	e.Level, e.Message, e.Caller = entry.Level, entry.Message, entry.Caller
+	e.Data["log"] = map[string]string{"type": LogType}
+
+	line, err := h.formatter.Format(e)
+	if err != nil {
+		h.drop("format")
+		return nil
+	}
+	if len(line) > h.opts.MaxLineBytes {
+		h.drop("too_large")
+		return nil
+	}
+
+	select {
+	case <-h.done:
+		h.drop("closed")
+		return nil
+	default:
+	}
+	select {
+	case h.queue <- line:
+	default:
+		h.drop("buffer_full")
+	}
+	if err := h.failure.Swap(nil); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Dropped возвращает количество отброшенных записей
+func (h *LogstashHook) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+func (h *LogstashHook) drop(reason string) {
+	h.dropped.Add(1)
+	h.droppedTotal.WithLabelValues(reason).Inc()
+}
+
+func (h *LogstashHook) run() {
+	defer h.wg.Done()
+
+	var (
+		conn    net.Conn
+		w       *bufio.Writer
+		pending []byte // запись, которую не удалось отправить из-за разрыва соединения
+		backoff = h.opts.MinBackoff
+	)
+	defer func() {
+		if pending != nil {
+			h.drop("shutdown")
+		}
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	connect := func() bool {
+		for {
+			c, err := net.DialTimeout("tcp", h.addr, h.opts.DialTimeout)
+			if err == nil {
+				conn, w = c, bufio.NewWriter(c)
+				backoff = h.opts.MinBackoff
+				return true
+			}
+			h.connectErrors.Inc()
+			if backoff == h.opts.MinBackoff {
+				err = fmt.Errorf("logstash: connect to %s: %w", h.addr, err)
+				h.failure.Store(&err)
+			}
+
+			// пауза с джиттером, чтобы инстансы не переподключались синхронно
+			sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			select {
+			case <-time.After(sleep):
+			case <-h.done:
+				return false
+			}
+			backoff *= 2
+			if backoff > h.opts.MaxBackoff {
+				backoff = h.opts.MaxBackoff
+			}
+		}
+	}
+
+	// write отправляет line и всё, что уже накопилось в очереди, одной пачкой
+	write := func(line []byte) bool {
+		if conn == nil && !connect() {
+			pending = line
+			return false
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(h.opts.WriteTimeout))
+		_, _ = w.Write(line)
+		batched := 0
+	batch:
+		for w.Buffered() < 256*1024 {
+			select {
+			case next := <-h.queue:
+				_, _ = w.Write(next)
+				batched++
+			default:
+				break batch
+			}
+		}
+		if err := w.Flush(); err != nil {
+			// пачка могла уйти частично; повторяем только первую запись,
+			// остальные считаем потерянными, чтобы память оставалась ограниченной
+			_ = conn.Close()
+			conn, w = nil, nil
+			pending = line
+			for i := 0; i < batched; i++ {
+				h.drop("connection_lost")
+			}
+			return false
+		}
+		pending = nil
+		return true
+	}
+
+	for {
+		if pending != nil {
+			if !write(pending) {
+				select {
+				case <-h.done:
+					return
+				default:
+					continue
+				}
+			}
+		}
+		select {
+		case line := <-h.queue:
+			write(line)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// Close прекращает приём записей, дожидается отправки буфера и закрывает соединение.
+// Если ctx истекает раньше, оставшиеся записи отбрасываются
+func (h *LogstashHook) Close(ctx context.Context) error {
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		for len(h.queue) > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+	}
+	h.closeOnce.Do(func() { close(h.done) })
+	h.wg.Wait()
+
+	for {
+		select {
+		case <-h.queue:
+			h.drop("shutdown")
+		default:
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"engkids/pkg/metrics"
+)
+
+// logstashServer — TCP-вход json_lines, складывающий сообщения в канал
+type logstashServer struct {
+	ln    net.Listener
+	lines chan string
+	conns chan net.Conn
+}
+
+func listenLogstash(t *testing.T, addr string) *logstashServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &logstashServer{ln: ln, lines: make(chan string, 1024), conns: make(chan net.Conn, 16)}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.conns <- c
+			go func() {
+				defer c.Close()
+				sc := bufio.NewScanner(c)
+				for sc.Scan() {
+					var rec struct {
+						Msg string `json:"message"`
+					}
+					if json.Unmarshal(sc.Bytes(), &rec) == nil {
+						s.lines <- rec.Msg
+					}
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+func (s *logstashServer) expect(t *testing.T, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case got := <-s.lines:
+			if got != w {
+				t.Fatalf("got message %q, want %q", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %q was not delivered", w)
+		}
+	}
+}
+
+// freeAddr возвращает адрес, на котором сейчас никто не слушает
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr
+}
+
+func fire(h *LogstashHook, msg string) error {
+	l := logrus.New()
+	return h.Fire(&logrus.Entry{Logger: l, Data: logrus.Fields{}, Time: time.Now(), Level: logrus.InfoLevel, Message: msg})
+}
+
+func testOptions(reg *metrics.Registry) LogstashOptions {
+	return LogstashOptions{
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 40 * time.Millisecond,
+		Metrics:    reg,
+	}
+}
+
+func closeHook(t *testing.T, h *LogstashHook) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// discardHook закрывает хук, не дожидаясь отправки буфера
+func discardHook(h *LogstashHook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	return h.Close(ctx)
+}
+
+func TestLogstashBuffersUntilConnected(t *testing.T) {
+	addr := freeAddr(t)
+	reg := metrics.NewRegistry()
+	h := NewLogstashHook(addr, testOptions(reg))
+
+	for _, msg := range []string{"one", "two", "three"} {
+		_ = fire(h, msg)
+	}
+	waitFor(t, func() bool {
+		return !strings.Contains(scrape(t, reg), "engkids_logstash_connect_errors_total 0")
+	})
+
+	srv := listenLogstash(t, addr)
+	srv.expect(t, "one", "two", "three")
+	closeHook(t, h)
+
+	if h.Dropped() != 0 {
+		t.Errorf("dropped %d records, want 0", h.Dropped())
+	}
+}
+
+func TestLogstashFireReportsConnectFailure(t *testing.T) {
+	h := NewLogstashHook(freeAddr(t), testOptions(metrics.NewRegistry()))
+	defer discardHook(h)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if err := fire(h, "probe"); err != nil {
+			if !strings.Contains(err.Error(), "logstash: connect to") {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := fire(h, "probe"); err != nil {
+				t.Fatalf("failure reported twice: %v", err)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Fire never reported the connect failure")
+}
+
+func TestLogstashReconnects(t *testing.T) {
+	srv := listenLogstash(t, "127.0.0.1:0")
+	h := NewLogstashHook(srv.ln.Addr().String(), testOptions(metrics.NewRegistry()))
+	defer closeHook(t, h)
+
+	_ = fire(h, "before")
+	srv.expect(t, "before")
+	(<-srv.conns).Close()
+
+	// первые записи после разрыва могут уйти в закрытый сокет,
+	// но хук должен переподключиться и продолжить отправку
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = fire(h, "after")
+		select {
+		case <-srv.conns:
+			srv.expect(t, "after")
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	t.Fatal("hook did not reconnect")
+}
+
+func TestLogstashCountsDrops(t *testing.T) {
+	reg := metrics.NewRegistry()
+	opts := testOptions(reg)
+	opts.BufferSize = 2
+	opts.MaxLineBytes = 256
+	h := NewLogstashHook(freeAddr(t), opts)
+
+	_ = fire(h, strings.Repeat("x", 300))
+	for i := 0; i < 10; i++ {
+		_ = fire(h, "queued")
+	}
+	// Logstash недоступен, буфер не опустеет: Close отбрасывает остаток по таймауту
+	if err := discardHook(h); err != context.DeadlineExceeded {
+		t.Fatalf("Close = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// одна запись слишком большая, не поместившиеся в буфер отбрасываются сразу,
+	// остальные — при закрытии
+	if got := h.Dropped(); got != 11 {
+		t.Errorf("Dropped() = %d, want 11", got)
+	}
+	out := scrape(t, reg)
+	for _, want := range []string{
+		`engkids_logstash_dropped_total{reason="too_large"} 1`,
+		`engkids_logstash_dropped_total{reason="buffer_full"}`,
+		`engkids_logstash_dropped_total{reason="shutdown"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := fire(h, "late"); err != nil {
+		t.Fatalf("Fire after Close: %v", err)
+	}
+	if !strings.Contains(scrape(t, reg), `engkids_logstash_dropped_total{reason="closed"} 1`) {
+		t.Error("records after Close are not counted")
+	}
+}
+
+func TestLogstashCloseFlushes(t *testing.T) {
+	srv := listenLogstash(t, "127.0.0.1:0")
+	h := NewLogstashHook(srv.ln.Addr().String(), testOptions(metrics.NewRegistry()))
+
+	want := make([]string, 200)
+	for i := range want {
+		want[i] = "msg"
+		if err := fire(h, want[i]); err != nil {
+			t.Fatalf("Fire: %v", err)
+		}
+	}
+	closeHook(t, h)
+	srv.expect(t, want...)
+	if h.Dropped() != 0 {
+		t.Errorf("dropped %d records, want 0", h.Dropped())
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func scrape(t *testing.T, reg *metrics.Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+	return buf.String()
+}
@@ -0,0 +1,335 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"engkids/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted — чем заменяются скрытые значения
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeys — поля, значения которых скрываются целиком. Сравнение без
+// учёта регистра, '-' и '_' (refreshToken, refresh_token и Refresh-Token совпадают).
+// Ключ с указанием типа ("models.Child.name") скрывает поле только в этом типе:
+// "name" слишком общее имя, чтобы скрывать его везде
+var DefaultRedactKeys = []string{
+	"password", "passwd", "new_password", "old_password",
+	"token", "access_token", "refresh_token", "id_token",
+	"authorization", "cookie", "set_cookie",
+	"secret", "client_secret", "api_key", "x_refresh_token", "x_new_access_token", "x_new_refresh_token",
+	"email", "child_name", "first_name", "last_name", "full_name",
+	"models.Child.name",
+}
+
+// DefaultRedactQueryParams — параметры URL, значения которых скрываются в любых строках
+var DefaultRedactQueryParams = []string{
+	"token", "access_token", "refresh_token", "code", "password", "email", "api_key", "signature",
+}
+
+// DefaultRedactPatterns — шаблоны секретов, скрываемые в любом тексте
+var DefaultRedactPatterns = []string{
+	`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`, // JWT
+	`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`,                // Authorization: Bearer ...
+	`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,    // email
+	`\$2[aby]\$[0-9]{2}\$[./A-Za-z0-9]{53}`,             // bcrypt-хеш
+}
+
+// RedactionConfig — правила скрытия чувствительных данных в логах
+type RedactionConfig struct {
+	Keys        []string // имена полей
+	QueryParams []string // параметры URL
+	Patterns    []string // регулярные выражения
+}
+
+// DefaultRedactionConfig возвращает правила по умолчанию
+func DefaultRedactionConfig() RedactionConfig {
+	return RedactionConfig{
+		Keys:        append([]string(nil), DefaultRedactKeys...),
+		QueryParams: append([]string(nil), DefaultRedactQueryParams...),
+		Patterns:    append([]string(nil), DefaultRedactPatterns...),
+	}
+}
+
+// RedactionConfigFromEnv дополняет правила по умолчанию переменными окружения:
+//
+//	LOG_REDACT_KEYS          имена полей через запятую, можно с типом: models.Child.name
+//	LOG_REDACT_QUERY_PARAMS  параметры URL через запятую
+//	LOG_REDACT_PATTERNS      регулярные выражения, разделённые " || "
+func RedactionConfigFromEnv() RedactionConfig {
+	cfg := DefaultRedactionConfig()
+	cfg.Keys = append(cfg.Keys, splitList(config.GetEnv("LOG_REDACT_KEYS", ""), ",")...)
+	cfg.QueryParams = append(cfg.QueryParams, splitList(config.GetEnv("LOG_REDACT_QUERY_PARAMS", ""), ",")...)
+	cfg.Patterns = append(cfg.Patterns, splitList(config.GetEnv("LOG_REDACT_PATTERNS", ""), "||")...)
+	return cfg
+}
+
+func splitList(raw, sep string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Redactor скрывает чувствительные данные в записях лога. Подключается первым
+// хуком, чтобы все выходы (stdout, файл, Logstash) получали уже очищенные записи
+type Redactor struct {
+	keys     map[string]struct{}
+	typeKeys map[string]map[string]struct{} // тип (models.Child) → поля этого типа
+	query    *regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor компилирует правила
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	r := &Redactor{keys: make(map[string]struct{}, len(cfg.Keys)), typeKeys: make(map[string]map[string]struct{})}
+	for _, k := range cfg.Keys {
+		i := strings.LastIndex(k, ".")
+		if i <= 0 {
+			r.keys[normalizeKey(k)] = struct{}{}
+			continue
+		}
+		typ := k[:i]
+		if r.typeKeys[typ] == nil {
+			r.typeKeys[typ] = make(map[string]struct{})
+		}
+		r.typeKeys[typ][normalizeKey(k[i+1:])] = struct{}{}
+	}
+
+	if len(cfg.QueryParams) > 0 {
+		names := make([]string, 0, len(cfg.QueryParams))
+		for _, p := range cfg.QueryParams {
+			names = append(names, regexp.QuoteMeta(p))
+		}
+		r.query = regexp.MustCompile(`(?i)([?&](?:` + strings.Join(names, "|") + `)=)[^&#\s"]*`)
+	}
+
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func normalizeKey(k string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(k))
+}
+
+// IsSensitiveKey сообщает, скрывается ли поле с таким именем целиком
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	_, ok := r.keys[normalizeKey(key)]
+	return ok
+}
+
+// String скрывает секреты в произвольной строке
+func (r *Redactor) String(s string) string {
+	if r.query != nil {
+		s = r.query.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+// Value скрывает секреты в значении поля. Структуры и карты обходятся
+// через JSON-представление, чтобы скрыть вложенные поля по имени; тип
+// исходного значения сохраняется для правил вида "models.Child.name"
+func (r *Redactor) Value(v any) any {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.String(val)
+	case error:
+		return r.String(val.Error())
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64,
+		time.Time, time.Duration:
+		return val
+	case fmt.Stringer:
+		return r.String(val.String())
+	}
+
+	rv := reflect.ValueOf(v)
+	switch reflect.Indirect(rv).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return r.String(fmt.Sprintf("%+v", v))
+		}
+		var generic any
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return Redacted
+		}
+		return r.typed(rv, generic)
+	default:
+		return r.String(fmt.Sprint(v))
+	}
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typed обходит JSON-представление generic параллельно с исходным значением v,
+// чтобы применить к полям структур правила их типа
+func (r *Redactor) typed(v reflect.Value, generic any) any {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return r.walk(generic)
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return r.walk(generic)
+	}
+	t := v.Type()
+	if t.Implements(jsonMarshaler) || t.Implements(textMarshaler) ||
+		reflect.PointerTo(t).Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(textMarshaler) {
+		return r.walk(generic)
+	}
+
+	switch val := generic.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields, typeKeys := jsonFields(t), r.typeKeys[t.String()]
+			out := make(map[string]any, len(val))
+			for k, item := range val {
+				if _, ok := typeKeys[normalizeKey(k)]; ok || r.IsSensitiveKey(k) {
+					out[k] = Redacted
+					continue
+				}
+				var fv reflect.Value
+				if index, ok := fields[k]; ok {
+					fv, _ = v.FieldByIndexErr(index)
+				}
+				out[k] = r.typed(fv, item)
+			}
+			return out
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String {
+				break
+			}
+			out := make(map[string]any, len(val))
+			for k, item := range val {
+				if r.IsSensitiveKey(k) {
+					out[k] = Redacted
+					continue
+				}
+				out[k] = r.typed(v.MapIndex(reflect.ValueOf(k).Convert(t.Key())), item)
+			}
+			return out
+		}
+	case []any:
+		if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && v.Len() == len(val) {
+			out := make([]any, len(val))
+			for i, item := range val {
+				out[i] = r.typed(v.Index(i), item)
+			}
+			return out
+		}
+	}
+	return r.walk(generic)
+}
+
+var jsonFieldsCache sync.Map // reflect.Type → map[string][]int
+
+// jsonFields сопоставляет JSON-имена полей структуры с их индексами,
+// включая поля встроенных структур
+func jsonFields(t reflect.Type) map[string][]int {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	var collect func(t reflect.Type, prefix []int)
+	collect = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			index := append(append([]int(nil), prefix...), i)
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				collect(ft, index)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if _, ok := fields[name]; !ok {
+				fields[name] = index
+			}
+		}
+	}
+	collect(t, nil)
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+func (r *Redactor) walk(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			if r.IsSensitiveKey(k) {
+				out[k] = Redacted
+				continue
+			}
+			out[k] = r.walk(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = r.walk(item)
+		}
+		return out
+	case string:
+		return r.String(val)
+	default:
+		return val
+	}
+}
+
+// Levels реализует logrus.Hook
+func (r *Redactor) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire реализует logrus.Hook. logrus передаёт хукам копию записи, поэтому
+// поля родительского логгера не изменяются
+func (r *Redactor) Fire(entry *logrus.Entry) error {
+	entry.Message = r.String(entry.Message)
+	for k, v := range entry.Data {
+		if r.IsSensitiveKey(k) {
+			entry.Data[k] = Redacted
+			continue
+		}
+		entry.Data[k] = r.Value(v)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package logstore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"engkids/pkg/elasticsearch"
+)
+
+// DefaultIndex — индексы, в которые Logstash пишет логи приложения
+const DefaultIndex = "engkids-logs-*"
+
+// ElasticStore ищет логи в Elasticsearch
+type ElasticStore struct {
+	client *elasticsearch.Client
+	index  string
+}
+
+// NewElasticStore создаёт хранилище поверх клиента Elasticsearch
+func NewElasticStore(client *elasticsearch.Client, index string) *ElasticStore {
+	if index == "" {
+		index = DefaultIndex
+	}
+	return &ElasticStore{client: client, index: index}
+}
+
+// Search реализует LogStore. Пагинация через search_after: курсор — значения
+// сортировки последней записи страницы
+func (s *ElasticStore) Search(ctx context.Context, q Query) (*Page, error) {
+	limit := q.limit()
+	query := map[string]interface{}{
+		"size": limit + 1,
+		"sort": []map[string]interface{}{
+			{"@timestamp": map[string]string{"order": "desc"}},
+			{"_doc": map[string]string{"order": "desc"}},
+		},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filters(q)},
+		},
+	}
+	if q.Cursor != "" {
+		var after []any
+		if err := decodeCursor(q.Cursor, &after); err != nil {
+			return nil, err
+		}
+		query["search_after"] = after
+	}
+
+	result, err := s.client.Search(ctx, s.index, query)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := result.Hits.Hits
+	page := &Page{Entries: make([]Entry, 0, limit)}
+	for i, hit := range hits {
+		if i == limit {
+			page.NextCursor = encodeCursor(hits[i-1].Sort)
+			break
+		}
+		var raw map[string]any
+		if err := json.Unmarshal(hit.Source, &raw); err != nil {
+			continue
+		}
+		if e, ok := parseEntry(raw); ok {
+			page.Entries = append(page.Entries, e)
+		}
+	}
+	return page, nil
+}
+
+func filters(q Query) []map[string]interface{} {
+	filters := []map[string]interface{}{}
+
+	if q.From != nil || q.To != nil {
+		rng := map[string]interface{}{}
+		if q.From != nil {
+			rng["gte"] = q.From.Format(time.RFC3339Nano)
+		}
+		if q.To != nil {
+			rng["lte"] = q.To.Format(time.RFC3339Nano)
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"@timestamp": rng},
+		})
+	}
+	if q.Level != "" {
+		filters = append(filters, map[string]interface{}{
+			"match": map[string]interface{}{"level": q.Level},
+		})
+	}
+	if q.RequestID != "" {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"request_id.keyword": q.RequestID},
+		})
+	}
+	if q.UserID != "" {
+		filters = append(filters, map[string]interface{}{
+			"match": map[string]interface{}{"user_id": q.UserID},
+		})
+	}
+	if q.Path != "" {
+		filters = append(filters, map[string]interface{}{
+			"prefix": map[string]interface{}{"path.keyword": q.Path},
+		})
+	}
+	return filters
+}
@@ -0,0 +1,281 @@
+package logstore
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore читает JSON-строки из файлов в каталоге (по умолчанию logs/).
+// Используется, когда Elasticsearch недоступен. Файлы читаются с конца и
+// сливаются по времени, поэтому поиск читает только хвосты файлов, нужные для
+// страницы. Строки в каждом файле должны идти в порядке записи, как их пишет logrus
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore создаёт хранилище поверх каталога с логами
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+type fileEntry struct {
+	Entry
+	key string // файл и смещение строки, для стабильной сортировки
+}
+
+type fileCursor struct {
+	T   int64  `json:"t"`
+	Key string `json:"k"`
+}
+
+// Search реализует LogStore
+func (s *FileStore) Search(ctx context.Context, q Query) (*Page, error) {
+	var after *fileCursor
+	if q.Cursor != "" {
+		after = &fileCursor{}
+		if err := decodeCursor(q.Cursor, after); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Page{Entries: []Entry{}}, nil
+		}
+		return nil, err
+	}
+
+	sources := &sourceHeap{}
+	defer sources.close()
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		src, err := openSource(filepath.Join(s.dir, f.Name()), f.Name(), q, after)
+		if err != nil {
+			return nil, err
+		}
+		sources.all = append(sources.all, src)
+		ok, err := src.next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			sources.ready = append(sources.ready, src)
+		}
+	}
+	heap.Init(sources)
+
+	limit := q.limit()
+	page := &Page{Entries: make([]Entry, 0, limit)}
+	var last fileEntry
+	for sources.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(page.Entries) == limit {
+			page.NextCursor = encodeCursor(fileCursor{T: last.Timestamp.UnixNano(), Key: last.key})
+			break
+		}
+		src := sources.ready[0]
+		last = src.head
+		page.Entries = append(page.Entries, last.Entry)
+
+		ok, err := src.next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			heap.Fix(sources, 0)
+		} else {
+			heap.Pop(sources)
+		}
+	}
+	return page, nil
+}
+
+func newer(a, b fileEntry) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+	return a.key > b.key
+}
+
+// fileSource выдаёт подходящие под запрос записи одного файла от новых к старым
+type fileSource struct {
+	name  string
+	f     *os.File
+	lines *backwardScanner
+	q     Query
+	after *fileCursor
+	head  fileEntry // очередная запись
+}
+
+func openSource(path, name string, q Query, after *fileCursor) (*fileSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileSource{name: name, f: f, lines: newBackwardScanner(f, info.Size()), q: q, after: after}, nil
+}
+
+// next читает записи до следующей подходящей; false — в файле их больше нет
+func (s *fileSource) next() (bool, error) {
+	for s.lines.Scan() {
+		var raw map[string]any
+		if err := json.Unmarshal(s.lines.Bytes(), &raw); err != nil {
+			continue
+		}
+		e, ok := parseEntry(raw)
+		if !ok {
+			continue
+		}
+		// дальше в файле только более старые записи
+		if s.q.From != nil && e.Timestamp.Before(*s.q.From) {
+			return false, nil
+		}
+		if !matches(e, s.q) {
+			continue
+		}
+		fe := fileEntry{Entry: e, key: fmt.Sprintf("%s:%012d", s.name, s.lines.Offset())}
+		if s.after != nil {
+			// берём только записи строго «старше» курсора
+			ts := e.Timestamp.UnixNano()
+			if ts > s.after.T || (ts == s.after.T && fe.key >= s.after.Key) {
+				continue
+			}
+		}
+		s.head = fe
+		return true, nil
+	}
+	return false, s.lines.Err()
+}
+
+// sourceHeap — файлы, упорядоченные по новизне очередной записи
+type sourceHeap struct {
+	all   []*fileSource // все открытые файлы, для закрытия
+	ready []*fileSource // файлы, в которых ещё есть записи
+}
+
+func (h *sourceHeap) Len() int           { return len(h.ready) }
+func (h *sourceHeap) Less(i, j int) bool { return newer(h.ready[i].head, h.ready[j].head) }
+func (h *sourceHeap) Swap(i, j int)      { h.ready[i], h.ready[j] = h.ready[j], h.ready[i] }
+func (h *sourceHeap) Push(x any)         { h.ready = append(h.ready, x.(*fileSource)) }
+func (h *sourceHeap) Pop() any {
+	last := h.ready[len(h.ready)-1]
+	h.ready = h.ready[:len(h.ready)-1]
+	return last
+}
+
+func (h *sourceHeap) close() {
+	for _, src := range h.all {
+		src.f.Close()
+	}
+}
+
+const (
+	backwardChunk = 64 * 1024
+	maxLineSize   = 1024 * 1024
+)
+
+// backwardScanner читает строки файла от последней к первой, блоками с конца
+type backwardScanner struct {
+	r    io.ReaderAt
+	pos  int64  // начало ещё не прочитанной части файла
+	buf  []byte // прочитанные, но ещё не выданные байты [pos, pos+len(buf))
+	line []byte
+	off  int64
+	err  error
+}
+
+func newBackwardScanner(r io.ReaderAt, size int64) *backwardScanner {
+	return &backwardScanner{r: r, pos: size}
+}
+
+// Scan переходит к предыдущей непустой строке
+func (b *backwardScanner) Scan() bool {
+	for b.err == nil {
+		if i := bytes.LastIndexByte(b.buf, '\n'); i >= 0 {
+			line := bytes.TrimSuffix(b.buf[i+1:], []byte("\r"))
+			b.buf = b.buf[:i]
+			if len(line) == 0 {
+				continue
+			}
+			b.line, b.off = line, b.pos+int64(i)+1
+			return true
+		}
+		if b.pos == 0 {
+			if len(b.buf) == 0 {
+				return false
+			}
+			b.line, b.off, b.buf = bytes.TrimSuffix(b.buf, []byte("\r")), 0, nil
+			return true
+		}
+		if len(b.buf) > maxLineSize {
+			b.err = bufio.ErrTooLong
+			return false
+		}
+		n := min(backwardChunk, b.pos)
+		chunk := make([]byte, n+int64(len(b.buf)))
+		if _, err := b.r.ReadAt(chunk[:n], b.pos-n); err != nil {
+			b.err = err
+			return false
+		}
+		copy(chunk[n:], b.buf)
+		b.pos -= n
+		b.buf = chunk
+	}
+	return false
+}
+
+// Bytes возвращает текущую строку; она действует до следующего Scan
+func (b *backwardScanner) Bytes() []byte { return b.line }
+
+// Offset возвращает смещение текущей строки от начала файла
+func (b *backwardScanner) Offset() int64 { return b.off }
+
+func (b *backwardScanner) Err() error { return b.err }
+
+func matches(e Entry, q Query) bool {
+	if q.From != nil && e.Timestamp.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && e.Timestamp.After(*q.To) {
+		return false
+	}
+	if q.Level != "" && !strings.EqualFold(e.Level, q.Level) {
+		return false
+	}
+	if q.RequestID != "" && fieldString(e, "request_id") != q.RequestID {
+		return false
+	}
+	if q.UserID != "" && fieldString(e, "user_id") != q.UserID {
+		return false
+	}
+	if q.Path != "" && !strings.HasPrefix(fieldString(e, "path"), q.Path) {
+		return false
+	}
+	return true
+}
+
+func fieldString(e Entry, key string) string {
+	v, ok := e.Fields[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
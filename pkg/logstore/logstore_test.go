@@ -0,0 +1,199 @@
+package logstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"engkids/pkg/elasticsearch"
+)
+
+func writeLines(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStoreFiltersAndPaginates(t *testing.T) {
+	dir := t.TempDir()
+	writeLines(t, dir, "app.log",
+		`{"time":"2025-01-01T10:00:00Z","level":"info","msg":"request completed","path":"/api/auth/login","request_id":"a","user_id":1}`,
+		`{"time":"2025-01-01T10:01:00Z","level":"error","msg":"request failed","path":"/api/user/profile","request_id":"b","user_id":2}`,
+		`not json`,
+		`{"time":"2025-01-01T10:02:00Z","level":"info","msg":"request completed","path":"/api/auth/refresh","request_id":"c","user_id":1}`,
+	)
+	writeLines(t, dir, "app.1.log",
+		`{"time":"2025-01-01T09:59:00Z","level":"info","msg":"Logger initialized"}`,
+	)
+
+	store := NewFileStore(dir)
+	ctx := context.Background()
+
+	page, err := store.Search(ctx, Query{UserID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].Fields["request_id"] != "c" {
+		t.Fatalf("unexpected entries: %+v", page.Entries)
+	}
+
+	page, err = store.Search(ctx, Query{Level: "ERROR", Path: "/api/user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Message != "request failed" {
+		t.Fatalf("unexpected entries: %+v", page.Entries)
+	}
+
+	from := time.Date(2025, 1, 1, 10, 0, 30, 0, time.UTC)
+	page, err = store.Search(ctx, Query{From: &from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 2 {
+		t.Fatalf("expected 2 entries after %s, got %d", from, len(page.Entries))
+	}
+
+	var seen []string
+	q := Query{Limit: 2}
+	for {
+		page, err := store.Search(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page.Entries {
+			seen = append(seen, e.Timestamp.Format("15:04"))
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if got := strings.Join(seen, ","); got != "10:02,10:01,10:00,09:59" {
+		t.Fatalf("unexpected order across pages: %s", got)
+	}
+
+	if _, err := store.Search(ctx, Query{Cursor: "%%%"}); err != ErrInvalidCursor {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestFileStoreMergesFilesNewestFirst(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	// два инстанса пишут каждый в свой файл; записей больше, чем блок чтения
+	var a, b []string
+	for i := range 3000 {
+		line := fmt.Sprintf(`{"time":%q,"level":"info","msg":"tick %d","padding":%q}`,
+			base.Add(time.Duration(i)*time.Second).Format(time.RFC3339Nano), i, strings.Repeat("x", 80))
+		if i%3 == 0 {
+			a = append(a, line)
+		} else {
+			b = append(b, line)
+		}
+	}
+	writeLines(t, dir, "a.log", a...)
+	writeLines(t, dir, "b.log", strings.Join(b, "\r\n"))
+
+	store := NewFileStore(dir)
+	q := Query{Limit: MaxLimit}
+	want := 2999
+	for {
+		page, err := store.Search(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page.Entries {
+			if e.Message != fmt.Sprintf("tick %d", want) {
+				t.Fatalf("got %q, want tick %d", e.Message, want)
+			}
+			want--
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if want != -1 {
+		t.Fatalf("stopped before tick %d", want)
+	}
+}
+
+func TestFileStoreReadsOnlyWhatThePageNeeds(t *testing.T) {
+	dir := t.TempDir()
+	// строка длиннее допустимой в начале файла: до неё доходит только
+	// запрос, которому нужны старые записи
+	writeLines(t, dir, "app.log",
+		`{"time":"2025-01-01T09:00:00Z","level":"info","msg":"`+strings.Repeat("x", 2<<20)+`"}`,
+		`{"time":"2025-01-01T10:00:00Z","level":"info","msg":"old"}`,
+		`{"time":"2025-01-01T11:00:00Z","level":"info","msg":"new"}`,
+	)
+	store := NewFileStore(dir)
+
+	page, err := store.Search(context.Background(), Query{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Message != "new" || page.NextCursor == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+	// чтение файла останавливается на первой записи старше From
+	from := time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)
+	if page, err = store.Search(context.Background(), Query{From: &from}); err != nil || len(page.Entries) != 1 {
+		t.Fatalf("from query = %+v, %v", page, err)
+	}
+	if _, err := store.Search(context.Background(), Query{}); err == nil {
+		t.Fatal("oversized line not reported")
+	}
+}
+
+func TestElasticStoreBuildsQueryAndCursor(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/engkids-logs-*/_search") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":3},"hits":[
+			{"_id":"1","_source":{"@timestamp":"2025-01-01T10:02:00Z","level":"info","message":"c","request_id":"r"},"sort":[1735725720000,3]},
+			{"_id":"2","_source":{"@timestamp":"2025-01-01T10:01:00Z","level":"info","message":"b","request_id":"r"},"sort":[1735725660000,2]},
+			{"_id":"3","_source":{"@timestamp":"2025-01-01T10:00:00Z","level":"info","message":"a","request_id":"r"},"sort":[1735725600000,1]}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	store := NewElasticStore(elasticsearch.New(srv.URL, srv.Client()), "")
+	page, err := store.Search(context.Background(), Query{RequestID: "r", Level: "info", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["size"].(float64) != 3 {
+		t.Fatalf("expected size limit+1, got %v", got["size"])
+	}
+	filter := got["query"].(map[string]any)["bool"].(map[string]any)["filter"].([]any)
+	if len(filter) != 2 {
+		t.Fatalf("expected 2 filters, got %v", filter)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].Message != "c" || page.NextCursor == "" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	if _, err := store.Search(context.Background(), Query{Cursor: page.NextCursor}); err != nil {
+		t.Fatal(err)
+	}
+	after := got["search_after"].([]any)
+	if len(after) != 2 || after[0].(float64) != 1735725660000 {
+		t.Fatalf("unexpected search_after: %v", after)
+	}
+}
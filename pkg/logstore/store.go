@@ -0,0 +1,108 @@
+package logstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultLimit и MaxLimit ограничивают размер страницы
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrInvalidCursor возвращается, если курсор не удалось разобрать
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Query — фильтры поиска по логам. Пустые поля не фильтруют
+type Query struct {
+	From      *time.Time
+	To        *time.Time
+	Level     string
+	RequestID string
+	UserID    string
+	Path      string // префикс пути запроса
+	Limit     int
+	Cursor    string
+}
+
+// Entry — одна запись лога
+type Entry struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// Page — страница результатов; NextCursor пуст на последней странице
+type Page struct {
+	Entries    []Entry `json:"entries"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// LogStore ищет записи логов, отсортированные от новых к старым
+type LogStore interface {
+	Search(ctx context.Context, q Query) (*Page, error)
+}
+
+func (q Query) limit() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultLimit
+	case q.Limit > MaxLimit:
+		return MaxLimit
+	default:
+		return q.Limit
+	}
+}
+
+func encodeCursor(v any) string {
+	raw, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// parseEntry приводит запись logrus (time/msg) или Logstash (@timestamp/message) к Entry
+func parseEntry(raw map[string]any) (Entry, bool) {
+	e := Entry{Fields: make(map[string]any, len(raw))}
+	for k, v := range raw {
+		switch k {
+		case "@timestamp", "time":
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				continue
+			}
+			// @timestamp от Logstash приоритетнее времени logrus
+			if e.Timestamp.IsZero() || k == "@timestamp" {
+				e.Timestamp = t
+			}
+		case "level":
+			e.Level = fmt.Sprint(v)
+		case "msg", "message":
+			e.Message = fmt.Sprint(v)
+		case "@version", "log", "host", "event":
+			// служебные поля Logstash
+		default:
+			e.Fields[k] = v
+		}
+	}
+	return e, !e.Timestamp.IsZero()
+}
@@ -0,0 +1,129 @@
+// Package mailer отправляет письма родителям
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"engkids/pkg/logger"
+	"engkids/pkg/tracing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Message — письмо в виде простого текста
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer пишет письма в лог вместо отправки — для разработки и окружений без SMTP
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info("Email not sent, SMTP is not configured")
+	return nil
+}
+
+// SMTP отправляет письма через SMTP-сервер с аутентификацией PLAIN
+type SMTP struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+}
+
+// Send отправляет письмо. Отмена ctx обрывает соединение с сервером, дедлайн
+// ctx ограничивает весь обмен
+func (s SMTP) Send(ctx context.Context, msg Message) (err error) {
+	ctx, span := tracing.Start(ctx, "smtp.send",
+		tracing.WithSpanKind(tracing.SpanKindClient),
+		tracing.WithAttributes("server.address", s.Addr),
+	)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if err := s.send(ctx, msg); err != nil {
+		return fmt.Errorf("send email to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// send повторяет smtp.SendMail, но на соединении, которое слушает ctx
+func (s SMTP) send(ctx context.Context, msg Message) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, s.format(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s SMTP) format(msg Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"engkids/pkg/tracing"
+)
+
+// fakeSMTP принимает одно письмо без TLS и аутентификации
+func fakeSMTP(t *testing.T, greet bool) (addr string, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	out := make(chan string, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if !greet {
+			// сервер завис: клиент должен уйти по контексту
+			_, _ = conn.Read(make([]byte, 1))
+			return
+		}
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 fake ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line + " x")[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				reply("250 queued")
+				out <- data.String()
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), out
+}
+
+func TestSMTPSend(t *testing.T) {
+	var spans bytes.Buffer
+	provider := tracing.NewProvider(tracing.Config{Exporter: tracing.NewStdoutExporter(&spans), SampleRatio: 1})
+	ctx := tracing.ContextWithProvider(context.Background(), provider)
+
+	addr, received := fakeSMTP(t, true)
+	m := SMTP{Addr: addr, From: "noreply@example.com"}
+	err := m.Send(ctx, Message{To: "parent@example.com", Subject: "Итоги недели", Body: "Привет!\nПока."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := <-received
+	for _, want := range []string{"To: parent@example.com\r\n", "Subject: =?utf-8?q?", "\r\n\r\nПривет!\r\nПока."} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var span struct {
+		Name       string         `json:"name"`
+		Kind       int            `json:"kind"`
+		Attributes map[string]any `json:"attributes"`
+	}
+	if err := json.Unmarshal(spans.Bytes(), &span); err != nil {
+		t.Fatalf("span: %v: %s", err, spans.String())
+	}
+	if span.Name != "smtp.send" || span.Kind != int(tracing.SpanKindClient) || span.Attributes["server.address"] != addr {
+		t.Errorf("span = %+v", span)
+	}
+}
+
+func TestSMTPSendHonorsContext(t *testing.T) {
+	addr, _ := fakeSMTP(t, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := SMTP{Addr: addr, From: "noreply@example.com"}.Send(ctx, Message{To: "parent@example.com"})
+	if err == nil {
+		t.Fatal("send to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("send took %s after the deadline", elapsed)
+	}
+}
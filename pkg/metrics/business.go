@@ -0,0 +1,42 @@
+package metrics
+
+// Business — доменные метрики приложения
+type Business struct {
+	Registrations    *Counter
+	Logins           *Counter
+	FailedLogins     *CounterVec
+	LessonsCompleted *Counter
+	GamesActive      *Gauge
+	GamesFinished    *Counter
+}
+
+// NewBusiness регистрирует доменные метрики в реестре r
+func NewBusiness(r *Registry) *Business {
+	return &Business{
+		Registrations: r.NewCounter(
+			"engkids_registrations_total",
+			"Количество успешных регистраций.",
+		),
+		Logins: r.NewCounter(
+			"engkids_logins_total",
+			"Количество успешных входов.",
+		),
+		FailedLogins: r.NewCounterVec(
+			"engkids_failed_logins_total",
+			"Количество неудачных попыток входа.",
+			"reason",
+		),
+		LessonsCompleted: r.NewCounter(
+			"engkids_lessons_completed_total",
+			"Количество завершённых детьми уроков.",
+		),
+		GamesActive: r.NewGauge(
+			"engkids_games_active",
+			"Количество викторин в лобби и в процессе игры.",
+		),
+		GamesFinished: r.NewCounter(
+			"engkids_games_finished_total",
+			"Количество сыгранных до конца викторин.",
+		),
+	}
+}
@@ -0,0 +1,305 @@
+package metrics
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// labelKey склеивает значения лейблов в ключ карты
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// vec — общая часть метрик с лейблами
+type vec[T any] struct {
+	mu         sync.RWMutex
+	metricName string
+	help       string
+	labelNames []string
+	values     map[string]*T
+	labels     map[string][]string
+	newValue   func() *T
+}
+
+func newVec[T any](name, help string, labelNames []string, newValue func() *T) vec[T] {
+	return vec[T]{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]*T),
+		labels:     make(map[string][]string),
+		newValue:   newValue,
+	}
+}
+
+func (v *vec[T]) name() string { return v.metricName }
+
+func (v *vec[T]) labelShape() string { return labelKey(v.labelNames) }
+
+func (v *vec[T]) with(labelValues []string) *T {
+	if len(labelValues) != len(v.labelNames) {
+		panic("metrics: wrong label count for " + v.metricName)
+	}
+	key := labelKey(labelValues)
+
+	v.mu.RLock()
+	val, ok := v.values[key]
+	v.mu.RUnlock()
+	if ok {
+		return val
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if val, ok = v.values[key]; ok {
+		return val
+	}
+	val = v.newValue()
+	v.values[key] = val
+	v.labels[key] = append([]string(nil), labelValues...)
+	return val
+}
+
+// each обходит значения в детерминированном порядке
+func (v *vec[T]) each(fn func(labelValues []string, val *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v.mu.RLock()
+		val, labels := v.values[k], v.labels[k]
+		v.mu.RUnlock()
+		fn(labels, val)
+	}
+}
+
+// Counter — монотонно растущий счётчик
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc увеличивает счётчик на единицу
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add увеличивает счётчик на delta; отрицательные значения игнорируются
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+// Value возвращает текущее значение счётчика
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// CounterVec — набор счётчиков, различающихся значениями лейблов
+type CounterVec struct {
+	vec[Counter]
+}
+
+// NewCounterVec создаёт и регистрирует счётчик с лейблами
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	cv := &CounterVec{newVec(name, help, labelNames, func() *Counter { return &Counter{} })}
+	return r.register(cv).(*CounterVec)
+}
+
+// NewCounter создаёт и регистрирует счётчик без лейблов
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+// WithLabelValues возвращает счётчик для заданных значений лейблов
+func (cv *CounterVec) WithLabelValues(values ...string) *Counter {
+	return cv.with(values)
+}
+
+func (cv *CounterVec) shape() string { return "counter:" + cv.labelShape() }
+
+func (cv *CounterVec) write(buf *bytes.Buffer) {
+	writeHeader(buf, cv.metricName, cv.help, "counter")
+	cv.each(func(labels []string, c *Counter) {
+		writeSample(buf, cv.metricName, cv.labelNames, labels, c.Value())
+	})
+}
+
+// Gauge — значение, которое может как расти, так и уменьшаться
+type Gauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Set устанавливает значение
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+// Inc увеличивает значение на единицу
+func (g *Gauge) Inc() { g.Add(1) }
+
+// Dec уменьшает значение на единицу
+func (g *Gauge) Dec() { g.Add(-1) }
+
+// Add изменяет значение на delta
+func (g *Gauge) Add(delta float64) {
+	g.mu.Lock()
+	g.value += delta
+	g.mu.Unlock()
+}
+
+// Value возвращает текущее значение
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// GaugeVec — набор gauge, различающихся значениями лейблов
+type GaugeVec struct {
+	vec[Gauge]
+}
+
+// NewGaugeVec создаёт и регистрирует gauge с лейблами
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	gv := &GaugeVec{newVec(name, help, labelNames, func() *Gauge { return &Gauge{} })}
+	return r.register(gv).(*GaugeVec)
+}
+
+// NewGauge создаёт и регистрирует gauge без лейблов
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).WithLabelValues()
+}
+
+// WithLabelValues возвращает gauge для заданных значений лейблов
+func (gv *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return gv.with(values)
+}
+
+func (gv *GaugeVec) shape() string { return "gauge:" + gv.labelShape() }
+
+func (gv *GaugeVec) write(buf *bytes.Buffer) {
+	writeHeader(buf, gv.metricName, gv.help, "gauge")
+	gv.each(func(labels []string, g *Gauge) {
+		writeSample(buf, gv.metricName, gv.labelNames, labels, g.Value())
+	})
+}
+
+// GaugeFunc — gauge, значение которого вычисляется в момент скрейпа
+type GaugeFunc struct {
+	metricName string
+	help       string
+	fn         func() float64
+}
+
+// NewGaugeFunc создаёт и регистрирует вычисляемый gauge. Повторная
+// регистрация с тем же именем — паника: старая функция не заменяется молча
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{metricName: name, help: help, fn: fn}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string { return g.metricName }
+
+func (g *GaugeFunc) shape() string { return "" }
+
+func (g *GaugeFunc) write(buf *bytes.Buffer) {
+	writeHeader(buf, g.metricName, g.help, "gauge")
+	writeSample(buf, g.metricName, nil, nil, g.fn())
+}
+
+// DefBuckets — границы бакетов по умолчанию, в секундах
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram считает распределение наблюдаемых значений по бакетам
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Observe добавляет наблюдение
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// HistogramVec — набор гистограмм, различающихся значениями лейблов
+type HistogramVec struct {
+	vec[Histogram]
+	buckets []float64
+}
+
+// NewHistogramVec создаёт и регистрирует гистограмму с лейблами; при пустом
+// buckets используются DefBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	hv := &HistogramVec{buckets: buckets}
+	hv.vec = newVec(name, help, labelNames, func() *Histogram {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	})
+	return r.register(hv).(*HistogramVec)
+}
+
+// WithLabelValues возвращает гистограмму для заданных значений лейблов
+func (hv *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return hv.with(values)
+}
+
+func (hv *HistogramVec) shape() string {
+	bounds := make([]string, len(hv.buckets))
+	for i, b := range hv.buckets {
+		bounds[i] = formatFloat(b)
+	}
+	return "histogram:" + hv.labelShape() + ":" + strings.Join(bounds, ",")
+}
+
+func (hv *HistogramVec) write(buf *bytes.Buffer) {
+	writeHeader(buf, hv.metricName, hv.help, "histogram")
+	bucketLabels := append(append([]string(nil), hv.labelNames...), "le")
+	hv.each(func(labels []string, h *Histogram) {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		values := append(append([]string(nil), labels...), "")
+		for i, upper := range hv.buckets {
+			values[len(values)-1] = formatFloat(upper)
+			writeSample(buf, hv.metricName+"_bucket", bucketLabels, values, float64(counts[i]))
+		}
+		values[len(values)-1] = "+Inf"
+		writeSample(buf, hv.metricName+"_bucket", bucketLabels, values, float64(count))
+		writeSample(buf, hv.metricName+"_sum", hv.labelNames, labels, sum)
+		writeSample(buf, hv.metricName+"_count", hv.labelNames, labels, float64(count))
+	})
+}
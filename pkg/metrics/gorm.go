@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+// GormPlugin — плагин GORM, замеряющий время выполнения запросов
+type GormPlugin struct {
+	duration *HistogramVec
+}
+
+// NewGormPlugin создаёт плагин для db.Use, пишущий в реестр r
+func NewGormPlugin(r *Registry) *GormPlugin {
+	return &GormPlugin{duration: r.NewHistogramVec(
+		"engkids_db_query_duration_seconds",
+		"Время выполнения запросов GORM в секундах.",
+		[]float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		"operation", "table", "status",
+	)}
+}
+
+// Name реализует gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "engkids:metrics"
+}
+
+// Initialize реализует gorm.Plugin: регистрирует колбэки до и после каждой операции
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(string) error
+		after     func(string) error
+	}{
+		{"create", cbBefore(cb.Create().Before("gorm:create")), p.cbAfter(cb.Create().After("gorm:create"))},
+		{"query", cbBefore(cb.Query().Before("gorm:query")), p.cbAfter(cb.Query().After("gorm:query"))},
+		{"update", cbBefore(cb.Update().Before("gorm:update")), p.cbAfter(cb.Update().After("gorm:update"))},
+		{"delete", cbBefore(cb.Delete().Before("gorm:delete")), p.cbAfter(cb.Delete().After("gorm:delete"))},
+		{"row", cbBefore(cb.Row().Before("gorm:row")), p.cbAfter(cb.Row().After("gorm:row"))},
+		{"raw", cbBefore(cb.Raw().Before("gorm:raw")), p.cbAfter(cb.Raw().After("gorm:raw"))},
+	}
+
+	for _, h := range hooks {
+		if err := h.before(h.operation); err != nil {
+			return err
+		}
+		if err := h.after(h.operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type callbackRegisterer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func cbBefore(r callbackRegisterer) func(string) error {
+	return func(operation string) error {
+		return r.Register("metrics:before_"+operation, func(db *gorm.DB) {
+			db.InstanceSet(gormStartKey, time.Now())
+		})
+	}
+}
+
+func (p *GormPlugin) cbAfter(r callbackRegisterer) func(string) error {
+	return func(operation string) error {
+		return r.Register("metrics:after_"+operation, func(db *gorm.DB) {
+			v, ok := db.InstanceGet(gormStartKey)
+			if !ok {
+				return
+			}
+			start, ok := v.(time.Time)
+			if !ok {
+				return
+			}
+
+			status := "ok"
+			if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+				status = "error"
+			}
+			table := db.Statement.Table
+			if table == "" {
+				table = "unknown"
+			}
+			p.duration.WithLabelValues(operation, table, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// dbPoolsName — имя, под которым в реестре лежат gauge пулов соединений
+const dbPoolsName = "engkids_db_pool"
+
+// dbPools — gauge состояния пулов соединений с БД. Значения суммируются по
+// всем зарегистрированным пулам: в процессе может быть несколько подключений,
+// например в тестах
+type dbPools struct {
+	mu    sync.Mutex
+	pools map[*sql.DB]struct{}
+}
+
+func (d *dbPools) name() string  { return dbPoolsName }
+func (d *dbPools) shape() string { return "db_pools" }
+
+func (d *dbPools) write(buf *bytes.Buffer) {
+	d.mu.Lock()
+	if len(d.pools) == 0 {
+		d.mu.Unlock()
+		return
+	}
+	var total sql.DBStats
+	for db := range d.pools {
+		s := db.Stats()
+		total.MaxOpenConnections += s.MaxOpenConnections
+		total.OpenConnections += s.OpenConnections
+		total.InUse += s.InUse
+		total.Idle += s.Idle
+		total.WaitCount += s.WaitCount
+		total.WaitDuration += s.WaitDuration
+	}
+	d.mu.Unlock()
+
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"engkids_db_pool_max_open_connections", "Максимальное количество открытых соединений с БД.", float64(total.MaxOpenConnections)},
+		{"engkids_db_pool_open_connections", "Количество открытых соединений с БД.", float64(total.OpenConnections)},
+		{"engkids_db_pool_in_use_connections", "Количество соединений с БД, занятых запросами.", float64(total.InUse)},
+		{"engkids_db_pool_idle_connections", "Количество простаивающих соединений с БД.", float64(total.Idle)},
+		{"engkids_db_pool_wait_count", "Суммарное количество ожиданий свободного соединения.", float64(total.WaitCount)},
+		{"engkids_db_pool_wait_duration_seconds", "Суммарное время ожидания свободного соединения в секундах.", total.WaitDuration.Seconds()},
+	}
+	for _, g := range gauges {
+		writeHeader(buf, g.name, g.help, "gauge")
+		writeSample(buf, g.name, nil, nil, g.value)
+	}
+}
+
+// RegisterDBStats добавляет пул в gauge состояния пулов соединений. Повторная
+// регистрация того же пула ничего не меняет
+func (r *Registry) RegisterDBStats(sqlDB *sql.DB) {
+	pools := r.register(&dbPools{pools: make(map[*sql.DB]struct{})}).(*dbPools)
+	pools.mu.Lock()
+	pools.pools[sqlDB] = struct{}{}
+	pools.mu.Unlock()
+}
+
+// UnregisterDBStats убирает пул из gauge; вызывается при закрытии подключения
+func (r *Registry) UnregisterDBStats(sqlDB *sql.DB) {
+	r.mu.RLock()
+	pools, ok := r.collectors[dbPoolsName].(*dbPools)
+	r.mu.RUnlock()
+	if !ok {
+		return
+	}
+	pools.mu.Lock()
+	delete(pools.pools, sqlDB)
+	pools.mu.Unlock()
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	apperrors "engkids/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// unmatchedRoute — значение лейбла route для запросов, не попавших ни в один маршрут,
+// чтобы произвольные пути не раздували кардинальность
+const unmatchedRoute = "unmatched"
+
+// Middleware считает количество и длительность запросов с лейблами
+// по шаблону маршрута (например /api/users/:id) и статусу ответа
+func (r *Registry) Middleware() fiber.Handler {
+	requestsTotal := r.NewCounterVec(
+		"engkids_http_requests_total",
+		"Количество обработанных HTTP-запросов.",
+		"method", "route", "status",
+	)
+	requestDuration := r.NewHistogramVec(
+		"engkids_http_request_duration_seconds",
+		"Время обработки HTTP-запроса в секундах.",
+		nil,
+		"method", "route", "status",
+	)
+	requestsInFlight := r.NewGauge(
+		"engkids_http_requests_in_flight",
+		"Количество HTTP-запросов, обрабатываемых в данный момент.",
+	)
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		own := c.Route()
+
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// ошибка ещё не превращена в ответ ErrorHandler'ом
+			status = StatusFromError(err)
+		}
+
+		route := unmatchedRoute
+		if r := c.Route(); r != own {
+			route = r.Path
+		}
+
+		labels := []string{c.Method(), route, strconv.Itoa(status)}
+		requestsTotal.WithLabelValues(labels...).Inc()
+		requestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// StatusFromError возвращает статус, с которым ErrorHandler ответит на ошибку,
+// по тем же правилам, что и apperrors.Handle (включая 503 и 504 для контекста)
+func StatusFromError(err error) int {
+	return apperrors.From(err).StatusCode()
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMiddlewareLabelsRouteTemplate(t *testing.T) {
+	r := NewRegistry()
+	app := fiber.New()
+	app.Use(r.Middleware())
+	app.Get("/api/users/:id", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/api/teapot", func(c *fiber.Ctx) error { return fiber.ErrTeapot })
+	app.Get("/api/slow", func(c *fiber.Ctx) error { return fmt.Errorf("query: %w", context.DeadlineExceeded) })
+	app.Get("/api/gone", func(c *fiber.Ctx) error { return context.Canceled })
+
+	for _, path := range []string{"/api/users/1", "/api/users/2", "/api/teapot", "/api/slow", "/api/gone", "/random/1", "/random/2"} {
+		if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		`engkids_http_requests_total{method="GET",route="/api/users/:id",status="200"} 2`,
+		// статус берётся из ошибки, которую ещё не обработал ErrorHandler
+		`engkids_http_requests_total{method="GET",route="/api/teapot",status="418"} 1`,
+		// истёкший и отменённый контекст — те же 504 и 503, что получит клиент
+		`engkids_http_requests_total{method="GET",route="/api/slow",status="504"} 1`,
+		`engkids_http_requests_total{method="GET",route="/api/gone",status="503"} 1`,
+		// пути без маршрута не раздувают число рядов
+		`engkids_http_requests_total{method="GET",route="unmatched",status="404"} 2`,
+		`engkids_http_request_duration_seconds_count{method="GET",route="/api/users/:id",status="200"} 2`,
+		"engkids_http_requests_in_flight 0",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "/random") {
+		t.Error("raw path used as route label")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "").Inc()
+	app := fiber.New()
+	app.Get("/metrics", r.Handler())
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type = %q", ct)
+	}
+}
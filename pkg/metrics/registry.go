@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// collector — метрика, умеющая записать себя в текстовом формате Prometheus
+type collector interface {
+	name() string
+	// shape — тип и лейблы метрики; метрики одной формы взаимозаменяемы.
+	// Пустая строка — метрику нельзя зарегистрировать повторно
+	shape() string
+	write(buf *bytes.Buffer)
+}
+
+// Registry хранит набор метрик и отдаёт их в формате Prometheus text exposition
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry создаёт пустой реестр метрик
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// register добавляет метрику в реестр. Если метрика с таким именем уже есть
+// и у неё та же форма, возвращается она: компонент можно собрать несколько раз
+// с одним реестром. Метрика с тем же именем, но другой формой — паника
+func (r *Registry) register(c collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.collectors[c.name()]
+	if !ok {
+		r.collectors[c.name()] = c
+		return c
+	}
+	if existing.shape() == "" || existing.shape() != c.shape() {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	return existing
+}
+
+// Unregister удаляет метрику из реестра по имени
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.collectors, name)
+}
+
+// WriteTo записывает все метрики реестра, отсортированные по имени
+func (r *Registry) WriteTo(buf *bytes.Buffer) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cs := make([]collector, 0, len(names))
+	for _, name := range names {
+		cs = append(cs, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	for _, c := range cs {
+		c.write(buf)
+	}
+}
+
+// Handler отдаёт метрики реестра для скрейпа Prometheus
+func (r *Registry) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var buf bytes.Buffer
+		r.WriteTo(&buf)
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		return c.Send(buf.Bytes())
+	}
+}
+
+func writeHeader(buf *bytes.Buffer, name, help, typ string) {
+	buf.WriteString("# HELP ")
+	buf.WriteString(name)
+	buf.WriteByte(' ')
+	buf.WriteString(strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	buf.WriteString("\n# TYPE ")
+	buf.WriteString(name)
+	buf.WriteByte(' ')
+	buf.WriteString(typ)
+	buf.WriteByte('\n')
+}
+
+func writeSample(buf *bytes.Buffer, name string, labelNames, labelValues []string, value float64) {
+	buf.WriteString(name)
+	writeLabels(buf, labelNames, labelValues)
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	buf.WriteByte('\n')
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabels(buf *bytes.Buffer, names, values []string) {
+	if len(names) == 0 {
+		return
+	}
+	buf.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(n)
+		buf.WriteString(`="`)
+		buf.WriteString(labelValueEscaper.Replace(values[i]))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('}')
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_requests_total", "Запросы.\nВторая строка", "path").WithLabelValues(`/a"b\c`).Add(3)
+	g := r.NewGauge("test_queue", "Очередь.")
+	g.Set(5)
+	g.Dec()
+	h := r.NewHistogramVec("test_latency_seconds", "Задержка.", []float64{1, 0.1}, "op")
+	h.WithLabelValues("get").Observe(0.05)
+	h.WithLabelValues("get").Observe(0.5)
+	h.WithLabelValues("get").Observe(2)
+	r.NewGaugeFunc("test_answer", "Ответ.", func() float64 { return 42 })
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	want := `# HELP test_answer Ответ.
+# TYPE test_answer gauge
+test_answer 42
+# HELP test_latency_seconds Задержка.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{op="get",le="0.1"} 1
+test_latency_seconds_bucket{op="get",le="1"} 2
+test_latency_seconds_bucket{op="get",le="+Inf"} 3
+test_latency_seconds_sum{op="get"} 2.55
+test_latency_seconds_count{op="get"} 3
+# HELP test_queue Очередь.
+# TYPE test_queue gauge
+test_queue 4
+# HELP test_requests_total Запросы.\nВторая строка
+# TYPE test_requests_total counter
+test_requests_total{path="/a\"b\\c"} 3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCounterIgnoresNegative(t *testing.T) {
+	c := NewRegistry().NewCounter("test_total", "")
+	c.Add(2)
+	c.Add(-1)
+	if c.Value() != 2 {
+		t.Errorf("value = %v, want 2", c.Value())
+	}
+}
+
+func TestRegisterReturnsExisting(t *testing.T) {
+	r := NewRegistry()
+	a := r.NewCounterVec("test_total", "", "kind")
+	b := r.NewCounterVec("test_total", "", "kind")
+	if a != b {
+		t.Error("same counter registered twice")
+	}
+	if r.NewHistogramVec("test_seconds", "", nil) != r.NewHistogramVec("test_seconds", "", DefBuckets) {
+		t.Error("same histogram registered twice")
+	}
+
+	conflicts := map[string]func(){
+		"other labels":  func() { r.NewCounterVec("test_total", "", "reason") },
+		"other type":    func() { r.NewGaugeVec("test_total", "", "kind") },
+		"other buckets": func() { r.NewHistogramVec("test_seconds", "", []float64{1}) },
+		"gauge func":    func() { r.NewGaugeFunc("test_func", "", nil); r.NewGaugeFunc("test_func", "", nil) },
+	}
+	for name, register := range conflicts {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestWrongLabelCountPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	NewRegistry().NewCounterVec("test_total", "", "a", "b").WithLabelValues("a")
+}
+
+// nopDriver нужен только для sql.Open: статистика пула не требует соединений
+type nopDriver struct{}
+
+func (nopDriver) Open(string) (driver.Conn, error) { return nil, errors.New("no connections") }
+
+func init() { sql.Register("metrics-nop", nopDriver{}) }
+
+func TestDBStats(t *testing.T) {
+	open := func(maxOpen int) *sql.DB {
+		db, err := sql.Open("metrics-nop", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(maxOpen)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	maxOpen := func(r *Registry) string {
+		var buf bytes.Buffer
+		r.WriteTo(&buf)
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if v, ok := strings.CutPrefix(line, "engkids_db_pool_max_open_connections "); ok {
+				return v
+			}
+		}
+		return ""
+	}
+
+	r := NewRegistry()
+	first, second := open(5), open(3)
+	r.RegisterDBStats(first)
+	r.RegisterDBStats(first)
+	r.RegisterDBStats(second)
+	if got := maxOpen(r); got != "8" {
+		t.Errorf("two pools: max open = %q, want 8", got)
+	}
+	r.UnregisterDBStats(first)
+	if got := maxOpen(r); got != "3" {
+		t.Errorf("after unregister: max open = %q, want 3", got)
+	}
+	r.UnregisterDBStats(second)
+	if got := maxOpen(r); got != "" {
+		t.Errorf("no pools: max open = %q, want no sample", got)
+	}
+}
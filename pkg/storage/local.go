@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local хранит файлы в каталоге на диске и выдаёт подписанные ссылки на
+// baseURL — маршрут приложения, который проверяет подпись и отдаёт файл.
+// Тип содержимого не хранится, а определяется по расширению ключа
+type Local struct {
+	dir     string
+	baseURL string
+	signer  *URLSigner
+}
+
+// NewLocal создаёт хранилище в каталоге dir; baseURL — адрес раздачи файлов,
+// например https://api.example.com/media или /media
+func NewLocal(dir, baseURL string, signer *URLSigner) *Local {
+	return &Local{dir: dir, baseURL: strings.TrimRight(baseURL, "/"), signer: signer}
+}
+
+// Put пишет файл во временный и переименовывает: читатели не видят файл,
+// записанный наполовину
+func (l *Local) Put(_ context.Context, key string, r io.Reader, size int64, _ string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("put %s: wrote %d bytes, expected %d", key, n, size)
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (l *Local) Open(_ context.Context, key string) (io.ReadCloser, *Object, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, l.object(key, info), nil
+}
+
+func (l *Local) Stat(_ context.Context, key string) (*Object, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.object(key, info), nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) SignedURL(_ context.Context, key string, expires time.Time) (string, error) {
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+	return l.baseURL + "/" + key + "?" + l.signer.Query(key, expires).Encode(), nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// mediaTypes — типы медиа, которых может не быть в системной таблице MIME
+var mediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".ogg":  "audio/ogg",
+}
+
+func (l *Local) object(key string, info fs.FileInfo) *Object {
+	ext := strings.ToLower(path.Ext(key))
+	contentType, ok := mediaTypes[ext]
+	if !ok {
+		contentType = mime.TypeByExtension(ext)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &Object{Key: key, Size: info.Size(), ContentType: contentType}
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	signer, err := NewURLSigner("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLocal(dir, "https://api.example.com/media/", signer)
+	ctx := context.Background()
+
+	if err := l.Put(ctx, "media/ab/abc.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(ctx, "media/ab/short.png", strings.NewReader("pn"), 3, "image/png"); err == nil {
+		t.Error("short write accepted")
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "media", "ab"))
+	if len(entries) != 1 {
+		t.Errorf("files after put: %v", entries)
+	}
+
+	rc, obj, err := l.Open(ctx, "media/ab/abc.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "png" || obj.Size != 3 || obj.ContentType != "image/png" {
+		t.Errorf("open = %q %+v", body, obj)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "media/../../etc/passwd", "media//a.png", "media/a b.png"} {
+		if _, err := l.Stat(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("stat %q = %v", key, err)
+		}
+	}
+
+	link, err := l.SignedURL(ctx, "media/ab/abc.png", time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(link)
+	if u.Host != "api.example.com" || u.Path != "/media/media/ab/abc.png" {
+		t.Errorf("signed url = %s", link)
+	}
+	q := u.Query()
+	if err := signer.Verify("media/ab/abc.png", q.Get(ExpiresParam), q.Get(SignatureParam), time.Unix(1699999999, 0)); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+
+	if err := l.Delete(ctx, "media/ab/abc.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete(ctx, "media/ab/abc.png"); err != nil {
+		t.Errorf("delete missing: %v", err)
+	}
+	if _, _, err := l.Open(ctx, "media/ab/abc.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open deleted = %v", err)
+	}
+}
+
+func TestURLSigner(t *testing.T) {
+	if _, err := NewURLSigner(""); err == nil {
+		t.Fatal("empty key accepted")
+	}
+	signer, _ := NewURLSigner("secret")
+	other, _ := NewURLSigner("other")
+	expires := time.Unix(1700000000, 0)
+	q := signer.Query("media/a.png", expires)
+	exp, sig := q.Get(ExpiresParam), q.Get(SignatureParam)
+
+	cases := []struct {
+		name   string
+		signer *URLSigner
+		key    string
+		exp    string
+		now    time.Time
+		want   error
+	}{
+		{"valid", signer, "media/a.png", exp, expires, nil},
+		{"expired", signer, "media/a.png", exp, expires.Add(time.Second), ErrLinkExpired},
+		{"other key", signer, "media/b.png", exp, expires, ErrLinkSignature},
+		{"extended expiry", signer, "media/a.png", "1800000000", expires, ErrLinkSignature},
+		{"other secret", other, "media/a.png", exp, expires, ErrLinkSignature},
+		{"garbage expiry", signer, "media/a.png", "soon", expires, ErrLinkSignature},
+	}
+	for _, c := range cases {
+		if err := c.signer.Verify(c.key, c.exp, sig, c.now); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
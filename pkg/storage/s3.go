@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"engkids/pkg/tracing"
+)
+
+const (
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayloadHash — SHA-256 пустого тела
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// maxPresignExpiry — дольше S3 подписанные ссылки не принимает
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// S3Config — подключение к S3-совместимому хранилищу
+type S3Config struct {
+	Endpoint  string // https://s3.amazonaws.com, http://minio:9000
+	Region    string // us-east-1, если пусто
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle — адреса вида endpoint/bucket/key вместо bucket.endpoint/key; нужен MinIO
+	PathStyle bool
+}
+
+// S3 хранит файлы в бакете S3-совместимого хранилища. Запросы подписываются
+// AWS Signature Version 4, ссылки для скачивания — предподписанные URL S3
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	http     *http.Client
+	now      func() time.Time
+}
+
+// NewS3 создаёт клиент бакета; httpClient может быть nil
+func NewS3(cfg S3Config, httpClient *http.Client) (*S3, error) {
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint %q: expected scheme://host", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is empty")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   time.Minute,
+			Transport: tracing.NewTransport(nil),
+		}
+	}
+	return &S3{cfg: cfg, endpoint: endpoint, http: httpClient, now: time.Now}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// тело не хешируется, чтобы не читать его дважды; целостность обеспечивает HTTPS
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, objectFromHeader(key, resp), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	req, err := s.request(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return objectFromHeader(key, resp), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL возвращает предподписанный URL S3: файл скачивается напрямую из
+// хранилища, минуя приложение. Срок ограничен семью днями
+func (s *S3) SignedURL(_ context.Context, key string, expires time.Time) (string, error) {
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	ttl := min(expires.Sub(now), maxPresignExpiry)
+	if ttl < time.Second {
+		return "", fmt.Errorf("presign %s: expiry %s is in the past", key, expires)
+	}
+	u := s.objectURL(key)
+	q := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.cfg.AccessKey + "/" + s.scope(now)},
+		"X-Amz-Date":          {now.Format(amzDateFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(ttl / time.Second))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u.RawQuery = canonicalQuery(q)
+	canonical := canonicalRequest(http.MethodGet, u, http.Header{}, []string{"host"}, unsignedPayload)
+	q.Set("X-Amz-Signature", s.signature(now, canonical))
+	u.RawQuery = canonicalQuery(q)
+	return u.String(), nil
+}
+
+func (s *S3) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+}
+
+// do подписывает и выполняет запрос. Ответ 404 — ErrNotFound, другие ответы
+// не 2xx — ошибка с текстом ответа S3
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.now().UTC())
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.cfg.PathStyle {
+		u.Path += "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path += "/" + key
+	}
+	return &u
+}
+
+// sign добавляет заголовки x-amz-date, x-amz-content-sha256 и Authorization.
+// Подписываются host и все заголовки запроса, кроме служебных заголовков транспорта
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signed := []string{"host"}
+	for name := range req.Header {
+		switch name = strings.ToLower(name); name {
+		case "authorization", "user-agent", "accept-encoding", "content-length":
+		default:
+			signed = append(signed, name)
+		}
+	}
+	sort.Strings(signed)
+
+	canonical := canonicalRequest(req.Method, req.URL, req.Header, signed, payloadHash)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, s.scope(now), strings.Join(signed, ";"), s.signature(now, canonical)))
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+// signature — подпись канонического запроса ключом, выведенным из секрета,
+// даты и региона
+func (s *S3) signature(now time.Time, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format(amzDateFormat) + "\n" + s.scope(now) + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalRequest(method string, u *url.URL, header http.Header, signed []string, payloadHash string) string {
+	var b strings.Builder
+	b.WriteString(method + "\n")
+	// ключи состоят из незарезервированных символов (CheckKey), путь не перекодируется
+	b.WriteString(u.EscapedPath() + "\n")
+	b.WriteString(canonicalQuery(u.Query()) + "\n")
+	for _, name := range signed {
+		value := u.Host
+		if name != "host" {
+			value = strings.Join(header.Values(name), ",")
+		}
+		b.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	b.WriteString("\n" + strings.Join(signed, ";") + "\n")
+	b.WriteString(payloadHash)
+	return b.String()
+}
+
+// canonicalQuery — параметры, отсортированные по имени и закодированные по правилам SigV4
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode кодирует всё, кроме A-Z, a-z, 0-9, '-', '.', '_' и '~'
+func uriEncode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func objectFromHeader(key string, resp *http.Response) *Object {
+	return &Object{Key: key, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Параметры подписанной ссылки
+const (
+	ExpiresParam   = "expires"   // срок действия, Unix-время в секундах
+	SignatureParam = "signature" // HMAC-SHA256 ключа файла и срока, hex
+)
+
+var (
+	ErrLinkExpired    = errors.New("signed link expired")
+	ErrLinkSignature  = errors.New("invalid link signature")
+	errEmptySignerKey = errors.New("empty signing key")
+)
+
+// URLSigner подписывает ссылки на файлы, которые раздаёт само приложение.
+// Ссылка действует до expires и только для своего ключа
+type URLSigner struct {
+	secret []byte
+}
+
+func NewURLSigner(secret string) (*URLSigner, error) {
+	if secret == "" {
+		return nil, errEmptySignerKey
+	}
+	return &URLSigner{secret: []byte(secret)}, nil
+}
+
+// Query возвращает параметры ссылки на key, действующей до expires
+func (s *URLSigner) Query(key string, expires time.Time) url.Values {
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	return url.Values{ExpiresParam: {unix}, SignatureParam: {s.sign(key, unix)}}
+}
+
+// Verify проверяет параметры ссылки на key в момент now
+func (s *URLSigner) Verify(key, expires, signature string, now time.Time) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrLinkSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return ErrLinkSignature
+	}
+	if now.Unix() > unix {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+func (s *URLSigner) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
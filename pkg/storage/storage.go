@@ -0,0 +1,58 @@
+// Package storage хранит файлы медиа — картинки и аудио уроков — на диске или
+// в S3-совместимом хранилище (AWS S3, MinIO, Yandex Object Storage)
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound — файла с таким ключом нет
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey — ключ не подходит под формат segment/segment/name.ext
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object — сведения о сохранённом файле
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+}
+
+// Storage — файлы по ключам вида media/ab/abcdef.png
+type Storage interface {
+	// Put сохраняет файл размером size, заменяя файл с тем же ключом
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open возвращает содержимое файла; вызывающий закрывает его. ErrNotFound — файла нет
+	Open(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete удаляет файл; удаление отсутствующего файла не ошибка
+	Delete(ctx context.Context, key string) error
+	// SignedURL — ссылка для скачивания без авторизации, действующая до expires
+	SignedURL(ctx context.Context, key string, expires time.Time) (string, error)
+}
+
+// CheckKey проверяет ключ: непустые сегменты из латиницы, цифр, '.', '-' и '_'
+// через '/', без "." и ".." — ключ безопасно превращать в путь на диске
+func CheckKey(key string) error {
+	if key == "" || len(key) > 512 {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+		for i := 0; i < len(segment); i++ {
+			c := segment[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_') {
+				return ErrInvalidKey
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"engkids/config"
+)
+
+// NewProviderFromEnv создаёт провайдер по стандартным переменным OpenTelemetry:
+//
+//	OTEL_TRACES_EXPORTER        otlp | console | none (по умолчанию otlp, если задан endpoint, иначе none)
+//	OTEL_EXPORTER_OTLP_ENDPOINT адрес коллектора, например http://otel-collector:4318
+//	OTEL_EXPORTER_OTLP_HEADERS  дополнительные заголовки в виде key1=value1,key2=value2
+//	OTEL_SERVICE_NAME           имя сервиса (по умолчанию serviceName)
+//	OTEL_TRACES_SAMPLER_ARG     доля записываемых трейсов от 0 до 1 (по умолчанию 1)
+//
+// Возвращает nil, если экспорт выключен
+func NewProviderFromEnv(serviceName string, onError func(error)) (*Provider, error) {
+	endpoint := config.GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	defaultExporter := "none"
+	if endpoint != "" {
+		defaultExporter = "otlp"
+	}
+	serviceName = config.GetEnv("OTEL_SERVICE_NAME", serviceName)
+
+	ratio := 1.0
+	if arg := config.GetEnv("OTEL_TRACES_SAMPLER_ARG", ""); arg != "" {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG: %w", err)
+		}
+		ratio = v
+	}
+
+	var exporter Exporter
+	switch kind := config.GetEnv("OTEL_TRACES_EXPORTER", defaultExporter); kind {
+	case "none", "":
+		return nil, nil
+	case "console", "stdout":
+		exporter = NewStdoutExporter(os.Stdout)
+	case "otlp":
+		if endpoint == "" {
+			return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT is required for otlp exporter")
+		}
+		exporter = NewOTLPExporter(endpoint, serviceName, parseHeaders(config.GetEnv("OTEL_EXPORTER_OTLP_HEADERS", "")))
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER: %s", kind)
+	}
+
+	return NewProvider(Config{
+		ServiceName: serviceName,
+		Exporter:    exporter,
+		SampleRatio: ratio,
+		OnError:     onError,
+	}), nil
+}
+
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var got otlpRequest
+	var path, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("body: %v: %s", err, body)
+		}
+	}))
+	defer srv.Close()
+
+	remote, _ := ParseTraceparent(exampleTraceparent)
+	start := time.Unix(1700000000, 5)
+	span := SpanData{
+		SpanContext:  SpanContext{TraceID: remote.TraceID, SpanID: SpanID{1, 2, 3, 4, 5, 6, 7, 8}, TraceState: "vendor=1"},
+		ParentSpanID: remote.SpanID,
+		Name:         "GET /api/users/:id",
+		Kind:         SpanKindServer,
+		Start:        start,
+		End:          start.Add(time.Millisecond),
+		Attributes:   map[string]any{"http.response.status_code": 500, "http.route": "/api/users/:id", "cached": false, "ratio": 0.5},
+		Status:       StatusError,
+		StatusMsg:    "HTTP 500",
+	}
+	e := NewOTLPExporter(srv.URL+"/", "engkids-test", map[string]string{"Authorization": "Bearer key"})
+	if err := e.ExportSpans(context.Background(), []SpanData{span}); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/v1/traces" || auth != "Bearer key" {
+		t.Errorf("request to %s with auth %q", path, auth)
+	}
+	if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("request = %+v", got)
+	}
+	service := got.ResourceSpans[0].Resource.Attributes
+	if len(service) != 1 || service[0].Key != "service.name" || *service[0].Value.StringValue != "engkids-test" {
+		t.Errorf("resource = %+v", service)
+	}
+	s := got.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if s.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || s.ParentSpanID != "00f067aa0ba902b7" || s.SpanID != "0102030405060708" || s.TraceState != "vendor=1" {
+		t.Errorf("ids = %+v", s)
+	}
+	// OTLP/JSON передаёт 64-битные числа строками
+	if s.StartTimeUnixNano != "1700000000000000005" || s.EndTimeUnixNano != "1700000000001000005" {
+		t.Errorf("times = %s..%s", s.StartTimeUnixNano, s.EndTimeUnixNano)
+	}
+	if s.Kind != SpanKindServer || s.Status.Code != StatusError || s.Status.Message != "HTTP 500" {
+		t.Errorf("kind/status = %d %+v", s.Kind, s.Status)
+	}
+	attrs := map[string]otlpAnyValue{}
+	for _, kv := range s.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if v := attrs["http.response.status_code"].IntValue; v == nil || *v != "500" {
+		t.Errorf("int attribute = %+v", attrs["http.response.status_code"])
+	}
+	if v := attrs["http.route"].StringValue; v == nil || *v != "/api/users/:id" {
+		t.Errorf("string attribute = %+v", attrs["http.route"])
+	}
+	if v := attrs["cached"].BoolValue; v == nil || *v {
+		t.Errorf("bool attribute = %+v", attrs["cached"])
+	}
+	if v := attrs["ratio"].DoubleValue; v == nil || *v != 0.5 {
+		t.Errorf("double attribute = %+v", attrs["ratio"])
+	}
+}
+
+func TestOTLPExporterFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := NewOTLPExporter(srv.URL, "engkids", nil).ExportSpans(context.Background(), []SpanData{{Name: "x"}})
+	if err == nil {
+		t.Error("503 from collector is not an error")
+	}
+}
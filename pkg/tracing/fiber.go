@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"fmt"
+
+	"engkids/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TraceIDHeader — заголовок ответа с идентификатором трейса, который клиент
+// может приложить к обращению в поддержку
+const TraceIDHeader = "X-Trace-Id"
+
+// Middleware создаёт через p серверный спан на каждый запрос, продолжая трейс
+// из заголовка traceparent, если его прислал клиент, и кладёт спан в
+// c.UserContext(). p может быть nil — тогда спаны не экспортируются
+func Middleware(p *Provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := Extract(ContextWithProvider(c.UserContext(), p), func(key string) string { return c.Get(key) })
+
+		own := c.Route()
+		ctx, span := p.Start(ctx, c.Method()+" "+c.Path(),
+			WithSpanKind(SpanKindServer),
+			WithAttributes(
+				"http.request.method", c.Method(),
+				"url.path", c.Path(),
+				"client.address", c.IP(),
+				"user_agent.original", c.Get(fiber.HeaderUserAgent),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		c.Set(TraceIDHeader, span.SpanContext().TraceID.String())
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = metrics.StatusFromError(err)
+		}
+
+		if r := c.Route(); r != own {
+			span.SetName(c.Method() + " " + r.Path)
+			span.SetAttributes("http.route", r.Path)
+		}
+		span.SetAttributes("http.response.status_code", status)
+		if rid, ok := c.Locals("requestid").(string); ok {
+			span.SetAttributes("http.request_id", rid)
+		}
+		if status >= fiber.StatusInternalServerError {
+			msg := fmt.Sprintf("HTTP %d", status)
+			if err != nil {
+				msg = err.Error()
+			}
+			span.SetStatus(StatusError, msg)
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProvider(Config{Exporter: NewStdoutExporter(&buf), SampleRatio: 1})
+
+	var handlerTrace TraceID
+	app := fiber.New()
+	app.Use(Middleware(p))
+	app.Get("/api/users/:id", func(c *fiber.Ctx) error {
+		handlerTrace = SpanContextFromContext(c.UserContext()).TraceID
+		return fiber.ErrBadGateway
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/api/users/42", nil)
+	req.Header.Set(TraceparentHeader, exampleTraceparent)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recordedSpans(t, &buf)
+	if len(spans) != 1 {
+		t.Fatalf("exported %+v", spans)
+	}
+	s := spans[0]
+	if s.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || s.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("trace not continued: %+v", s)
+	}
+	if handlerTrace.String() != s.TraceID || resp.Header.Get(TraceIDHeader) != s.TraceID {
+		t.Errorf("handler trace %s, header %s, span %s", handlerTrace, resp.Header.Get(TraceIDHeader), s.TraceID)
+	}
+	if s.Name != "GET /api/users/:id" || s.Kind != SpanKindServer || s.Attributes["http.route"] != "/api/users/:id" {
+		t.Errorf("span = %+v", s)
+	}
+	if s.Attributes["http.response.status_code"] != float64(fiber.StatusBadGateway) || s.Status != StatusError {
+		t.Errorf("status = %v, %d", s.Attributes["http.response.status_code"], s.Status)
+	}
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin — плагин GORM, создающий спан на каждый запрос к БД. Чтобы спан
+// попал в трейс запроса, сервис должен вызывать db.WithContext(ctx)
+type GormPlugin struct{}
+
+// NewGormPlugin создаёт плагин для db.Use
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+// Name реализует gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "engkids:tracing"
+}
+
+// Initialize реализует gorm.Plugin
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	type registerer interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}
+	hooks := []struct {
+		operation     string
+		before, after registerer
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+
+	for _, h := range hooks {
+		operation := h.operation
+		if err := h.before.Register("tracing:before_"+operation, func(db *gorm.DB) {
+			_, span := Start(db.Statement.Context, "gorm."+operation,
+				WithSpanKind(SpanKindClient),
+				WithAttributes("db.system", "postgresql", "db.operation.name", operation),
+			)
+			db.InstanceSet(gormSpanKey, span)
+		}); err != nil {
+			return err
+		}
+		if err := h.after.Register("tracing:after_"+operation, afterGorm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func afterGorm(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(*Span)
+	if !ok {
+		return
+	}
+
+	// текст запроса с плейсхолдерами, без значений параметров
+	span.SetAttributes(
+		"db.collection.name", db.Statement.Table,
+		"db.query.text", db.Statement.SQL.String(),
+		"db.response.rows_affected", db.RowsAffected,
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+	span.End()
+}
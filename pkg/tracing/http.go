@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"net/http"
+)
+
+// Transport оборачивает http.RoundTripper: создаёт клиентский спан на каждый
+// исходящий запрос (почта, вебхуки, внешние API) и передаёт traceparent
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport создаёт Transport поверх base (http.DefaultTransport, если nil)
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip реализует http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), "HTTP "+req.Method,
+		WithSpanKind(SpanKindClient),
+		WithAttributes(
+			"http.request.method", req.Method,
+			"server.address", req.URL.Host,
+			"url.path", req.URL.Path,
+		),
+	)
+	defer span.End()
+
+	out := req.Clone(ctx)
+	Inject(ctx, out.Header.Set)
+
+	resp, err := t.Base.RoundTrip(out)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(StatusError, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,31 @@
+package tracing
+
+import "github.com/sirupsen/logrus"
+
+// LogrusHook добавляет trace_id и span_id в записи, созданные через
+// logger.WithContext(ctx)
+type LogrusHook struct{}
+
+// NewLogrusHook создаёт хук для logger.AddHook
+func NewLogrusHook() *LogrusHook {
+	return &LogrusHook{}
+}
+
+// Levels реализует logrus.Hook
+func (h *LogrusHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire реализует logrus.Hook
+func (h *LogrusHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	sc := SpanContextFromContext(entry.Context)
+	if !sc.IsValid() {
+		return nil
+	}
+	entry.Data["trace_id"] = sc.TraceID.String()
+	entry.Data["span_id"] = sc.SpanID.String()
+	return nil
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+)
+
+// Заголовки W3C Trace Context
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// FormatTraceparent собирает значение заголовка traceparent
+func FormatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent разбирает заголовок traceparent; ok=false для невалидных значений
+func ParseTraceparent(value string) (sc SpanContext, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// версия ff запрещена, для версии 00 лишних полей быть не должно
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return SpanContext{}, false
+	}
+	if _, err := hex.DecodeString(version); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(traceID)); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(spanID)); err != nil {
+		return SpanContext{}, false
+	}
+	f, err := hex.DecodeString(flags)
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = f[0]&0x01 == 0x01
+	sc.Remote = true
+	return sc, sc.IsValid()
+}
+
+// Extract достаёт родительский контекст из входящих заголовков
+func Extract(ctx context.Context, get func(key string) string) context.Context {
+	sc, ok := ParseTraceparent(get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	sc.TraceState = get(TracestateHeader)
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject записывает контекст текущего спана в исходящие заголовки
+func Inject(ctx context.Context, set func(key, value string)) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	set(TraceparentHeader, FormatTraceparent(sc))
+	if sc.TraceState != "" {
+		set(TracestateHeader, sc.TraceState)
+	}
+}
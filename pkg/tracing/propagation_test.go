@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+const exampleTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent(exampleTraceparent)
+	if !ok {
+		t.Fatal("valid traceparent rejected")
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled || !sc.Remote {
+		t.Errorf("parsed = %+v", sc)
+	}
+	if got := FormatTraceparent(sc); got != exampleTraceparent {
+		t.Errorf("format = %s", got)
+	}
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	} {
+		if _, ok := ParseTraceparent(value); ok {
+			t.Errorf("%q accepted", value)
+		}
+	}
+	// будущие версии могут добавлять поля
+	if _, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); !ok {
+		t.Error("future version with extra field rejected")
+	}
+}
+
+func TestExtractInject(t *testing.T) {
+	in := map[string]string{TraceparentHeader: exampleTraceparent, TracestateHeader: "vendor=1"}
+	ctx := Extract(context.Background(), func(key string) string { return in[key] })
+
+	// дочерний спан продолжает удалённый трейс
+	ctx, span := (*Provider)(nil).Start(ctx, "child")
+	if span.SpanContext().TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("child = %+v, parent %s", span.SpanContext(), span.parent)
+	}
+
+	out := map[string]string{}
+	Inject(ctx, func(key, value string) { out[key] = value })
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanContext().SpanID.String() + "-01"
+	if out[TraceparentHeader] != want || out[TracestateHeader] != "vendor=1" {
+		t.Errorf("injected = %v", out)
+	}
+
+	out = map[string]string{}
+	Inject(context.Background(), func(key, value string) { out[key] = value })
+	if len(out) != 0 {
+		t.Errorf("injected without span: %v", out)
+	}
+	if ctx := Extract(context.Background(), func(string) string { return "garbage" }); SpanContextFromContext(ctx).IsValid() {
+		t.Error("garbage traceparent extracted")
+	}
+}
@@ -0,0 +1,256 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Exporter отправляет завершённые спаны во внешнюю систему
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Config — настройки провайдера трейсинга
+type Config struct {
+	ServiceName   string
+	Exporter      Exporter
+	SampleRatio   float64       // доля корневых трейсов, которые записываются (0..1)
+	BatchSize     int           // максимальный размер пачки для экспорта
+	QueueSize     int           // ёмкость очереди; при переполнении спаны отбрасываются
+	FlushInterval time.Duration // как часто отправлять неполную пачку
+	OnError       func(error)   // вызывается при ошибке экспорта
+}
+
+// Provider создаёт спаны и асинхронно экспортирует их пачками
+type Provider struct {
+	cfg      Config
+	queue    chan SpanData
+	flushReq chan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewProvider создаёт провайдер и запускает фоновую отправку спанов
+func NewProvider(cfg Config) *Provider {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "engkids"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 2048
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.SampleRatio < 0 {
+		cfg.SampleRatio = 0
+	}
+	if cfg.SampleRatio > 1 {
+		cfg.SampleRatio = 1
+	}
+
+	p := &Provider{
+		cfg:      cfg,
+		queue:    make(chan SpanData, cfg.QueueSize),
+		flushReq: make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.loop()
+	return p
+}
+
+// ServiceName возвращает имя сервиса, указываемое в ресурсе спанов
+func (p *Provider) ServiceName() string {
+	return p.cfg.ServiceName
+}
+
+// StartOption настраивает создаваемый спан
+type StartOption func(*Span)
+
+// WithSpanKind задаёт роль спана
+func WithSpanKind(kind SpanKind) StartOption {
+	return func(s *Span) { s.kind = kind }
+}
+
+// WithAttributes задаёт начальные атрибуты спана парами ключ-значение
+func WithAttributes(kv ...any) StartOption {
+	return func(s *Span) { s.SetAttributes(kv...) }
+}
+
+// Start создаёт дочерний спан для контекста ctx и возвращает контекст с ним
+func (p *Provider) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := SpanContextFromContext(ctx)
+
+	s := &Span{
+		name:       name,
+		kind:       SpanKindInternal,
+		start:      time.Now(),
+		attributes: make(map[string]any),
+	}
+	s.sc.SpanID = newSpanID()
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.sc.Sampled = parent.Sampled
+		s.sc.TraceState = parent.TraceState
+		s.parent = parent.SpanID
+	} else {
+		s.sc.TraceID = newTraceID()
+		s.sc.Sampled = p != nil && p.sample(s.sc.TraceID)
+	}
+	if p != nil {
+		s.provider = p
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return ContextWithSpan(ctx, s), s
+}
+
+// sample решает по идентификатору трейса, записывать ли его, чтобы решение
+// было одинаковым во всех инстансах
+func (p *Provider) sample(id TraceID) bool {
+	switch {
+	case p.cfg.SampleRatio >= 1:
+		return true
+	case p.cfg.SampleRatio <= 0:
+		return false
+	}
+	bound := uint64(p.cfg.SampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+func (p *Provider) enqueue(s SpanData) {
+	select {
+	case <-p.done:
+	case p.queue <- s:
+	default:
+		// очередь переполнена — трейсинг не должен тормозить запросы
+	}
+}
+
+func (p *Provider) loop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, p.cfg.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := p.cfg.Exporter.ExportSpans(ctx, batch); err != nil && p.cfg.OnError != nil {
+			p.cfg.OnError(err)
+		}
+		cancel()
+		batch = make([]SpanData, 0, p.cfg.BatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-p.queue:
+				batch = append(batch, s)
+				if len(batch) >= p.cfg.BatchSize {
+					export()
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case s := <-p.queue:
+			batch = append(batch, s)
+			if len(batch) >= p.cfg.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-p.flushReq:
+			drain()
+			export()
+			close(ack)
+		case <-p.done:
+			drain()
+			export()
+			return
+		}
+	}
+}
+
+// ForceFlush отправляет все накопленные спаны
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case p.flushReq <- ack:
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown отправляет оставшиеся спаны и останавливает экспортёр
+func (p *Provider) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.done) })
+
+	finished := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.cfg.Exporter.Shutdown(ctx)
+}
+
+type providerKey struct{}
+
+// ContextWithProvider кладёт в контекст провайдер, через который функция Start
+// создаёт спаны. Middleware делает это для каждого запроса, приложение — для
+// контекста фоновой работы
+func ContextWithProvider(ctx context.Context, p *Provider) context.Context {
+	return context.WithValue(ctx, providerKey{}, p)
+}
+
+// ProviderFromContext возвращает провайдер текущего спана или положенный
+// через ContextWithProvider (nil, если его нет)
+func ProviderFromContext(ctx context.Context) *Provider {
+	if ctx == nil {
+		return nil
+	}
+	if s := SpanFromContext(ctx); s != nil && s.provider != nil {
+		return s.provider
+	}
+	p, _ := ctx.Value(providerKey{}).(*Provider)
+	return p
+}
+
+// Start создаёт спан через провайдер из контекста. Без провайдера спаны не
+// экспортируются, но идентификаторы трейса всё равно создаются и пробрасываются,
+// чтобы логи можно было связать между собой
+func Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	return ProviderFromContext(ctx).Start(ctx, name, opts...)
+}
@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recordedSpans разбирает вывод StdoutExporter
+func recordedSpans(t *testing.T, buf *bytes.Buffer) []stdoutSpan {
+	t.Helper()
+	var spans []stdoutSpan
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var s stdoutSpan
+		if err := dec.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+func TestProviderExportsSpanTree(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProvider(Config{Exporter: NewStdoutExporter(&buf), SampleRatio: 1})
+
+	ctx, root := p.Start(context.Background(), "root", WithSpanKind(SpanKindServer), WithAttributes("user.id", 7))
+	_, child := p.Start(ctx, "child")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	child.End() // повторное завершение игнорируется
+	root.SetName("renamed")
+	root.End()
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := recordedSpans(t, &buf)
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans: %+v", len(spans), spans)
+	}
+	c, r := spans[0], spans[1]
+	if r.Name != "renamed" || r.Kind != SpanKindServer || r.ParentSpanID != "" || r.Attributes["user.id"] != float64(7) {
+		t.Errorf("root = %+v", r)
+	}
+	if c.TraceID != r.TraceID || c.ParentSpanID != r.SpanID || c.Kind != SpanKindInternal {
+		t.Errorf("child = %+v, root = %+v", c, r)
+	}
+	if c.Status != StatusError || c.StatusMsg != "boom" || c.Attributes["exception.message"] != "boom" {
+		t.Errorf("child status = %d %q %v", c.Status, c.StatusMsg, c.Attributes)
+	}
+	if c.End.Before(c.Start) {
+		t.Errorf("child ends before start")
+	}
+}
+
+func TestProviderSampling(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProvider(Config{Exporter: NewStdoutExporter(&buf), SampleRatio: 0})
+
+	ctx, root := p.Start(context.Background(), "root")
+	_, child := p.Start(ctx, "child")
+	if root.IsRecording() || child.IsRecording() {
+		t.Error("unsampled trace is recorded")
+	}
+	// идентификаторы нужны логам даже без экспорта
+	if !root.SpanContext().IsValid() || child.SpanContext().TraceID != root.SpanContext().TraceID {
+		t.Errorf("ids: root %+v, child %+v", root.SpanContext(), child.SpanContext())
+	}
+	child.End()
+	root.End()
+
+	// решение удалённого родителя важнее своей доли
+	remote, _ := ParseTraceparent(exampleTraceparent)
+	_, span := p.Start(ContextWithRemoteSpanContext(context.Background(), remote), "continued")
+	span.End()
+
+	if err := p.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := recordedSpans(t, &buf)
+	if len(spans) != 1 || spans[0].Name != "continued" {
+		t.Errorf("exported %+v", spans)
+	}
+	p.Shutdown(context.Background())
+}
+
+func TestProviderReportsExportErrors(t *testing.T) {
+	errs := make(chan error, 1)
+	p := NewProvider(Config{
+		Exporter:    NewStdoutExporter(failingWriter{}),
+		SampleRatio: 1,
+		OnError:     func(err error) { errs <- err },
+	})
+	_, span := p.Start(context.Background(), "lost")
+	span.End()
+	p.Shutdown(context.Background())
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("error = %v", err)
+		}
+	default:
+		t.Error("export error not reported")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestStartUsesProviderFromContext(t *testing.T) {
+	var first, second bytes.Buffer
+	p1 := NewProvider(Config{Exporter: NewStdoutExporter(&first), SampleRatio: 1})
+	p2 := NewProvider(Config{Exporter: NewStdoutExporter(&second), SampleRatio: 1})
+
+	ctx, root := Start(ContextWithProvider(context.Background(), p1), "root")
+	// спан продолжает провайдер родителя, даже если в контексте лежит другой
+	_, child := Start(ContextWithProvider(ctx, p2), "child")
+	child.End()
+	root.End()
+
+	_, orphan := Start(context.Background(), "orphan")
+	if orphan.IsRecording() || !orphan.SpanContext().TraceID.IsValid() {
+		t.Errorf("span without provider: recording=%v trace=%s", orphan.IsRecording(), orphan.SpanContext().TraceID)
+	}
+	orphan.End()
+
+	for _, p := range []*Provider{p1, p2} {
+		if err := p.ForceFlush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if spans := recordedSpans(t, &first); len(spans) != 2 {
+		t.Errorf("first provider exported %+v", spans)
+	}
+	if spans := recordedSpans(t, &second); len(spans) != 0 {
+		t.Errorf("second provider exported %+v", spans)
+	}
+}
@@ -0,0 +1,222 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID — идентификатор трейса по W3C Trace Context
+type TraceID [16]byte
+
+// SpanID — идентификатор спана по W3C Trace Context
+type SpanID [8]byte
+
+// String возвращает hex-представление идентификатора
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid сообщает, что идентификатор ненулевой
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// String возвращает hex-представление идентификатора
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid сообщает, что идентификатор ненулевой
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+// SpanContext — неизменяемая часть спана, передаваемая между сервисами
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+	Remote     bool
+}
+
+// IsValid сообщает, что контекст содержит идентификаторы трейса и спана
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind — роль спана в обмене (коды совпадают с OTLP)
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode — статус завершения спана (коды совпадают с OTLP)
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Span — единица работы внутри трейса
+type Span struct {
+	mu         sync.Mutex
+	provider   *Provider
+	sc         SpanContext
+	parent     SpanID
+	name       string
+	kind       SpanKind
+	start      time.Time
+	end        time.Time
+	attributes map[string]any
+	status     StatusCode
+	statusMsg  string
+	ended      bool
+}
+
+// SpanContext возвращает идентификаторы спана
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording сообщает, будет ли спан экспортирован
+func (s *Span) IsRecording() bool {
+	return s != nil && s.sc.Sampled && s.provider != nil
+}
+
+// SetName меняет имя спана (например, когда шаблон маршрута известен только после роутинга)
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+// SetAttributes добавляет атрибуты спана; поддерживаются string, bool, целые и float64
+func (s *Span) SetAttributes(kv ...any) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		s.attributes[key] = kv[i+1]
+	}
+}
+
+// SetStatus устанавливает статус спана
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	s.status = code
+	s.statusMsg = msg
+	s.mu.Unlock()
+}
+
+// RecordError помечает спан как ошибочный
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	s.SetAttributes("exception.message", err.Error())
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End завершает спан и передаёт его экспортёру; повторные вызовы игнорируются
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	data := SpanData{
+		SpanContext:  s.sc,
+		ParentSpanID: s.parent,
+		Name:         s.name,
+		Kind:         s.kind,
+		Start:        s.start,
+		End:          s.end,
+		Attributes:   s.attributes,
+		Status:       s.status,
+		StatusMsg:    s.statusMsg,
+	}
+	s.mu.Unlock()
+
+	s.provider.enqueue(data)
+}
+
+// SpanData — снимок завершённого спана для экспорта
+type SpanData struct {
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	Name         string
+	Kind         SpanKind
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]any
+	Status       StatusCode
+	StatusMsg    string
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// ContextWithSpan кладёт спан в контекст
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext достаёт текущий спан из контекста (nil, если его нет)
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteSpanContext кладёт в контекст родителя, пришедшего из другого сервиса
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext возвращает контекст текущего спана, локального или удалённого
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
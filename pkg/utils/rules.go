@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator"
+	"golang.org/x/text/language"
+)
+
+// Допустимый возраст ребёнка в годах
+const (
+	MinChildAge = 3
+	MaxChildAge = 12
+)
+
+// MinPasswordLength — минимальная длина пароля для правила password
+const MinPasswordLength = 8
+
+// CEFRLevels — уровни владения языком по шкале CEFR
+var CEFRLevels = []string{"pre-A1", "A1", "A2", "B1", "B2", "C1", "C2"}
+
+// customRules — правила, которых нет в go-playground/validator
+var customRules = map[string]validator.Func{
+	"password":  validatePassword,
+	"child_age": validateChildAge,
+	"cefr":      validateCEFR,
+	"lang_code": validateLangCode,
+}
+
+// validatePassword: не короче MinPasswordLength, есть буква и цифра
+func validatePassword(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if len([]rune(s)) < MinPasswordLength {
+		return false
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasLetter && hasDigit
+}
+
+func validateChildAge(fl validator.FieldLevel) bool {
+	age := fl.Field().Int()
+	return age >= MinChildAge && age <= MaxChildAge
+}
+
+func validateCEFR(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	for _, level := range CEFRLevels {
+		if strings.EqualFold(s, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLangCode: двухбуквенный код ISO 639-1 в нижнем регистре (en, ru, ...)
+func validateLangCode(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if len(s) != 2 || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := language.ParseBase(s)
+	return err == nil
+}
@@ -1,22 +1,100 @@
 package utils
 
 import (
+	"errors"
 	"github.com/go-playground/validator"
-	"github.com/gofiber/fiber/v2"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// в ошибках используем имена полей из JSON, а не из Go
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return f.Name
+		}
+		return name
+	})
+
+	for tag, fn := range customRules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			panic(err)
+		}
+	}
+	return v
+}
 
 func ValidateStruct(s interface{}) error {
 	return validate.Struct(s)
 }
 
-func ParseAndValidate(c *fiber.Ctx, dst interface{}) error {
-	if err := c.BodyParser(dst); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Невозможно обработать данные")
+// Violation — нарушенное правило одного поля. Field — путь в JSON
+// (для вложенных — children[0].age), Rule — правило с уточнением для длины
+type Violation struct {
+	Field string
+	Rule  string
+	Param string
+}
+
+// Violations разбирает ошибку ValidateStruct на нарушения полей. ok == false,
+// если err — не ошибка правил validate (например, неподдерживаемый тип)
+func Violations(err error) (out []Violation, ok bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	out = make([]Violation, 0, len(verrs))
+	for _, fe := range verrs {
+		out = append(out, Violation{
+			Field: fieldPath(fe.Namespace()),
+			Rule:  ruleName(fe),
+			Param: ruleParam(fe),
+		})
+	}
+	return out, true
+}
+
+// fieldPath убирает имя корневой структуры: RegisterRequest.email -> email
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// ruleName уточняет правила длины для строк и коллекций, чтобы сообщение
+// говорило о символах или элементах, а не о значении
+func ruleName(fe validator.FieldError) string {
+	tag := fe.Tag()
+	switch tag {
+	case "min", "max", "len":
+		switch fe.Kind() {
+		case reflect.String:
+			return tag + "_length"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return tag + "_items"
+		}
 	}
-	if err := ValidateStruct(dst); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	return tag
+}
+
+func ruleParam(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "password":
+		return strconv.Itoa(MinPasswordLength)
+	case "child_age":
+		return strconv.Itoa(MinChildAge) + "-" + strconv.Itoa(MaxChildAge)
+	case "cefr":
+		return strings.Join(CEFRLevels, " ")
 	}
-	return nil
+	return fe.Param()
 }
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type profileRequest struct {
+	Password string `json:"password" validate:"required,password"`
+	Level    string `json:"level" validate:"required,cefr"`
+	Lang     string `json:"lang" validate:"required,lang_code"`
+	Children []struct {
+		Name string `json:"name" validate:"required,min=2"`
+		Age  int    `json:"age" validate:"child_age"`
+	} `json:"children" validate:"dive"`
+}
+
+func TestViolations(t *testing.T) {
+	var req profileRequest
+	body := `{"password":"abcdefgh","level":"D1","lang":"zz","children":[{"name":"A","age":15}]}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	violations, ok := Violations(ValidateStruct(req))
+	if !ok {
+		t.Fatal("expected validation errors")
+	}
+	want := map[string]Violation{
+		"password":         {Field: "password", Rule: "password", Param: "8"},
+		"level":            {Field: "level", Rule: "cefr", Param: strings.Join(CEFRLevels, " ")},
+		"lang":             {Field: "lang", Rule: "lang_code"},
+		"children[0].name": {Field: "children[0].name", Rule: "min_length", Param: "2"},
+		"children[0].age":  {Field: "children[0].age", Rule: "child_age", Param: "3-12"},
+	}
+	if len(violations) != len(want) {
+		t.Errorf("got %d violations: %+v", len(violations), violations)
+	}
+	for _, v := range violations {
+		if w, ok := want[v.Field]; !ok || v != w {
+			t.Errorf("violation %+v, want %+v", v, w)
+		}
+	}
+
+	if _, ok := Violations(errors.New("boom")); ok {
+		t.Error("plain error reported as validation errors")
+	}
+}
+
+func TestCustomRulesAcceptValidValues(t *testing.T) {
+	ok := struct {
+		Password string `validate:"password"`
+		Level    string `validate:"cefr"`
+		Lang     string `validate:"lang_code"`
+		Age      int    `validate:"child_age"`
+	}{"secret123", "B2", "ru", 7}
+	if err := ValidateStruct(ok); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
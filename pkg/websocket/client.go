@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"time"
+
+	ws "github.com/fasthttp/websocket"
+)
+
+// Client — соединение, зарегистрированное в хабе
+type Client struct {
+	hub  *Hub
+	conn Conn
+	send chan []byte // закрывается хабом при отключении
+	// written закрывается, когда горутина записи закончила работу с conn
+	written chan struct{}
+
+	// rooms, closeCode и closeReason меняет только Hub.Run; горутина записи
+	// читает close* после закрытия send
+	rooms        map[string]struct{}
+	initialRooms []string
+	closeCode    int
+	closeReason  string
+}
+
+// Serve читает входящие сообщения и передаёт их в handle, пока соединение
+// открыто, затем отключает клиента. Блокируется; вызывается из обработчика
+// соединения. handle может быть nil, если клиент только получает сообщения.
+// Возвращается только после горутины записи: после выхода из обработчика
+// fasthttp закрывает соединение, и запись в него уже недопустима
+func (c *Client) Serve(handle func(msg []byte)) {
+	defer func() {
+		c.hub.Unregister(c)
+		<-c.written
+	}()
+
+	opts := c.hub.opts
+	c.conn.SetReadLimit(opts.MaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(opts.PongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(opts.PongTimeout))
+	})
+
+	for {
+		_, msg, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(opts.PongTimeout))
+		if handle != nil {
+			handle(msg)
+		}
+	}
+}
+
+// writeLoop отправляет сообщения из очереди и ping'и; единственный писатель в conn
+func (c *Client) writeLoop() {
+	opts := c.hub.opts
+	ticker := time.NewTicker(opts.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+		close(c.written)
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			deadline := time.Now().Add(opts.WriteTimeout)
+			if !ok {
+				_ = c.conn.WriteControl(ws.CloseMessage, ws.FormatCloseMessage(c.closeCode, c.closeReason), deadline)
+				return
+			}
+			c.conn.SetWriteDeadline(deadline)
+			if err := c.conn.WriteMessage(ws.TextMessage, msg); err != nil {
+				c.hub.Unregister(c)
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(ws.PingMessage, nil, time.Now().Add(opts.WriteTimeout)); err != nil {
+				c.hub.Unregister(c)
+				return
+			}
+		}
+	}
+}
@@ -1,29 +1,311 @@
+// Package websocket — хаб WebSocket-соединений с комнатами. Состоянием хаба
+// владеет одна горутина (Run), у каждого клиента своя очередь отправки и
+// горутина записи, поэтому медленный клиент не задерживает остальных.
+// Сообщения проходят через PubSub, так что с общим бэкендом публикация на
+// одном экземпляре доходит до клиентов всех экземпляров
 package websocket
 
 import (
-	"github.com/gofiber/websocket"
+	"context"
+	"time"
+
+	"engkids/pkg/logger"
+	"engkids/pkg/metrics"
+
+	ws "github.com/fasthttp/websocket"
 )
 
+// Conn — то, что хабу нужно от соединения; *websocket.Conn из fasthttp/websocket подходит
+type Conn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	SetReadLimit(limit int64)
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	SetPongHandler(h func(appData string) error)
+	Close() error
+}
+
+// Options — настройки хаба
+type Options struct {
+	SendBuffer     int           // сообщений в очереди клиента; при переполнении клиент отключается
+	WriteTimeout   time.Duration // дедлайн записи одного сообщения
+	PongTimeout    time.Duration // сколько ждать pong или сообщения от клиента
+	PingInterval   time.Duration // должен быть меньше PongTimeout
+	MaxMessageSize int64         // максимальный размер входящего сообщения
+
+	// PubSub рассылает сообщения между экземплярами; nil — только этот процесс
+	PubSub PubSub
+	// Metrics — реестр метрик хаба; nil — метрики не публикуются
+	Metrics *metrics.Registry
+	// Prepare, если задан, вызывается в горутине хаба перед рассылкой в комнату.
+	// seq — номер сообщения комнаты на этом экземпляре, начиная с 1. Сообщения
+	// в комнаты без участников на экземпляре не нумеруются, а опустевшая
+	// комната начинает счёт заново
+	Prepare func(room string, seq uint64, msg []byte) []byte
+}
+
+func (o *Options) setDefaults() {
+	if o.SendBuffer <= 0 {
+		o.SendBuffer = 64
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 10 * time.Second
+	}
+	if o.PongTimeout <= 0 {
+		o.PongTimeout = 60 * time.Second
+	}
+	if o.PingInterval <= 0 || o.PingInterval >= o.PongTimeout {
+		o.PingInterval = o.PongTimeout * 9 / 10
+	}
+	if o.MaxMessageSize <= 0 {
+		o.MaxMessageSize = 4096
+	}
+	if o.PubSub == nil {
+		o.PubSub = NewMemoryPubSub()
+	}
+	if o.Metrics == nil {
+		o.Metrics = metrics.NewRegistry()
+	}
+}
+
+type delivery struct {
+	room string // пусто — всем клиентам
+	msg  []byte
+}
+
+type membership struct {
+	client *Client
+	room   string
+	join   bool
+}
+
+type query struct {
+	room  string // пусто — все клиенты
+	reply chan int
+}
+
+// Hub рассылает сообщения подключённым клиентам: всем или участникам комнаты
+// (семьи, класса, игры). Все методы безопасны для параллельного вызова
 type Hub struct {
-	Clients   map[*websocket.Conn]bool
-	Broadcast chan []byte
+	opts Options
+
+	register   chan *Client
+	unregister chan *Client
+	broadcast  chan delivery
+	membership chan membership
+	queries    chan query
+	done       chan struct{}
+
+	connections *metrics.Gauge
+	evictions   *metrics.Counter
+
+	// состояние ниже меняет только Run
+	clients map[*Client]struct{}
+	rooms   map[string]map[*Client]struct{}
+	seq     map[string]uint64
 }
 
-func NewHub() *Hub {
+func NewHub(opts Options) *Hub {
+	opts.setDefaults()
 	return &Hub{
-		Clients:   make(map[*websocket.Conn]bool),
-		Broadcast: make(chan []byte),
+		opts:       opts,
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		broadcast:  make(chan delivery, 256),
+		membership: make(chan membership),
+		queries:    make(chan query),
+		done:       make(chan struct{}),
+		clients:    map[*Client]struct{}{},
+		rooms:      map[string]map[*Client]struct{}{},
+		seq:        map[string]uint64{},
+		connections: opts.Metrics.NewGauge(
+			"engkids_websocket_connections",
+			"Количество открытых WebSocket-соединений.",
+		),
+		evictions: opts.Metrics.NewCounter(
+			"engkids_websocket_evictions_total",
+			"Количество клиентов, отключённых из-за переполненной очереди отправки.",
+		),
 	}
 }
 
-func (h *Hub) Run() {
+// Run обслуживает хаб, пока не отменён ctx, после чего закрывает все соединения
+func (h *Hub) Run(ctx context.Context) {
+	err := h.opts.PubSub.Subscribe(ctx, func(room string, msg []byte) {
+		select {
+		case h.broadcast <- delivery{room: room, msg: msg}:
+		case <-h.done:
+		}
+	})
+	if err != nil {
+		// без подписки сообщения не дойдут до клиентов этого экземпляра
+		logger.FromContext(ctx).WithError(err).Error("Failed to subscribe WebSocket hub to pub/sub")
+	}
+
+	defer func() {
+		close(h.done)
+		for c := range h.clients {
+			h.remove(c, ws.CloseGoingAway, "server shutting down")
+		}
+	}()
+
 	for {
-		msg := <-h.Broadcast
-		for client := range h.Clients {
-			err := client.WriteMessage(websocket.TextMessage, msg)
-			if err != nil {
-				return
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-h.register:
+			h.clients[c] = struct{}{}
+			h.connections.Inc()
+			for _, room := range c.initialRooms {
+				h.join(c, room)
+			}
+			c.initialRooms = nil
+		case c := <-h.unregister:
+			if _, ok := h.clients[c]; ok {
+				h.remove(c, ws.CloseNormalClosure, "")
+			}
+		case m := <-h.membership:
+			if _, ok := h.clients[m.client]; !ok {
+				continue
+			}
+			if m.join {
+				h.join(m.client, m.room)
+			} else {
+				h.leave(m.client, m.room)
+			}
+		case d := <-h.broadcast:
+			h.deliver(d)
+		case q := <-h.queries:
+			if q.room == "" {
+				q.reply <- len(h.clients)
+			} else {
+				q.reply <- len(h.rooms[q.room])
 			}
 		}
 	}
 }
+
+func (h *Hub) join(c *Client, room string) {
+	members, ok := h.rooms[room]
+	if !ok {
+		members = map[*Client]struct{}{}
+		h.rooms[room] = members
+	}
+	members[c] = struct{}{}
+	c.rooms[room] = struct{}{}
+}
+
+func (h *Hub) leave(c *Client, room string) {
+	delete(c.rooms, room)
+	if members, ok := h.rooms[room]; ok {
+		delete(members, c)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+			delete(h.seq, room)
+		}
+	}
+}
+
+func (h *Hub) deliver(d delivery) {
+	targets := h.clients
+	if d.room != "" {
+		targets = h.rooms[d.room]
+		// seq хранится только для комнат с участниками на этом экземпляре,
+		// иначе счётчики всех комнат кластера копились бы без конца
+		if len(targets) == 0 {
+			return
+		}
+		if h.opts.Prepare != nil {
+			h.seq[d.room]++
+			d.msg = h.opts.Prepare(d.room, h.seq[d.room], d.msg)
+		}
+	}
+	for c := range targets {
+		select {
+		case c.send <- d.msg:
+		default:
+			// клиент не успевает читать: отключаем, чтобы не копить память
+			h.evictions.Inc()
+			h.remove(c, ws.CloseTryAgainLater, "slow consumer")
+		}
+	}
+}
+
+// remove отключает клиента: горутина записи отправит close-фрейм и закроет соединение
+func (h *Hub) remove(c *Client, code int, reason string) {
+	for room := range c.rooms {
+		h.leave(c, room)
+	}
+	delete(h.clients, c)
+	h.connections.Dec()
+	c.closeCode, c.closeReason = code, reason
+	close(c.send)
+}
+
+// Register добавляет соединение в хаб и в комнаты rooms и запускает горутину
+// записи. Входящие сообщения читает Client.Serve
+func (h *Hub) Register(conn Conn, rooms ...string) *Client {
+	c := &Client{
+		hub:          h,
+		conn:         conn,
+		send:         make(chan []byte, h.opts.SendBuffer),
+		written:      make(chan struct{}),
+		rooms:        map[string]struct{}{},
+		initialRooms: rooms,
+	}
+	select {
+	case h.register <- c:
+		go c.writeLoop()
+	case <-h.done:
+		conn.Close()
+		close(c.written)
+	}
+	return c
+}
+
+// Unregister отключает клиента; повторный вызов ничего не делает
+func (h *Hub) Unregister(c *Client) {
+	select {
+	case h.unregister <- c:
+	case <-h.done:
+	}
+}
+
+// Join добавляет клиента в комнату
+func (h *Hub) Join(c *Client, room string) {
+	select {
+	case h.membership <- membership{client: c, room: room, join: true}:
+	case <-h.done:
+	}
+}
+
+// Leave убирает клиента из комнаты
+func (h *Hub) Leave(c *Client, room string) {
+	select {
+	case h.membership <- membership{client: c, room: room}:
+	case <-h.done:
+	}
+}
+
+// Broadcast отправляет сообщение всем клиентам всех экземпляров
+func (h *Hub) Broadcast(ctx context.Context, msg []byte) error {
+	return h.Publish(ctx, "", msg)
+}
+
+// Publish отправляет сообщение участникам комнаты room на всех экземплярах
+func (h *Hub) Publish(ctx context.Context, room string, msg []byte) error {
+	return h.opts.PubSub.Publish(ctx, room, msg)
+}
+
+// Count возвращает число клиентов в комнате room, для пустой — всех клиентов
+func (h *Hub) Count(room string) int {
+	q := query{room: room, reply: make(chan int, 1)}
+	select {
+	case h.queries <- q:
+		return <-q.reply
+	case <-h.done:
+		return 0
+	}
+}
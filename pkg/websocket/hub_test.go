@@ -0,0 +1,300 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	ws "github.com/fasthttp/websocket"
+)
+
+// fakeConn — соединение в памяти: входящие сообщения приходят из in, записанные
+// складываются в out. block задерживает запись, имитируя медленного клиента
+type fakeConn struct {
+	in    chan []byte
+	out   chan []byte
+	block chan struct{}
+
+	mu        sync.Mutex
+	closed    bool
+	closeCode int
+	pings     int
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{in: make(chan []byte), out: make(chan []byte, 100)}
+}
+
+var errClosed = errors.New("closed")
+
+func (c *fakeConn) ReadMessage() (int, []byte, error) {
+	msg, ok := <-c.in
+	if !ok {
+		return 0, nil, errClosed
+	}
+	return ws.TextMessage, msg, nil
+}
+
+func (c *fakeConn) WriteMessage(_ int, data []byte) error {
+	if c.block != nil {
+		<-c.block
+	}
+	c.out <- data
+	return nil
+}
+
+func (c *fakeConn) WriteControl(messageType int, data []byte, _ time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch messageType {
+	case ws.PingMessage:
+		c.pings++
+	case ws.CloseMessage:
+		c.closeCode = int(data[0])<<8 | int(data[1])
+	}
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) SetReadLimit(int64)                {}
+func (c *fakeConn) SetReadDeadline(time.Time) error   { return nil }
+func (c *fakeConn) SetWriteDeadline(time.Time) error  { return nil }
+func (c *fakeConn) SetPongHandler(func(string) error) {}
+
+func (c *fakeConn) state() (closed bool, code, pings int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed, c.closeCode, c.pings
+}
+
+func startHub(t *testing.T, opts Options) *Hub {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewHub(opts)
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return h
+}
+
+func expectMessage(t *testing.T, c *fakeConn, want string) {
+	t.Helper()
+	select {
+	case got := <-c.out:
+		if string(got) != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no message, want %q", want)
+	}
+}
+
+func expectNoMessage(t *testing.T, c *fakeConn) {
+	t.Helper()
+	select {
+	case got := <-c.out:
+		t.Fatalf("unexpected message %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPublishToRoom(t *testing.T) {
+	ctx := t.Context()
+	h := startHub(t, Options{})
+	a, b, other := newFakeConn(), newFakeConn(), newFakeConn()
+	ca := h.Register(a, "family:1")
+	h.Register(b, "family:1", "game:7")
+	h.Register(other, "family:2")
+
+	if n := h.Count("family:1"); n != 2 {
+		t.Fatalf("family:1 has %d clients", n)
+	}
+
+	h.Publish(ctx, "family:1", []byte("lesson done"))
+	expectMessage(t, a, "lesson done")
+	expectMessage(t, b, "lesson done")
+	expectNoMessage(t, other)
+
+	h.Leave(ca, "family:1")
+	h.Join(ca, "game:7")
+	h.Publish(ctx, "game:7", []byte("round 1"))
+	expectMessage(t, a, "round 1")
+	expectMessage(t, b, "round 1")
+
+	h.Broadcast(ctx, []byte("maintenance"))
+	for _, c := range []*fakeConn{a, b, other} {
+		expectMessage(t, c, "maintenance")
+	}
+}
+
+func TestServeUnregistersOnReadError(t *testing.T) {
+	h := startHub(t, Options{})
+	conn := newFakeConn()
+	c := h.Register(conn, "family:1")
+
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		c.Serve(func(msg []byte) { got = append(got, string(msg)) })
+		close(done)
+	}()
+
+	conn.in <- []byte("hello")
+	close(conn.in)
+	<-done
+
+	if len(got) != 1 || got[0] != "hello" {
+		t.Errorf("handled %v", got)
+	}
+	if n := h.Count(""); n != 0 {
+		t.Errorf("%d clients left after disconnect", n)
+	}
+	if n := h.Count("family:1"); n != 0 {
+		t.Errorf("room not cleaned up: %d", n)
+	}
+	// Serve возвращается, когда close-фрейм уже записан и соединение закрыто
+	if closed, code, _ := conn.state(); !closed || code != ws.CloseNormalClosure {
+		t.Errorf("Serve returned before the writer: closed=%v code=%d", closed, code)
+	}
+}
+
+func TestSlowConsumerIsEvicted(t *testing.T) {
+	ctx := t.Context()
+	h := startHub(t, Options{SendBuffer: 2})
+	slow, fast := newFakeConn(), newFakeConn()
+	slow.block = make(chan struct{})
+	h.Register(slow, "room")
+	h.Register(fast, "room")
+
+	for _, msg := range []string{"1", "2", "3", "4"} {
+		h.Publish(ctx, "room", []byte(msg))
+		expectMessage(t, fast, msg)
+	}
+
+	if n := h.Count("room"); n != 1 {
+		t.Fatalf("room has %d clients, slow consumer not evicted", n)
+	}
+	close(slow.block)
+	waitFor(t, func() bool {
+		closed, code, _ := slow.state()
+		return closed && code == ws.CloseTryAgainLater
+	})
+}
+
+func TestPing(t *testing.T) {
+	h := startHub(t, Options{PongTimeout: 50 * time.Millisecond, PingInterval: 10 * time.Millisecond})
+	conn := newFakeConn()
+	h.Register(conn)
+	waitFor(t, func() bool { _, _, pings := conn.state(); return pings >= 2 })
+}
+
+func TestShutdownClosesClients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewHub(Options{})
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+	conn := newFakeConn()
+	h.Register(conn, "room")
+	h.Count("") // дожидаемся регистрации
+
+	cancel()
+	<-done
+	waitFor(t, func() bool {
+		closed, code, _ := conn.state()
+		return closed && code == ws.CloseGoingAway
+	})
+
+	// после остановки вызовы не блокируются
+	h.Publish(ctx, "room", []byte("late"))
+	late := newFakeConn()
+	close(late.in)
+	h.Register(late).Serve(nil)
+	if closed, _, _ := late.state(); !closed {
+		t.Error("connection registered after shutdown left open")
+	}
+}
+
+func TestSharedPubSubReachesAllHubs(t *testing.T) {
+	ctx := t.Context()
+	ps := NewMemoryPubSub()
+	prepare := func(room string, seq uint64, msg []byte) []byte {
+		return []byte(fmt.Sprintf("%s#%d:%s", room, seq, msg))
+	}
+	first := startHub(t, Options{PubSub: ps, Prepare: prepare})
+	second := startHub(t, Options{PubSub: ps, Prepare: prepare})
+
+	a, b := newFakeConn(), newFakeConn()
+	first.Register(a, "family:1")
+	second.Register(b, "family:1")
+
+	// опубликовано на одном экземпляре — доходит до клиентов обоих
+	if err := first.Publish(ctx, "family:1", []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Publish(ctx, "family:1", []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*fakeConn{a, b} {
+		expectMessage(t, c, "family:1#1:one")
+		expectMessage(t, c, "family:1#2:two")
+	}
+}
+
+// Счётчики seq есть только у комнат с участниками на этом экземпляре
+func TestSeqOnlyForLocalRooms(t *testing.T) {
+	ctx := t.Context()
+	prepare := func(room string, seq uint64, msg []byte) []byte {
+		return []byte(fmt.Sprintf("%s#%d:%s", room, seq, msg))
+	}
+	h := startHub(t, Options{Prepare: prepare})
+	probe := newFakeConn()
+	h.Register(probe, "probe")
+	for range 3 {
+		h.Publish(ctx, "game:ABC123", []byte("nobody"))
+	}
+	// рассылка идёт по порядку: дошло это — обработаны и предыдущие
+	h.Publish(ctx, "probe", []byte("sync"))
+	expectMessage(t, probe, "probe#1:sync")
+
+	conn := newFakeConn()
+	c := h.Register(conn, "game:ABC123")
+	h.Publish(ctx, "game:ABC123", []byte("one"))
+	expectMessage(t, conn, "game:ABC123#1:one")
+
+	h.Leave(c, "game:ABC123")
+	h.Join(c, "game:ABC123")
+	h.Publish(ctx, "game:ABC123", []byte("again"))
+	expectMessage(t, conn, "game:ABC123#1:again")
+	if n := len(h.seq); n != 2 {
+		t.Errorf("seq kept for %d rooms", n)
+	}
+}
@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+)
+
+// PubSub доставляет сообщения хабам всех экземпляров приложения. Хаб публикует
+// через него и получает из него всё, что опубликовано, включая свои сообщения
+type PubSub interface {
+	Publish(ctx context.Context, room string, msg []byte) error
+	// Subscribe возвращается, когда подписка установлена, и дальше вызывает
+	// deliver для каждого сообщения, пока не отменён ctx. Порядок сообщений
+	// одинаков для всех подписчиков
+	Subscribe(ctx context.Context, deliver func(room string, msg []byte)) error
+}
+
+// MemoryPubSub — PubSub внутри одного процесса
+type MemoryPubSub struct {
+	mu   sync.Mutex
+	subs map[*memorySub]struct{}
+}
+
+type memorySub struct {
+	deliver func(room string, msg []byte)
+}
+
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{subs: map[*memorySub]struct{}{}}
+}
+
+// Publish вызывает подписчиков синхронно под блокировкой, поэтому порядок
+// сообщений у всех одинаков
+func (p *MemoryPubSub) Publish(_ context.Context, room string, msg []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for s := range p.subs {
+		s.deliver(room, msg)
+	}
+	return nil
+}
+
+func (p *MemoryPubSub) Subscribe(ctx context.Context, deliver func(room string, msg []byte)) error {
+	s := &memorySub{deliver: deliver}
+	p.mu.Lock()
+	p.subs[s] = struct{}{}
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		delete(p.subs, s)
+		p.mu.Unlock()
+	}()
+	return nil
+}